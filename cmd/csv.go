@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/S7evinK/issues-to-go/pkg/archive"
+	"github.com/S7evinK/issues-to-go/pkg/spreadsheet"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// csvFile is the name of the CSV file updated after downloading issues
+const csvFile = "issues.csv"
+
+// csvCmd exports the metadata of issues as CSV file
+var csvCmd = &cobra.Command{
+	Use:   "csv [issue numbers]",
+	Short: "Exports the metadata of issues as CSV file",
+	Long: `Writes one row per selected issue to a CSV file, which can be opened by spreadsheet applications.
+
+Use the --csv flag when downloading issues to update the file ` + csvFile + ` in the output folder automatically.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		issues, err := selectIssues(cmd, args)
+		if err != nil {
+			log.Fatal("Unable to select issues: ", err)
+		}
+
+		file, _ := cmd.Flags().GetString("file")
+		columns, _ := cmd.Flags().GetStringSlice("columns")
+		w := os.Stdout
+		if file != "-" {
+			f, err := os.Create(file)
+			if err != nil {
+				log.Fatal("Unable to create file: ", err)
+			}
+			defer f.Close()
+			w = f
+		}
+
+		if err := spreadsheet.Write(w, issues, columns); err != nil {
+			log.Fatal("Unable to write CSV: ", err)
+		}
+	},
+}
+
+func init() {
+	exportCmd.AddCommand(csvCmd)
+
+	csvCmd.Flags().StringP("file", "f", "-", "File to write the CSV to, - writes to stdout")
+	csvCmd.Flags().StringSlice("columns", spreadsheet.DefaultColumns, "Columns to write, available: "+strings.Join(spreadsheet.Columns(), ", "))
+	addSelectionFlags(csvCmd)
+}
+
+// updateCSV replaces the CSV file in the output folder
+func updateCSV(output string, issues []*archive.Issue) error {
+	if err := spreadsheet.WriteFile(filepath.Join(output, csvFile), issues, viper.GetStringSlice("csv-columns")); err != nil {
+		return errors.Wrap(err, "unable to write CSV")
+	}
+	log.Printf("Wrote %d issue(s) to %s\n", len(issues), csvFile)
+	return nil
+}
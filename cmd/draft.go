@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/S7evinK/issues-to-go/pkg/archive"
+	"github.com/S7evinK/issues-to-go/pkg/draft"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// draftCmd starts a comment offline
+var draftCmd = &cobra.Command{
+	Use:   "draft <number>",
+	Short: "Starts a comment to a downloaded issue offline",
+	Long: `Creates the file ` + draft.Dir + `/NUMBER.md in the output folder for a comment to the issue and opens it
+in $EDITOR, if set. The file records the number of comments of the issue, so push can detect
+comments added in the meantime. Drafts can also be written without this command, then the comments
+in the archive at the time of the push are taken as the state the draft is based on.
+
+Drafts are posted with push.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		output := viper.GetString("output")
+		number, err := strconv.Atoi(strings.TrimPrefix(args[0], "#"))
+		if err != nil {
+			log.Fatalf("Invalid issue number %q", args[0])
+		}
+		issue, err := archive.Find(output, number)
+		if os.IsNotExist(err) {
+			log.Fatalf("Issue %d not found in %s", number, output)
+		}
+		if err != nil {
+			log.Fatal("Unable to read issue: ", err)
+		}
+
+		path := draft.Path(output, number)
+		if _, err := draft.Create(output, issue, time.Now().Truncate(time.Second)); os.IsExist(err) {
+			log.Printf("Continuing the draft %s\n", path)
+		} else if err != nil {
+			log.Fatal("Unable to create draft: ", err)
+		}
+
+		editor := os.Getenv("EDITOR")
+		if editor == "" || !isTerminal(os.Stdin) {
+			fmt.Println(path)
+			return
+		}
+		c := exec.Command("sh", "-c", editor+` "$1"`, "sh", path)
+		c.Stdin, c.Stdout, c.Stderr = os.Stdin, os.Stdout, os.Stderr
+		if err := c.Run(); err != nil {
+			log.Fatal("Unable to run the editor: ", err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(draftCmd)
+}
@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/S7evinK/issues-to-go/pkg/epub"
+	"github.com/spf13/cobra"
+)
+
+// epubCmd exports issues as EPUB book
+var epubCmd = &cobra.Command{
+	Use:   "epub [issue numbers]",
+	Short: "Exports issues as EPUB book for e-readers",
+	Long: `Bundles the selected issues into a single EPUB book.
+Every issue is a chapter, every comment a section in the table of contents. References between issues in the book are linked, local images are embedded.
+Remote images are only embedded with --download-images, otherwise e-readers need network access to show them.`,
+	Example: `Create a book of all issues of the milestone "v1.0":
+	issues-to-go export epub --milestone v1.0 -f v1.0.epub
+
+Create a book of some issues:
+	issues-to-go export epub 12 15 23`,
+	Run: func(cmd *cobra.Command, args []string) {
+		issues, err := selectIssues(cmd, args)
+		if err != nil {
+			log.Fatal("Unable to select issues: ", err)
+		}
+
+		title, _ := cmd.Flags().GetString("title")
+		var fetch epub.Fetcher
+		if download, _ := cmd.Flags().GetBool("download-images"); download {
+			fetch = epub.Download(&http.Client{Timeout: 30 * time.Second})
+		}
+		book, err := epub.FromIssues(title, issues, fetch)
+		if err != nil {
+			log.Fatal("Unable to create book: ", err)
+		}
+		if skipped := book.Skipped(); len(skipped) > 0 {
+			if fetch == nil {
+				log.Printf("%d remote image(s) are linked, use --download-images to embed them:\n", len(skipped))
+			} else {
+				log.Printf("%d remote image(s) couldn't be embedded and are linked:\n", len(skipped))
+			}
+			for _, s := range skipped {
+				log.Println("  " + s)
+			}
+		}
+
+		file, _ := cmd.Flags().GetString("file")
+		f, err := os.Create(file)
+		if err != nil {
+			log.Fatal("Unable to create file: ", err)
+		}
+		defer f.Close()
+
+		if err := book.Write(f); err != nil {
+			log.Fatal("Unable to write book: ", err)
+		}
+		log.Printf("Exported %d issue(s) to %s\n", len(issues), file)
+	},
+}
+
+func init() {
+	exportCmd.AddCommand(epubCmd)
+
+	epubCmd.Flags().StringP("file", "f", "issues.epub", "File to write the book to")
+	epubCmd.Flags().String("title", "Issues", "Title of the book")
+	epubCmd.Flags().Bool("download-images", false, "Download remote images and embed them in the book")
+	addSelectionFlags(epubCmd)
+}
@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/S7evinK/issues-to-go/pkg/archive"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// exportCmd groups the commands converting the archive to other formats
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Exports downloaded issues to other formats",
+	Long: `Exports issues from the output folder to other formats.
+The issues are read from the local archive, no requests to Github are made.`,
+}
+
+func init() {
+	rootCmd.AddCommand(exportCmd)
+}
+
+// addSelectionFlags adds the flags used by selectIssues
+func addSelectionFlags(cmd *cobra.Command) {
+	cmd.Flags().String("milestone", "", "Only use issues of this milestone")
+	cmd.Flags().String("label", "", "Only use issues with this label (requires front matter)")
+	cmd.Flags().String("state", "", "Only use issues with this state (open or closed)")
+}
+
+// selectIssues loads the issues from the output folder and filters them by the selection flags.
+// If issue numbers are given as arguments, only these issues are used.
+func selectIssues(cmd *cobra.Command, args []string) ([]*archive.Issue, error) {
+	issues, err := archive.Load(viper.GetString("output"))
+	if err != nil && len(issues) == 0 {
+		return nil, err
+	}
+
+	f := archive.Filter{}
+	if f.Numbers, err = issueNumbers(args); err != nil {
+		return nil, err
+	}
+	f.Milestone, _ = cmd.Flags().GetString("milestone")
+	f.Label, _ = cmd.Flags().GetString("label")
+	f.State, _ = cmd.Flags().GetString("state")
+
+	selected := f.Apply(issues)
+	if len(selected) == 0 {
+		return nil, errors.New("no issues found")
+	}
+	return selected, nil
+}
+
+// issueNumbers parses issue numbers given as arguments, with or without a leading #
+func issueNumbers(args []string) (map[int]bool, error) {
+	numbers := make(map[int]bool)
+	for _, arg := range args {
+		n, err := strconv.Atoi(strings.TrimPrefix(arg, "#"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid issue number %q", arg)
+		}
+		numbers[n] = true
+	}
+	return numbers, nil
+}
+
+// updateExports refreshes the files in the output folder, which are created from the archive or the changes of the last sync
+func updateExports(output, repo string, changes []archive.Change) error {
+	if viper.GetBool("feed") {
+		if err := updateFeed(output, repo, changes); err != nil {
+			return err
+		}
+	}
+	if !viper.GetBool("mbox") && !viper.GetBool("csv") {
+		return nil
+	}
+	issues, err := archive.Load(output)
+	if err != nil {
+		log.Println("Unable to read all issues:", err)
+	}
+	if viper.GetBool("mbox") {
+		if err := updateMbox(output, repo, issues); err != nil {
+			return err
+		}
+	}
+	if viper.GetBool("csv") {
+		return updateCSV(output, issues)
+	}
+	return nil
+}
@@ -0,0 +1,26 @@
+package cmd
+
+import (
+	"log"
+	"path/filepath"
+	"time"
+
+	"github.com/S7evinK/issues-to-go/pkg/archive"
+	"github.com/S7evinK/issues-to-go/pkg/feed"
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+)
+
+// feedFile is the name of the Atom feed in the output folder
+const feedFile = "feed.atom"
+
+// updateFeed adds the changes of the last sync to the Atom feed in the output folder
+func updateFeed(output, repo string, changes []archive.Change) error {
+	window := time.Duration(viper.GetInt("feed-days")) * 24 * time.Hour
+	count, err := feed.Update(filepath.Join(output, feedFile), repo, changes, window, time.Now())
+	if err != nil {
+		return errors.Wrap(err, "unable to update feed")
+	}
+	log.Printf("Added %d entries to %s\n", count, feedFile)
+	return nil
+}
@@ -0,0 +1,175 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/S7evinK/issues-to-go/pkg/archive"
+	"github.com/S7evinK/issues-to-go/pkg/query"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// dateLayout is the layout of dates given as flags and printed in tables
+const dateLayout = "2006-01-02"
+
+// listCmd prints the issues of the archive
+var listCmd = &cobra.Command{
+	Use:   "list [query]",
+	Short: "Lists downloaded issues",
+	Long: `Prints a table of the issues in the output folder, optionally filtered and sorted.
+The issues are read from the local archive, no requests to Github are made.
+
+Issues can be selected with the flags or with a Github search query, eg.
+  issues-to-go list is:open label:bug author:foo milestone:"v2" updated:>2019-01-01 sort:comments-desc`,
+	Run: func(cmd *cobra.Command, args []string) {
+		f, err := listFilter(cmd)
+		if err != nil {
+			log.Fatal(err)
+		}
+		q := parseQuery(args)
+		issues, err := archive.Load(viper.GetString("output"))
+		if err != nil {
+			log.Println("Unable to read all issues:", err)
+		}
+		issues = q.Apply(f.Apply(issues))
+
+		key, _ := cmd.Flags().GetString("sort")
+		reverse, _ := cmd.Flags().GetBool("reverse")
+		if q.SortKey != "" && !cmd.Flags().Changed("sort") {
+			key, reverse = q.SortKey, q.SortDesc
+		}
+		if err := archive.Sort(issues, key, reverse); err != nil {
+			log.Fatal(err)
+		}
+
+		format, _ := cmd.Flags().GetString("format")
+		switch format {
+		case "table":
+			err = printTable(os.Stdout, issues)
+		case "json":
+			err = printJSON(os.Stdout, issues)
+		default:
+			err = fmt.Errorf("unknown format %q, available: table, json", format)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(listCmd)
+
+	listCmd.Flags().String("state", "", "Only list issues with this state (open or closed)")
+	listCmd.Flags().String("milestone", "", "Only list issues of this milestone")
+	listCmd.Flags().String("author", "", "Only list issues created by this user")
+	listCmd.Flags().String("label", "", "Only list issues with this label (requires front matter)")
+	listCmd.Flags().String("since", "", "Only list issues created on or after this date (YYYY-MM-DD)")
+	listCmd.Flags().String("until", "", "Only list issues created on or before this date (YYYY-MM-DD)")
+	listCmd.Flags().StringP("text", "t", "", "Only list issues containing this text in the title, body or comments")
+	listCmd.Flags().StringP("sort", "s", "number", "Sort by "+strings.Join(archive.SortKeys(), ", "))
+	listCmd.Flags().Bool("reverse", false, "Reverse the sort order")
+	listCmd.Flags().StringP("format", "f", "table", "Output format: table or json")
+}
+
+// parseQuery parses the Github search query given as arguments, qualifiers which are ignored are logged
+func parseQuery(args []string) *query.Query {
+	q, err := query.Parse(strings.Join(args, " "))
+	if err != nil {
+		log.Fatal("Invalid query: ", err)
+	}
+	for _, ignored := range q.Ignored {
+		log.Printf("Ignoring %s, it can't be evaluated offline\n", ignored)
+	}
+	return q
+}
+
+// listFilter creates the filter from the flags of the list command
+func listFilter(cmd *cobra.Command) (archive.Filter, error) {
+	f := archive.Filter{}
+	f.State, _ = cmd.Flags().GetString("state")
+	f.Milestone, _ = cmd.Flags().GetString("milestone")
+	f.Author, _ = cmd.Flags().GetString("author")
+	f.Label, _ = cmd.Flags().GetString("label")
+	f.Text, _ = cmd.Flags().GetString("text")
+
+	var err error
+	since, _ := cmd.Flags().GetString("since")
+	if since != "" {
+		if f.Since, err = time.ParseInLocation(dateLayout, since, time.Local); err != nil {
+			return f, errors.Wrap(err, "invalid date for --since")
+		}
+	}
+	until, _ := cmd.Flags().GetString("until")
+	if until != "" {
+		if f.Until, err = time.ParseInLocation(dateLayout, until, time.Local); err != nil {
+			return f, errors.Wrap(err, "invalid date for --until")
+		}
+		f.Until = f.Until.AddDate(0, 0, 1)
+	}
+	return f, nil
+}
+
+func printTable(w io.Writer, issues []*archive.Issue) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "#\tSTATE\tCREATED\tUPDATED\tCOMMENTS\tAUTHOR\tMILESTONE\tTITLE")
+	for _, i := range issues {
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%d\t%s\t%s\t%s\n", i.Number, i.State, i.CreatedAt.Format(dateLayout),
+			i.LastActivity().Format(dateLayout), len(i.Comments), i.Author, i.Milestone, i.Title)
+	}
+	return tw.Flush()
+}
+
+// listEntry is an issue printed by the list command in the JSON format
+type listEntry struct {
+	Number    int        `json:"number"`
+	Title     string     `json:"title"`
+	State     string     `json:"state"`
+	Author    string     `json:"author"`
+	CreatedAt time.Time  `json:"created"`
+	ClosedAt  *time.Time `json:"closed,omitempty"`
+	UpdatedAt time.Time  `json:"updated"`
+	Milestone string     `json:"milestone,omitempty"`
+	Labels    []string   `json:"labels,omitempty"`
+	Assignees []string   `json:"assignees,omitempty"`
+	Comments  int        `json:"comments"`
+	URL       string     `json:"url,omitempty"`
+	Path      string     `json:"path"`
+}
+
+func printJSON(w io.Writer, issues []*archive.Issue) error {
+	entries := make([]listEntry, 0, len(issues))
+	for _, i := range issues {
+		e := listEntry{
+			Number:    i.Number,
+			Title:     i.Title,
+			State:     i.State,
+			Author:    i.Author,
+			CreatedAt: i.CreatedAt,
+			UpdatedAt: i.LastActivity(),
+			Milestone: i.Milestone,
+			Labels:    i.Labels,
+			Assignees: i.Assignees,
+			Comments:  len(i.Comments),
+			URL:       i.URL,
+			Path:      i.Path,
+		}
+		if !i.ClosedAt.IsZero() {
+			closed := i.ClosedAt
+			e.ClosedAt = &closed
+		}
+		entries = append(entries, e)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	return enc.Encode(entries)
+}
@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	"github.com/S7evinK/issues-to-go/pkg/archive"
+	"github.com/S7evinK/issues-to-go/pkg/mbox"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// mboxFile is the name of the mbox file updated after downloading issues
+const mboxFile = "issues.mbox"
+
+// mboxCmd exports issues as mbox file
+var mboxCmd = &cobra.Command{
+	Use:   "mbox [issue numbers]",
+	Short: "Exports issues as mail threads in a mbox file",
+	Long: `Writes the selected issues as mail threads to a mbox file, which can be opened by mail clients like mutt or Thunderbird.
+Every issue is the root message of a thread, every comment is a reply to it.
+The Message-IDs contain the host of the issues, which is unknown for archives without front matter. Their issues
+are assumed to be on Github, or on the GitLab instance given by gitlab-url in the config file.
+
+Use the --mbox flag when downloading issues to update the file ` + mboxFile + ` in the output folder automatically.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		issues, err := selectIssues(cmd, args)
+		if err != nil {
+			log.Fatal("Unable to select issues: ", err)
+		}
+
+		file, _ := cmd.Flags().GetString("file")
+		f, err := os.Create(file)
+		if err != nil {
+			log.Fatal("Unable to create file: ", err)
+		}
+		defer f.Close()
+
+		if err := mbox.Write(f, mboxHost(), viper.GetString("repo"), issues); err != nil {
+			log.Fatal("Unable to write mbox: ", err)
+		}
+		log.Printf("Exported %d issue(s) to %s\n", len(issues), file)
+	},
+}
+
+func init() {
+	exportCmd.AddCommand(mboxCmd)
+
+	mboxCmd.Flags().StringP("file", "f", "issues.mbox", "File to write the messages to")
+	addSelectionFlags(mboxCmd)
+}
+
+// updateMbox appends new issues and comments of the archive to the mbox file in the output folder
+func updateMbox(output, repo string, issues []*archive.Issue) error {
+	count, err := mbox.Append(filepath.Join(output, mboxFile), mboxHost(), repo, issues)
+	if err != nil {
+		return errors.Wrap(err, "unable to update mbox")
+	}
+	log.Printf("Added %d message(s) to %s\n", count, mboxFile)
+	return nil
+}
+
+// mboxHost returns the host of the issues without URL, which is the host of --gitlab-url if it's set
+func mboxHost() string {
+	u, err := url.Parse(viper.GetString("gitlab-url"))
+	if err != nil {
+		return ""
+	}
+	return u.Hostname()
+}
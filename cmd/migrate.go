@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/S7evinK/issues-to-go/pkg/archive"
+	"github.com/S7evinK/issues-to-go/pkg/gh"
+	"github.com/S7evinK/issues-to-go/pkg/migrate"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// migrateCmd recreates the archived issues in another repository
+var migrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Recreates the archived issues in another repository",
+	Long: `Creates the issues of the output folder and their comments in the repository given by --to, in the
+order of their numbers. The original authors and dates are added to the top of every issue and comment,
+closed issues are closed after their comments are posted.
+
+Links to other issues of the archive are rewritten to the new numbers. Labels which don't exist in the
+target are left out, milestones are mapped by title or by --milestone-map and left out if they don't exist.
+
+Every created issue and comment is recorded in a mapping file (.meta/migrate-OWNER_REPO.json in the
+output folder or --mapping), so an interrupted migration continues where it stopped and never creates
+anything twice. SIGINT and SIGTERM stop the migration after the current issue or comment.`,
+	Example: `  issues-to-go migrate -r S7evinK/issues-to-go --to S7evinK/issues --milestone-map v1.0=1.0`,
+	Run: func(cmd *cobra.Command, args []string) {
+		output := viper.GetString("output")
+		to, _ := cmd.Flags().GetString("to")
+		mappingFile, _ := cmd.Flags().GetString("mapping")
+		msMap, _ := cmd.Flags().GetStringToString("milestone-map")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		if to == "" {
+			log.Fatal("The target repository is missing, use --to OWNER/REPOSITORY")
+		}
+
+		issues, err := archive.Load(output)
+		if err != nil {
+			log.Println(err)
+		}
+		if len(issues) == 0 {
+			log.Fatal("No issues to migrate in ", output)
+		}
+		source := viper.GetString("repo")
+		if source == "" {
+			source = issues[0].Repository()
+		}
+		if source == "" {
+			log.Fatal("Unable to determine the source repository, use --repo")
+		}
+		if source == to {
+			log.Fatal("The target must be another repository")
+		}
+		if mappingFile == "" {
+			mappingFile = filepath.Join(output, archive.MetaDir, "migrate-"+strings.Replace(to, "/", "_", -1)+".json")
+		}
+		mapping, err := migrate.OpenMapping(mappingFile, source, to)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		cl, err := gh.New(
+			gh.Output(output),
+			gh.Count(viper.GetInt("count")),
+			gh.Repo(to),
+			gh.Token(viper.GetString("GITHUB_TOKEN")),
+		)
+		if err != nil {
+			log.Fatal("Unable to create new github client: ", err)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		signals := make(chan os.Signal, 2)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+		go func() {
+			sig := <-signals
+			log.Printf("Received %v, stopping after the current step\n", sig)
+			cancel()
+			<-signals
+			log.Fatal("Stopped immediately")
+		}()
+
+		m := &migrate.Migrator{
+			Target:     cl,
+			Mapping:    mapping,
+			Milestones: msMap,
+			DryRun:     dryRun,
+			Log: func(format string, args ...interface{}) {
+				log.Printf(format+"\n", args...)
+			},
+		}
+		if err := m.Run(ctx, issues); err != nil {
+			log.Fatal("Migration stopped, run it again to continue: ", err)
+		}
+		if !dryRun {
+			log.Printf("Migrated %d issue(s) to %s, mapping in %s\n", len(mapping.Issues), to, mappingFile)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(migrateCmd)
+
+	migrateCmd.Flags().String("to", "", "Target repository (OWNER/REPOSITORY)")
+	migrateCmd.Flags().StringToString("milestone-map", nil, "Maps milestones of the archive to milestones of the target (OLD=NEW)")
+	migrateCmd.Flags().String("mapping", "", "Mapping file of the migration (default .meta/migrate-OWNER_REPO.json in the output folder)")
+	migrateCmd.Flags().BoolP("dry-run", "n", false, "Only report what would be migrated")
+}
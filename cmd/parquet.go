@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/S7evinK/issues-to-go/pkg/archive"
+	"github.com/S7evinK/issues-to-go/pkg/dataset"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// parquetCmd exports issues, comments and events as Parquet tables
+var parquetCmd = &cobra.Command{
+	Use:   "parquet",
+	Short: "Exports issues, comments and events as Parquet tables",
+	Long: `Writes the issues, comments and events (opened, commented, closed) of the archive
+as Parquet tables, partitioned by repository:
+
+  DIR/issues/owner=OWNER/repo=REPOSITORY/data.parquet
+  DIR/comments/owner=OWNER/repo=REPOSITORY/data.parquet
+  DIR/events/owner=OWNER/repo=REPOSITORY/data.parquet
+
+Subgroups of GitLab projects are part of OWNER, separated by underscores.
+Existing tables of a repository are replaced, so the command can be run after every download.
+Use --archive-root to export all archives stored as OWNER/REPOSITORY below a folder at once.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		dir, _ := cmd.Flags().GetString("dir")
+		root, _ := cmd.Flags().GetString("archive-root")
+
+		archives := map[string]string{viper.GetString("repo"): viper.GetString("output")}
+		if root != "" {
+			var err error
+			if archives, err = findArchives(root); err != nil {
+				log.Fatal("Unable to find archives: ", err)
+			}
+		}
+
+		for repo, folder := range archives {
+			issues, err := archive.Load(folder)
+			if err != nil {
+				log.Println("Unable to read all issues:", err)
+			}
+			if len(issues) == 0 {
+				log.Printf("No issues found in %s\n", folder)
+				continue
+			}
+			if r := issues[0].Repository(); r != "" {
+				repo = r
+			}
+			if repo == "" {
+				log.Fatalf("Unknown repository of %s, use --repo or download the issues with --front-matter", folder)
+			}
+			if err := dataset.Write(dir, repo, issues); err != nil {
+				log.Fatal("Unable to write Parquet tables: ", err)
+			}
+			log.Printf("Wrote %d issue(s) of %s to %s\n", len(issues), repo, dir)
+		}
+	},
+}
+
+func init() {
+	exportCmd.AddCommand(parquetCmd)
+
+	parquetCmd.Flags().StringP("dir", "d", "parquet", "Folder to write the tables to")
+	parquetCmd.Flags().String("archive-root", "", "Folder containing archives of several repositories as OWNER/REPOSITORY")
+}
+
+// findArchives returns the archives below root, mapped from OWNER/REPOSITORY to the archive folder
+func findArchives(root string) (map[string]string, error) {
+	archives := make(map[string]string)
+	owners, err := ioutil.ReadDir(root)
+	if err != nil {
+		return nil, err
+	}
+	for _, owner := range owners {
+		if !owner.IsDir() {
+			continue
+		}
+		repos, err := ioutil.ReadDir(filepath.Join(root, owner.Name()))
+		if err != nil {
+			return nil, err
+		}
+		for _, repo := range repos {
+			folder := filepath.Join(root, owner.Name(), repo.Name())
+			if repo.IsDir() && isArchive(folder) {
+				archives[owner.Name()+"/"+repo.Name()] = folder
+			}
+		}
+	}
+	return archives, nil
+}
+
+// isArchive reports whether folder contains downloaded issues
+func isArchive(folder string) bool {
+	for _, state := range archive.States {
+		if _, err := os.Stat(filepath.Join(folder, state)); err == nil {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/S7evinK/issues-to-go/pkg/draft"
+	"github.com/S7evinK/issues-to-go/pkg/gh"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// publishCmd creates the issues written offline
+var publishCmd = &cobra.Command{
+	Use:   "publish [file...]",
+	Short: "Creates issues written offline",
+	Long: `Creates the issues in the folder ` + draft.NewDir + ` of the output folder, all of them or only the given files.
+Every issue is a Markdown file with front matter containing the title and optionally labels,
+assignees and the milestone:
+
+  ---
+  title: Crash on start
+  labels:
+  - bug
+  assignees:
+  - S7evinK
+  milestone: v1.0
+  ---
+
+  Steps to reproduce...
+
+Labels, assignees and the milestone must exist. Created issues are moved to open/NUMBER.md and
+downloaded again.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		output := viper.GetString("output")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+		issues, err := draft.LoadIssues(output)
+		if err != nil {
+			log.Println("Unable to read all new issues:", err)
+		}
+		if len(args) > 0 {
+			issues = nil
+			for _, path := range args {
+				i, err := draft.ReadIssue(path)
+				if err != nil {
+					log.Fatal(err)
+				}
+				issues = append(issues, i)
+			}
+		}
+		if len(issues) == 0 {
+			fmt.Println("No new issues to publish")
+			return
+		}
+
+		cl, err := newGithubClient()
+		if err != nil {
+			log.Fatal(err)
+		}
+		ctx := context.Background()
+		tz := time.Local
+		if viper.GetBool("utc") {
+			tz = time.UTC
+		}
+		var (
+			created []int
+			failed  int
+		)
+		for _, i := range issues {
+			number, err := publishIssue(ctx, cl, output, i, tz, dryRun)
+			if err != nil {
+				log.Printf("%s: %v\n", i.Path, err)
+				failed++
+			}
+			if number > 0 {
+				created = append(created, number)
+			}
+		}
+
+		if len(created) > 0 {
+			if err := cl.SyncIssues(ctx, created...); err != nil {
+				log.Fatal("Unable to download the issues: ", err)
+			}
+			if err := updateExports(output, viper.GetString("repo"), cl.Changes()); err != nil {
+				log.Fatal(err)
+			}
+			if _, err := updateSearchIndex(output); err != nil {
+				log.Fatal(err)
+			}
+		}
+		if failed > 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(publishCmd)
+
+	publishCmd.Flags().BoolP("dry-run", "n", false, "Only check labels, assignees and milestones, don't create the issues")
+}
+
+// publishIssue creates an issue and moves the file to the archive. Returns the number of the created issue.
+func publishIssue(ctx context.Context, cl *gh.GH, output string, i *draft.Issue, tz *time.Location, dryRun bool) (int, error) {
+	issue := gh.NewIssue{Title: i.Title, Body: i.Body, Labels: i.Labels, Assignees: i.Assignees, Milestone: i.Milestone}
+	if dryRun {
+		if _, err := cl.ResolveIssue(ctx, issue); err != nil {
+			return 0, err
+		}
+		fmt.Printf("%s: ready to publish %q\n", i.Path, i.Title)
+		return 0, nil
+	}
+
+	created, err := cl.CreateIssue(ctx, issue)
+	if err != nil {
+		return 0, err
+	}
+	from := i.Path
+	path, err := i.Archive(output, created.Number, created.Author, created.URL, created.CreatedAt.In(tz))
+	if err != nil {
+		return created.Number, fmt.Errorf("created %s, but unable to move the file, delete it to avoid creating the issue again: %v", created.URL, err)
+	}
+	fmt.Printf("#%d: created %s, %s moved to %s\n", created.Number, created.URL, filepath.Base(from), relPath(output, path))
+	return created.Number, nil
+}
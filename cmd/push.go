@@ -0,0 +1,164 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/S7evinK/issues-to-go/pkg/archive"
+	"github.com/S7evinK/issues-to-go/pkg/draft"
+	"github.com/S7evinK/issues-to-go/pkg/gh"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// pushCmd posts the drafted comments
+var pushCmd = &cobra.Command{
+	Use:   "push [number...]",
+	Short: "Posts comments drafted offline",
+	Long: `Posts the drafts in the folder ` + draft.Dir + ` of the output folder as comments, all of them or only those
+to the given issues. Posted drafts are deleted and the issues are downloaded again.
+
+If comments were added to an issue since its draft was started, the draft is not posted. The issue
+is downloaded, so the new comments can be read with show, and the draft can be posted with --force.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		output := viper.GetString("output")
+		force, _ := cmd.Flags().GetBool("force")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+		drafts, err := draft.Load(output)
+		if err != nil {
+			log.Println("Unable to read all drafts:", err)
+		}
+		drafts, err = selectDrafts(drafts, args)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if len(drafts) == 0 {
+			fmt.Println("No drafts to push")
+			return
+		}
+
+		cl, err := newGithubClient()
+		if err != nil {
+			log.Fatal(err)
+		}
+		ctx := context.Background()
+		var (
+			affected []int
+			failed   int
+		)
+		for _, d := range drafts {
+			posted, err := pushDraft(ctx, cl, output, d, force, dryRun)
+			if err != nil {
+				log.Printf("#%d: %v\n", d.Number, err)
+				failed++
+			}
+			if posted || err == errConflict {
+				affected = append(affected, d.Number)
+			}
+		}
+
+		if len(affected) > 0 && !dryRun {
+			if err := cl.SyncIssues(ctx, affected...); err != nil {
+				log.Fatal("Unable to download the issues: ", err)
+			}
+			if err := updateExports(output, viper.GetString("repo"), cl.Changes()); err != nil {
+				log.Fatal(err)
+			}
+			if _, err := updateSearchIndex(output); err != nil {
+				log.Fatal(err)
+			}
+		}
+		if failed > 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(pushCmd)
+
+	pushCmd.Flags().Bool("force", false, "Post drafts even if comments were added since they were started")
+	pushCmd.Flags().BoolP("dry-run", "n", false, "Only check the drafts for new comments, don't post them")
+}
+
+// errConflict is returned if comments were added to an issue since its draft was started
+var errConflict = errors.New("comments were added since the draft was started, read them with show and push again with --force")
+
+// selectDrafts returns the drafts for the issues given as arguments, all drafts without arguments
+func selectDrafts(drafts []*draft.Draft, args []string) ([]*draft.Draft, error) {
+	if len(args) == 0 {
+		return drafts, nil
+	}
+	var selected []*draft.Draft
+	for _, arg := range args {
+		number, err := strconv.Atoi(strings.TrimPrefix(arg, "#"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid issue number %q", arg)
+		}
+		found := false
+		for _, d := range drafts {
+			if d.Number == number {
+				selected = append(selected, d)
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("no draft for issue %d", number)
+		}
+	}
+	return selected, nil
+}
+
+// pushDraft posts a draft unless comments were added since it was started and reports whether it was posted
+func pushDraft(ctx context.Context, cl *gh.GH, output string, d *draft.Draft, force, dryRun bool) (bool, error) {
+	if d.Body == "" {
+		log.Printf("#%d: skipping the empty draft %s\n", d.Number, d.Path)
+		return false, nil
+	}
+	t, err := cl.Thread(ctx, d.Number, 10)
+	if err != nil {
+		return false, err
+	}
+
+	archived, _ := archive.Find(output, d.Number)
+	if n := d.Conflict(archived, t.Comments); n > 0 {
+		var authors []string
+		for i := len(t.Latest) - n; i < len(t.Latest); i++ {
+			if i >= 0 {
+				authors = append(authors, t.Latest[i].Author.Login)
+			}
+		}
+		fmt.Printf("#%d: %d new comment(s) by %s\n", d.Number, n, strings.Join(authors, ", "))
+		if !force {
+			// record the state the draft is based on, the new comments are in the archive after the download
+			if d.Comments < 0 && !dryRun {
+				d.Comments, d.Started = t.Comments-n, time.Now().Truncate(time.Second)
+				if err := d.WriteFile(); err != nil {
+					return false, err
+				}
+			}
+			return false, errConflict
+		}
+	}
+	if dryRun {
+		fmt.Printf("#%d: ready to post %s\n", d.Number, d.Path)
+		return false, nil
+	}
+
+	comment, err := cl.AddComment(ctx, t.ID, d.Body)
+	if err != nil {
+		return false, err
+	}
+	fmt.Printf("#%d: posted %s\n", d.Number, comment.URL)
+	if err := os.Remove(d.Path); err != nil {
+		log.Printf("#%d: unable to delete the posted draft: %v\n", d.Number, err)
+	}
+	return true, nil
+}
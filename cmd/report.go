@@ -0,0 +1,25 @@
+package cmd
+
+import (
+	"log"
+	"os"
+	"time"
+
+	"github.com/S7evinK/issues-to-go/pkg/archive"
+	"github.com/S7evinK/issues-to-go/pkg/report"
+	"github.com/pkg/errors"
+)
+
+// writeChangeReport prints the changes of the last sync and saves them as report in the output folder
+func writeChangeReport(output, repo string, changes []archive.Change) error {
+	now := time.Now()
+	if err := report.Markdown(os.Stdout, output, repo, changes, now); err != nil {
+		return errors.Wrap(err, "unable to print change report")
+	}
+	path, err := report.Write(output, repo, changes, now)
+	if err != nil {
+		return errors.Wrap(err, "unable to write change report")
+	}
+	log.Println("Wrote change report to", path)
+	return nil
+}
@@ -1,12 +1,14 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
-	"time"
+	"strings"
 
-	"github.com/S7evinK/issues-to-go/pkg/gh"
+	"github.com/S7evinK/issues-to-go/pkg/gitlab"
+	"github.com/S7evinK/issues-to-go/pkg/spreadsheet"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -24,7 +26,10 @@ Download all issues associated with the repository "S7evinK/issues-to-go" to a f
 	GITHUB_TOKEN=mysecrettoken issues-to-go -r S7evinK/issues-to-go
 
 Download all issues to a specific folder "output":
-	issues-to-go -r S7evinK/issues-to-go -o ./output`,
+	issues-to-go -r S7evinK/issues-to-go -o ./output
+
+Download the issues of a GitLab project with a token in GITLAB_TOKEN:
+	GITLAB_TOKEN=mysecrettoken issues-to-go -r group/project --gitlab-url https://gitlab.com`,
 	Short: "Downloads issues from Github for offline usage",
 	Long: `issues-to-go downloads issues from Github for offline usage.
 The default output format is Markdown. The issues are downloaded to a specified folder and to separate folders for open and closed issues.
@@ -34,44 +39,8 @@ After the first run a config file (.issues-to-go.yaml) will be created, subseque
 	// Uncomment the following line if your bare application
 	// has an action associated with it:
 	Run: func(cmd *cobra.Command, args []string) {
-		repo := viper.GetString("repo")
-		since, err := time.Parse(time.RFC3339, viper.GetString("lastIssueTime"))
-		if err != nil {
-			since = time.Unix(0, 0)
-		}
-
-		cl, err := gh.New(
-			gh.Output(viper.GetString("output")),
-			gh.All(viper.GetBool("all")),
-			gh.Count(viper.GetInt("count")),
-			gh.UTC(viper.GetBool("utc")),
-			gh.Since(viper.GetString("lastIssueTime")),
-			gh.Repo(repo),
-			gh.Token(viper.GetString("GITHUB_TOKEN")),
-			gh.Milestones(viper.GetBool("milestones")),
-		)
-		if err != nil {
-			log.Fatal("Unable to create new github client: ", err)
-		}
-		chClose := make(chan bool)
-		s := NewSpinner(chClose)
-
-		log.Printf("Getting new and updated issues/comments from %s since %v\n", repo, since.UTC())
-		go s.Run()
-		err = cl.FetchIssues()
-		switch err {
-		case gh.ErrNoIssues:
-			log.Println("No new or updated issues found.")
-		case nil:
-		default:
-			log.Fatal("Unable to fetch issues: ", err)
-		}
-		chClose <- true
-
-		// update lastIssueTime
-		viper.Set("lastIssueTime", time.Now().UTC().Format(time.RFC3339))
-		if err := viper.WriteConfigAs(configName + ".yaml"); err != nil {
-			log.Fatal(fmt.Errorf("error writing to file: %v", err))
+		if _, err := runSync(context.Background(), true); err != nil {
+			log.Fatal(err)
 		}
 	},
 }
@@ -93,17 +62,27 @@ func init() {
 	// will be global for your application.
 
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is .issues-to-go.yaml)")
+	rootCmd.PersistentFlags().StringP("repo", "r", "", "Repository to download (eg: S7evinK/issues-to-go or a GitLab project like group/project)")
+	rootCmd.PersistentFlags().StringP("output", "o", "./.issues", "Output folder to download the issues to")
 
 	// Cobra also supports local flags, which will only run
 	// when this action is called directly.
-	rootCmd.Flags().StringP("repo", "r", "", "Repository to download (eg: S7evinK/issues-to-go)")
-	rootCmd.Flags().StringP("output", "o", "./.issues", "Output folder to download the issues to")
 	rootCmd.Flags().Bool("utc", false, "Use UTC for dates. Defaults to false")
 	rootCmd.Flags().IntP("count", "c", 100, "Sets the amount of issues/comments to fetch at once")
 	rootCmd.Flags().Bool("all", false, "Get open and closed issues. By default only open issues will be downloaded")
 	rootCmd.Flags().Bool("milestones", false, "Create a separate folder with issues linked to milestones.")
+	rootCmd.Flags().String("gitlab-url", "", "Download the issues from the GitLab instance at this URL (eg. "+gitlab.DefaultURL+") instead of Github, using the token in GITLAB_TOKEN")
+	rootCmd.Flags().String("archive-root", "", "Folder containing archives of other repositories as OWNER/REPOSITORY, used to link references to them")
+	rootCmd.Flags().Bool("csv", false, "Write the metadata of all issues to the file "+csvFile+" in the output folder")
+	rootCmd.Flags().StringSlice("csv-columns", spreadsheet.DefaultColumns, "Columns of the CSV file, available: "+strings.Join(spreadsheet.Columns(), ", "))
+	rootCmd.Flags().Bool("mbox", false, "Append new issues and comments to the mbox file "+mboxFile+" in the output folder")
+	rootCmd.Flags().Bool("feed", false, "Add new issues, comments and state changes to the Atom feed "+feedFile+" in the output folder")
+	rootCmd.Flags().Int("feed-days", 30, "Days of history kept in the Atom feed")
+	rootCmd.Flags().Bool("change-report", false, "Print the new issues, comments and changed issues and write them to CHANGES-<timestamp>.md in the output folder")
+	rootCmd.Flags().Bool("front-matter", false, "Write YAML front matter with the issue metadata to the top of each file")
 
 	_ = viper.BindPFlags(rootCmd.Flags())
+	_ = viper.BindPFlags(rootCmd.PersistentFlags())
 
 }
 
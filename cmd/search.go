@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/S7evinK/issues-to-go/pkg/archive"
+	"github.com/S7evinK/issues-to-go/pkg/render"
+	"github.com/S7evinK/issues-to-go/pkg/search"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// searchCmd searches the full text of the archive
+var searchCmd = &cobra.Command{
+	Use:   "search <query>",
+	Short: "Searches the full text of downloaded issues",
+	Long: `Searches the issues in the output folder and prints the best matches with highlighted snippets.
+
+The query consists of terms and "quoted phrases", all of which must match. Terms and phrases can be
+restricted to a field (title, body, comments, author), eg. title:crash or comments:"out of memory",
+and excluded by a leading minus, eg. -wontfix. Results are ranked by BM25.
+Qualifiers of Github searches like is:open, label:bug or sort:created-desc can be added to the query.
+
+The search index is stored in the output folder and updated on every download and search.`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		gq := parseQuery(args)
+		q, err := search.ParseQuery(gq.Text)
+		if err != nil {
+			log.Fatal("Invalid query: ", err)
+		}
+		if len(q.Clauses) == 0 {
+			log.Fatal("The query contains no search terms, use list to select issues by qualifiers only")
+		}
+		if len(gq.In) > 0 {
+			q.Restrict(gq.In)
+		}
+		idx, err := updateSearchIndex(viper.GetString("output"))
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		var found []*archive.Issue
+		for _, r := range idx.Search(q) {
+			issue, err := archive.ReadFile(r.Path)
+			if err != nil {
+				log.Println("Unable to read issue:", err)
+				continue
+			}
+			found = append(found, issue)
+		}
+		if err := archive.SetMilestones(viper.GetString("output"), found); err != nil {
+			log.Fatal(err)
+		}
+		var issues []*archive.Issue
+		for _, issue := range found {
+			if gq.MatchQualifiers(issue) {
+				issues = append(issues, issue)
+			}
+		}
+		if gq.SortKey != "" {
+			_ = archive.Sort(issues, gq.SortKey, gq.SortDesc)
+		}
+
+		color, _ := cmd.Flags().GetString("color")
+		s := render.Styler(color == "always" || (color == "auto" && isTerminal(os.Stdout)))
+		highlight := func(term string) string { return s.Style(term, "1", "33") }
+		if !s {
+			highlight = func(term string) string { return "**" + term + "**" }
+		}
+
+		limit, _ := cmd.Flags().GetInt("limit")
+		for i, issue := range issues {
+			if limit > 0 && i >= limit {
+				break
+			}
+			fmt.Printf("%s %s %s\n", s.Bold(fmt.Sprintf("#%d", issue.Number)), s.Style(issue.State, render.StateColor(issue.State)), s.Bold(issue.Title))
+			if snippet, ok := search.MakeSnippet(issue, q, 160, highlight); ok {
+				label := snippet.Field
+				if snippet.Author != "" {
+					label = snippet.Author + " commented"
+				}
+				fmt.Printf("    %s %s\n", s.Dim(label+":"), snippet.Text)
+			}
+		}
+		if limit > 0 && len(issues) > limit {
+			fmt.Printf("%d more result(s), use --limit to show them\n", len(issues)-limit)
+		}
+		if len(issues) == 0 {
+			fmt.Println("No issues found")
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(searchCmd)
+
+	searchCmd.Flags().IntP("limit", "n", 20, "Maximum number of results, 0 shows all")
+	searchCmd.Flags().String("color", "auto", "Highlight matches with ANSI escape sequences: auto, always or never")
+}
+
+// updateSearchIndex adds new and changed issues of the output folder to the search index
+func updateSearchIndex(output string) (*search.Index, error) {
+	idx, err := search.Open(output)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to open search index")
+	}
+	updated, removed, err := idx.Update()
+	if err != nil {
+		log.Println("Unable to update search index:", err)
+	}
+	if updated > 0 || removed > 0 {
+		log.Printf("Updated %d and removed %d issue(s) in the search index\n", updated, removed)
+	}
+	return idx, nil
+}
@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/S7evinK/issues-to-go/pkg/server"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// serveCmd serves the archive as web pages
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serves downloaded issues as web pages",
+	Long: `Starts a web server rendering the issues in the output folder as HTML, with lists of open
+and closed issues, milestones and a search box accepting Github search queries.
+Changes to the output folder (eg. by a download running in parallel) are picked up automatically.
+Besides the issues only files linked from them, like images, are served.
+
+By default the server only accepts connections from this computer, use --listen :8080 to make
+the archive available in the local network.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		title, _ := cmd.Flags().GetString("title")
+		if title == "" {
+			title = viper.GetString("repo")
+		}
+		if title == "" {
+			title = "issues-to-go"
+		}
+		srv, err := server.New(viper.GetString("output"), title)
+		if err != nil {
+			log.Fatal("Unable to read archive: ", err)
+		}
+
+		listen, _ := cmd.Flags().GetString("listen")
+		log.Printf("Serving %s on http://%s\n", viper.GetString("output"), listen)
+		log.Fatal(http.ListenAndServe(listen, srv))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(serveCmd)
+
+	serveCmd.Flags().StringP("listen", "l", "localhost:8080", "Address to listen on")
+	serveCmd.Flags().String("title", "", "Title of the pages (default is the repository)")
+}
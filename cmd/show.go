@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/S7evinK/issues-to-go/pkg/archive"
+	"github.com/S7evinK/issues-to-go/pkg/render"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// showCmd renders an issue in the terminal
+var showCmd = &cobra.Command{
+	Use:   "show <number>",
+	Short: "Shows a downloaded issue in the terminal",
+	Long: `Renders an issue and its comments from the output folder with formatting for the terminal.
+If the output is a terminal, it is shown in $PAGER (default less).`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		number, err := strconv.Atoi(strings.TrimPrefix(args[0], "#"))
+		if err != nil {
+			log.Fatalf("Invalid issue number %q", args[0])
+		}
+		issue, err := archive.Find(viper.GetString("output"), number)
+		if os.IsNotExist(err) {
+			log.Fatalf("Issue %d not found in %s", number, viper.GetString("output"))
+		}
+		if err != nil {
+			log.Fatal("Unable to read issue: ", err)
+		}
+
+		tty := isTerminal(os.Stdout)
+		noPager, _ := cmd.Flags().GetBool("no-pager")
+		color, _ := cmd.Flags().GetString("color")
+		var styled bool
+		switch color {
+		case "auto":
+			styled = tty
+		case "always":
+			styled = true
+		case "never":
+		default:
+			log.Fatalf("Invalid value %q for --color, use auto, always or never", color)
+		}
+
+		out := render.Issue(issue, render.Styler(styled), nil)
+		if tty && !noPager {
+			// the text was shown if the pager started, even if it exits with an error
+			if started, _ := page(out); started {
+				return
+			}
+		}
+		fmt.Print(out)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(showCmd)
+
+	showCmd.Flags().Bool("no-pager", false, "Don't use a pager")
+	showCmd.Flags().String("color", "auto", "Style the output with ANSI escape sequences: auto, always or never")
+}
+
+// isTerminal reports whether f is a terminal
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// page shows the text in the pager defined by $PAGER, less is used by default.
+// Reports whether the pager was started, the text must not be printed again in this case.
+func page(text string) (bool, error) {
+	pager := os.Getenv("PAGER")
+	if pager == "" {
+		pager = "less"
+	}
+	cmd := exec.Command("sh", "-c", pager)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	// let less show colors and quit if the text fits on the screen
+	if os.Getenv("LESS") == "" {
+		cmd.Env = append(os.Environ(), "LESS=FRX")
+	}
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return false, err
+	}
+	if err := cmd.Start(); err != nil {
+		return false, err
+	}
+	_, _ = io.WriteString(stdin, text)
+	stdin.Close()
+	return true, cmd.Wait()
+}
@@ -0,0 +1,139 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/S7evinK/issues-to-go/pkg/archive"
+	"github.com/S7evinK/issues-to-go/pkg/stats"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// statsCmd prints metrics about the issues of the archive
+var statsCmd = &cobra.Command{
+	Use:   "stats [query]",
+	Short: "Prints project health metrics of downloaded issues",
+	Long: `Computes metrics from the issues in the output folder: the number of open and closed issues over time,
+the median and 90th percentile of the time to close issues and of the time to the first response
+(the first comment by someone else than the author), the top reporters and commenters and the
+completion of each milestone.
+
+The issues can be selected with a Github search query, eg.
+  issues-to-go stats label:bug created:>=2019-01-01`,
+	Run: func(cmd *cobra.Command, args []string) {
+		q := parseQuery(args)
+		issues, err := archive.Load(viper.GetString("output"))
+		if err != nil {
+			log.Println("Unable to read all issues:", err)
+		}
+
+		interval, _ := cmd.Flags().GetString("interval")
+		top, _ := cmd.Flags().GetInt("top")
+		s, err := stats.Compute(q.Apply(issues), interval, top, time.Now())
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		if dir, _ := cmd.Flags().GetString("svg"); dir != "" {
+			if err := writeCharts(dir, s); err != nil {
+				log.Fatal("Unable to write charts: ", err)
+			}
+		}
+
+		format, _ := cmd.Flags().GetString("format")
+		switch format {
+		case "table":
+			err = printStats(os.Stdout, s, interval)
+		case "json":
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			enc.SetEscapeHTML(false)
+			err = enc.Encode(s)
+		default:
+			err = fmt.Errorf("unknown format %q, available: table, json", format)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(statsCmd)
+
+	statsCmd.Flags().StringP("format", "f", "table", "Output format: table or json")
+	statsCmd.Flags().String("interval", stats.Month, "Interval of the open and closed issues over time: week or month")
+	statsCmd.Flags().Int("top", 10, "Number of reporters and commenters to show")
+	statsCmd.Flags().String("svg", "", "Write the charts timeline.svg and milestones.svg to this folder")
+}
+
+func printStats(w io.Writer, s *stats.Stats, interval string) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(tw, "Open issues\t%d\n", s.Open)
+	fmt.Fprintf(tw, "Closed issues\t%d\n", s.Closed)
+	for _, d := range []struct {
+		name string
+		stats.Durations
+	}{{"Time to close", s.TimeToClose}, {"Time to first response", s.TimeToFirstResponse}} {
+		if d.Count == 0 {
+			fmt.Fprintf(tw, "%s\t-\n", d.name)
+			continue
+		}
+		fmt.Fprintf(tw, "%s\tmedian %s, 90%% within %s (%d issues)\n", d.name, stats.FormatDuration(d.Median), stats.FormatDuration(d.P90), d.Count)
+	}
+
+	fmt.Fprintf(tw, "\nEND OF %s\tOPEN\tCLOSED\n", strings.ToUpper(interval))
+	for _, p := range s.Timeline {
+		fmt.Fprintf(tw, "%s\t%d\t%d\n", p.Time.Format(dateLayout), p.Open, p.Closed)
+	}
+
+	fmt.Fprintln(tw, "\nTOP REPORTERS\tISSUES")
+	for _, c := range s.Reporters {
+		fmt.Fprintf(tw, "%s\t%d\n", c.User, c.Count)
+	}
+	fmt.Fprintln(tw, "\nTOP COMMENTERS\tCOMMENTS")
+	for _, c := range s.Commenters {
+		fmt.Fprintf(tw, "%s\t%d\n", c.User, c.Count)
+	}
+
+	if len(s.Milestones) > 0 {
+		fmt.Fprintln(tw, "\nMILESTONE\tOPEN\tCLOSED\tDONE")
+		for _, m := range s.Milestones {
+			fmt.Fprintf(tw, "%s\t%d\t%d\t%.0f%%\n", m.Name, m.Open, m.Closed, m.Percent())
+		}
+	}
+	return tw.Flush()
+}
+
+// writeCharts writes the timeline and milestone charts to dir
+func writeCharts(dir string, s *stats.Stats) error {
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return err
+	}
+	charts := map[string]func(io.Writer) error{
+		"timeline.svg":   func(w io.Writer) error { return stats.TimelineSVG(w, s.Timeline) },
+		"milestones.svg": func(w io.Writer) error { return stats.MilestonesSVG(w, s.Milestones) },
+	}
+	for name, draw := range charts {
+		f, err := os.Create(filepath.Join(dir, name))
+		if err != nil {
+			return err
+		}
+		if err := draw(f); err != nil {
+			f.Close()
+			return err
+		}
+		if err := f.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/S7evinK/issues-to-go/pkg/archive"
+	"github.com/S7evinK/issues-to-go/pkg/gh"
+	"github.com/S7evinK/issues-to-go/pkg/gitlab"
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+)
+
+// runSync downloads new and updated issues, updates the files created from the archive and saves the
+// time of the sync in the config file. If ctx is cancelled, the download stops after the current issue
+// and the time of the last complete sync is kept, so the next sync downloads the remaining issues.
+// Returns the changes to the archive.
+func runSync(ctx context.Context, spin bool) ([]archive.Change, error) {
+	repo := viper.GetString("repo")
+	since, err := time.Parse(time.RFC3339, viper.GetString("lastIssueTime"))
+	if err != nil {
+		since = time.Unix(0, 0)
+	}
+	// issues updated while downloading are downloaded again by the next sync
+	start := time.Now()
+
+	cl, err := newClient()
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Getting new and updated issues/comments from %s since %v\n", repo, since.UTC())
+	chClose := make(chan bool)
+	if spin {
+		go NewSpinner(chClose).Run()
+	}
+	err = cl.FetchIssuesContext(ctx)
+	if spin {
+		chClose <- true
+	}
+	interrupted := ctx.Err() != nil
+	switch {
+	case err == gh.ErrNoIssues:
+		log.Println("No new or updated issues found.")
+	case err == nil, interrupted:
+	default:
+		return nil, errors.Wrap(err, "unable to fetch issues")
+	}
+	if interrupted {
+		log.Println("Download interrupted, the remaining issues are downloaded by the next sync")
+	}
+
+	output := viper.GetString("output")
+	changes := cl.Changes()
+	if len(changes) > 0 && viper.GetBool("change-report") {
+		if err := writeChangeReport(output, repo, changes); err != nil {
+			return changes, err
+		}
+	}
+	if err := updateExports(output, repo, changes); err != nil {
+		return changes, err
+	}
+	if _, err := updateSearchIndex(output); err != nil {
+		return changes, err
+	}
+	if interrupted {
+		return changes, nil
+	}
+
+	// update lastIssueTime
+	viper.Set("lastIssueTime", start.UTC().Format(time.RFC3339))
+	if err := viper.WriteConfigAs(configName + ".yaml"); err != nil {
+		return changes, fmt.Errorf("error writing to file: %v", err)
+	}
+	return changes, nil
+}
+
+// newClient creates the client with the settings of the config file and the flags. The issues are
+// downloaded from GitLab, if --gitlab-url is set.
+func newClient() (*gh.GH, error) {
+	opts := []gh.Option{
+		gh.Output(viper.GetString("output")),
+		gh.All(viper.GetBool("all")),
+		gh.Count(viper.GetInt("count")),
+		gh.UTC(viper.GetBool("utc")),
+		gh.Since(viper.GetString("lastIssueTime")),
+		gh.Repo(viper.GetString("repo")),
+		gh.Token(viper.GetString("GITHUB_TOKEN")),
+		gh.Milestones(viper.GetBool("milestones")),
+		gh.FrontMatter(viper.GetBool("front-matter")),
+		gh.Root(viper.GetString("archive-root")),
+	}
+	if u := viper.GetString("gitlab-url"); u != "" {
+		opts = append(opts, gh.From(gitlab.New(u, viper.GetString("repo"), viper.GetString("GITLAB_TOKEN"), viper.GetInt("count"))))
+	}
+	cl, err := gh.New(opts...)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to create new github client")
+	}
+	return cl, nil
+}
+
+// newGithubClient is like newClient, but fails for projects on GitLab, since issues can only be changed on Github
+func newGithubClient() (*gh.GH, error) {
+	if viper.GetString("gitlab-url") != "" {
+		return nil, errors.New("issues can only be changed on Github")
+	}
+	return newClient()
+}
+
+// summarizeChanges counts the changes by kind, eg. "2 new-issue, 5 new-comment"
+func summarizeChanges(changes []archive.Change) string {
+	if len(changes) == 0 {
+		return "no changes"
+	}
+	counts := make(map[archive.ChangeKind]int)
+	var kinds []archive.ChangeKind
+	for _, c := range changes {
+		if counts[c.Kind] == 0 {
+			kinds = append(kinds, c.Kind)
+		}
+		counts[c.Kind]++
+	}
+	var parts []string
+	for _, k := range kinds {
+		parts = append(parts, fmt.Sprintf("%d %s", counts[k], k))
+	}
+	return strings.Join(parts, ", ")
+}
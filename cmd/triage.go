@@ -0,0 +1,258 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/S7evinK/issues-to-go/pkg/archive"
+	"github.com/S7evinK/issues-to-go/pkg/triage"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// triageCmd groups the commands recording and replaying triage actions
+var triageCmd = &cobra.Command{
+	Use:   "triage",
+	Short: "Records triage actions offline and replays them later",
+	Long: `Records closing, reopening, labeling and changing the milestone of downloaded issues in the journal
+.meta/` + triage.File + ` in the output folder. replay takes the recorded actions on Github.
+
+Before an action is replayed, the issue is checked: actions which were already taken by someone else
+(eg. the issue was closed in the meantime) are skipped, milestone changes of issues whose milestone
+was changed since the action was recorded are flagged and kept in the journal.`,
+}
+
+var triageCloseCmd = &cobra.Command{
+	Use:   "close <number>...",
+	Short: "Records closing issues",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		recordActions(args, func(issue *archive.Issue) (triage.Action, error) {
+			if issue.Closed() {
+				return triage.Action{}, fmt.Errorf("issue %d is already closed", issue.Number)
+			}
+			return triage.Action{Kind: triage.Close}, nil
+		})
+	},
+}
+
+var triageReopenCmd = &cobra.Command{
+	Use:   "reopen <number>...",
+	Short: "Records reopening issues",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		recordActions(args, func(issue *archive.Issue) (triage.Action, error) {
+			if !issue.Closed() {
+				return triage.Action{}, fmt.Errorf("issue %d is open", issue.Number)
+			}
+			return triage.Action{Kind: triage.Reopen}, nil
+		})
+	},
+}
+
+var triageLabelCmd = &cobra.Command{
+	Use:     "label <number>... --add LABEL --remove LABEL",
+	Short:   "Records adding and removing labels",
+	Example: `  issues-to-go triage label 12 14 --add bug --remove question`,
+	Args:    cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		add, _ := cmd.Flags().GetStringSlice("add")
+		remove, _ := cmd.Flags().GetStringSlice("remove")
+		if len(add) == 0 && len(remove) == 0 {
+			log.Fatal("Use --add or --remove to change labels")
+		}
+		recordActions(args, func(issue *archive.Issue) (triage.Action, error) {
+			return triage.Action{Kind: triage.Label, Add: add, Remove: remove}, nil
+		})
+	},
+}
+
+var triageMilestoneCmd = &cobra.Command{
+	Use:   "milestone <number>... --set MILESTONE | --remove",
+	Short: "Records setting or removing the milestone",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		milestone, _ := cmd.Flags().GetString("set")
+		remove, _ := cmd.Flags().GetBool("remove")
+		if (milestone == "") == !remove {
+			log.Fatal("Use either --set or --remove")
+		}
+		recordActions(args, func(issue *archive.Issue) (triage.Action, error) {
+			// without front matter an issue without milestone symlink may still have a milestone
+			known := issue.FrontMatter || issue.Milestone != ""
+			if known && archive.MatchMilestone(issue.Milestone, milestone) {
+				return triage.Action{}, fmt.Errorf("issue %d already has this milestone", issue.Number)
+			}
+			return triage.Action{Kind: triage.Milestone, Milestone: milestone, Before: issue.Milestone, BeforeUnknown: !known}, nil
+		})
+	},
+}
+
+var triageListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"pending"},
+	Short:   "Shows the actions which weren't replayed yet",
+	Args:    cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		j := openJournal()
+		if len(j.Actions) == 0 {
+			fmt.Println("No pending actions")
+			return
+		}
+		for _, a := range j.Actions {
+			fmt.Printf("%3d  %s  %s\n", a.ID, a.Recorded.Format("2006-01-02 15:04"), a)
+			if a.Flag != "" {
+				fmt.Printf("     flagged: %s\n", a.Flag)
+			}
+		}
+	},
+}
+
+var triageDropCmd = &cobra.Command{
+	Use:   "drop <id>...",
+	Short: "Removes actions from the journal",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		j := openJournal()
+		for _, arg := range args {
+			id, err := strconv.Atoi(arg)
+			if err != nil {
+				log.Fatalf("Invalid action id %q", arg)
+			}
+			if !j.Drop(id) {
+				log.Fatalf("No action with id %d", id)
+			}
+		}
+		if err := j.Save(); err != nil {
+			log.Fatal("Unable to write the journal: ", err)
+		}
+	},
+}
+
+var triageReplayCmd = &cobra.Command{
+	Use:   "replay",
+	Short: "Takes the recorded actions on Github",
+	Long: `Takes the recorded actions on Github in the order they were recorded. Actions which were already
+taken are skipped, conflicting actions are flagged and kept in the journal unless --force is set.
+The changed issues are downloaded again.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		force, _ := cmd.Flags().GetBool("force")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		output := viper.GetString("output")
+
+		j := openJournal()
+		if len(j.Actions) == 0 {
+			fmt.Println("No pending actions")
+			return
+		}
+		cl, err := newGithubClient()
+		if err != nil {
+			log.Fatal(err)
+		}
+		ctx := context.Background()
+
+		var (
+			changed []int
+			seen    = make(map[int]bool)
+			failed  int
+		)
+		for _, r := range j.Replay(ctx, cl, force, dryRun) {
+			switch {
+			case r.Err != nil:
+				fmt.Printf("failed    %s: %v\n", r.Action, r.Err)
+				failed++
+			case r.Outcome == triage.Apply && dryRun:
+				fmt.Printf("ready     %s\n", r.Action)
+			case r.Outcome == triage.Apply:
+				fmt.Printf("applied   %s\n", r.Action)
+				if !seen[r.Action.Number] {
+					seen[r.Action.Number] = true
+					changed = append(changed, r.Action.Number)
+				}
+			case r.Outcome == triage.Done:
+				fmt.Printf("skipped   %s: %s\n", r.Action, r.Reason)
+			case r.Outcome == triage.Conflict:
+				fmt.Printf("conflict  %s: %s\n", r.Action, r.Reason)
+				failed++
+			}
+		}
+		if dryRun {
+			return
+		}
+		if err := j.Save(); err != nil {
+			log.Fatal("Unable to write the journal: ", err)
+		}
+
+		if len(changed) > 0 {
+			if err := cl.SyncIssues(ctx, changed...); err != nil {
+				log.Fatal("Unable to download the issues: ", err)
+			}
+			if err := updateExports(output, viper.GetString("repo"), cl.Changes()); err != nil {
+				log.Fatal(err)
+			}
+			if _, err := updateSearchIndex(output); err != nil {
+				log.Fatal(err)
+			}
+		}
+		if failed > 0 {
+			fmt.Printf("%d action(s) kept in the journal, use --force to apply conflicting actions or drop them\n", failed)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(triageCmd)
+	triageCmd.AddCommand(triageCloseCmd, triageReopenCmd, triageLabelCmd, triageMilestoneCmd, triageListCmd, triageDropCmd, triageReplayCmd)
+
+	triageLabelCmd.Flags().StringSlice("add", nil, "Labels to add")
+	triageLabelCmd.Flags().StringSlice("remove", nil, "Labels to remove")
+	triageMilestoneCmd.Flags().String("set", "", "Milestone to set")
+	triageMilestoneCmd.Flags().Bool("remove", false, "Remove the milestone")
+	triageReplayCmd.Flags().Bool("force", false, "Apply conflicting actions")
+	triageReplayCmd.Flags().BoolP("dry-run", "n", false, "Only check the actions, don't take them")
+}
+
+// openJournal opens the triage journal of the output folder
+func openJournal() *triage.Journal {
+	j, err := triage.Open(viper.GetString("output"))
+	if err != nil {
+		log.Fatal("Unable to read the journal: ", err)
+	}
+	return j
+}
+
+// recordActions records an action for each issue given as argument. The issues must be in the archive.
+func recordActions(args []string, action func(issue *archive.Issue) (triage.Action, error)) {
+	output := viper.GetString("output")
+	j := openJournal()
+	for _, arg := range args {
+		number, err := strconv.Atoi(strings.TrimPrefix(arg, "#"))
+		if err != nil {
+			log.Fatalf("Invalid issue number %q", arg)
+		}
+		issue, err := archive.Find(output, number)
+		if os.IsNotExist(err) {
+			log.Fatalf("Issue %d not found in %s", number, output)
+		}
+		if err != nil {
+			log.Fatal("Unable to read issue: ", err)
+		}
+		a, err := action(issue)
+		if err != nil {
+			log.Fatal(err)
+		}
+		a.Number, a.Recorded = number, time.Now().Truncate(time.Second)
+		a = j.Record(a)
+		fmt.Printf("%3d  %s\n", a.ID, a)
+	}
+	if err := j.Save(); err != nil {
+		log.Fatal("Unable to write the journal: ", err)
+	}
+}
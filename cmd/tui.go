@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"log"
+
+	"github.com/S7evinK/issues-to-go/pkg/tui"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// tuiCmd browses the archive in a full-screen terminal interface
+var tuiCmd = &cobra.Command{
+	Use:   "tui",
+	Short: "Browses downloaded issues in the terminal",
+	Long: `Shows the issues in the output folder in a full-screen terminal interface: a list of issues,
+filtered by state (s), milestone (m) and a Github search query (/), and a reading pane with the
+selected issue and its comments.
+
+In the reading pane tab and shift+tab select the links to other issues, enter follows the selected
+link. b (or backspace) and f go back and forward in the visited issues, q or escape return to the
+list, q in the list quits.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ui, err := tui.New(viper.GetString("output"))
+		if err != nil {
+			log.Fatal("Unable to read archive: ", err)
+		}
+		if err := ui.Run(); err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(tuiCmd)
+}
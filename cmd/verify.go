@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/S7evinK/issues-to-go/pkg/verify"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// verifyCmd checks the integrity of the archive
+var verifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Checks the output folder for inconsistencies",
+	Long: `Checks the output folder against the expected layout and reports unreadable issue files,
+issues stored in more than one state folder or in the wrong one, dangling or wrong milestone symlinks
+and links pointing to missing issues.
+
+With --fix, duplicates are removed (the most recently updated file is kept), issue files are moved
+to the folder of their state, milestone symlinks are recreated or removed and broken links are
+pointed to the current file of the issue or to Github. Unreadable and stray files are only reported.
+
+Exits with status 1 if problems remain.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		output := viper.GetString("output")
+		fix, _ := cmd.Flags().GetBool("fix")
+
+		var (
+			fixed, problems []verify.Problem
+			err             error
+		)
+		if fix {
+			fixed, problems, err = verify.Repair(output)
+		} else {
+			problems, err = verify.Check(output)
+		}
+		if err != nil {
+			log.Fatal("Unable to verify the output folder: ", err)
+		}
+
+		for _, p := range fixed {
+			fmt.Printf("fixed %s %s: %s\n", p.Kind, relPath(output, p.Path), p.Message)
+		}
+		fixable := 0
+		for _, p := range problems {
+			fmt.Printf("%s %s: %s\n", p.Kind, relPath(output, p.Path), p.Message)
+			if p.Fixable() {
+				fixable++
+			}
+		}
+
+		switch {
+		case len(problems) == 0 && len(fixed) == 0:
+			fmt.Println("No problems found")
+		case len(problems) == 0:
+			fmt.Printf("Fixed %d problem(s)\n", len(fixed))
+		case fix:
+			fmt.Printf("Fixed %d problem(s), %d problem(s) remain\n", len(fixed), len(problems))
+		default:
+			fmt.Printf("Found %d problem(s), %d can be fixed with --fix\n", len(problems), fixable)
+		}
+		if len(problems) > 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(verifyCmd)
+
+	verifyCmd.Flags().Bool("fix", false, "Repair the problems which can be fixed automatically")
+}
+
+// relPath returns path relative to the output folder, or path itself if that's not possible
+func relPath(output, path string) string {
+	if rel, err := filepath.Rel(output, path); err == nil {
+		return rel
+	}
+	return path
+}
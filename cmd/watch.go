@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/S7evinK/issues-to-go/pkg/archive"
+	"github.com/S7evinK/issues-to-go/pkg/watch"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// healthFile is the name of the health file in the metadata folder of the output folder
+const healthFile = "health.json"
+
+// watchCmd downloads issues periodically
+var watchCmd = &cobra.Command{
+	Use:     "watch",
+	Aliases: []string{"daemon"},
+	Short:   "Downloads new and updated issues periodically",
+	Long: `Runs the download every --interval, delayed by a random duration up to --jitter, until it's stopped.
+All flags of the download (eg. --all, --feed or --csv) can be used.
+
+SIGINT and SIGTERM stop the download after the current issue and save the state, so the next
+run continues where it stopped. A second signal stops immediately.
+
+The state of the last run is written to .meta/` + healthFile + ` in the output folder (or --health-file)
+and served on /health, if --health-listen is set. The status code is 503, if no download
+succeeded for three intervals.`,
+	Example: `  issues-to-go watch -r S7evinK/issues-to-go --all --interval 10m --health-listen :9090`,
+	Run: func(cmd *cobra.Command, args []string) {
+		interval, _ := cmd.Flags().GetDuration("interval")
+		jitter, _ := cmd.Flags().GetDuration("jitter")
+		if interval <= 0 {
+			log.Fatal("The interval must be positive")
+		}
+		file, _ := cmd.Flags().GetString("health-file")
+		if file == "" {
+			file = filepath.Join(viper.GetString("output"), archive.MetaDir, healthFile)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		signals := make(chan os.Signal, 2)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+		go func() {
+			sig := <-signals
+			log.Printf("Received %v, stopping after the current issue\n", sig)
+			cancel()
+			<-signals
+			log.Fatal("Stopped immediately")
+		}()
+
+		health := watch.NewHealth(3*interval+jitter, time.Now())
+		if err := health.WriteFile(file); err != nil {
+			log.Fatal("Unable to write health file: ", err)
+		}
+		if listen, _ := cmd.Flags().GetString("health-listen"); listen != "" {
+			mux := http.NewServeMux()
+			mux.Handle("/health", health)
+			go func() {
+				log.Fatal(http.ListenAndServe(listen, mux))
+			}()
+		}
+
+		watch.Run(ctx, interval, jitter, func(ctx context.Context) {
+			start := time.Now()
+			changes, err := runSync(ctx, false)
+			if err != nil {
+				log.Println("Download failed:", err)
+			} else {
+				log.Printf("Download finished in %v: %s\n", time.Since(start).Round(time.Second), summarizeChanges(changes))
+			}
+			health.Record(err, len(changes), time.Now())
+			if err := health.WriteFile(file); err != nil {
+				log.Println("Unable to write health file:", err)
+			}
+		})
+		log.Println("Stopped")
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(watchCmd)
+
+	watchCmd.Flags().Duration("interval", 15*time.Minute, "Time between downloads")
+	watchCmd.Flags().Duration("jitter", time.Minute, "Maximum random delay added to the interval")
+	watchCmd.Flags().String("health-file", "", "File the state of the last download is written to (default is .meta/"+healthFile+" in the output folder)")
+	watchCmd.Flags().String("health-listen", "", "Address to serve the state of the last download on /health (eg. :9090)")
+	// the flags of the download are shared with the root command, which binds them to the configuration
+	watchCmd.Flags().AddFlagSet(rootCmd.Flags())
+}
@@ -0,0 +1,5 @@
+// Package archive describes the on-disk format of downloaded issues.
+package archive
+
+// MetaDir is the folder below the output folder, which contains data about the archive itself
+const MetaDir = ".meta"
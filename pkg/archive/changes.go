@@ -0,0 +1,94 @@
+package archive
+
+import "time"
+
+// ChangeKind defines the type of a change to an issue
+type ChangeKind string
+
+const (
+	// NewIssue is an issue which wasn't part of the archive
+	NewIssue ChangeKind = "new-issue"
+	// NewComment is a comment which wasn't part of the archive
+	NewComment ChangeKind = "new-comment"
+	// StateChange is an issue which was closed or reopened
+	StateChange ChangeKind = "state"
+	// TitleChange is an issue whose title was changed
+	TitleChange ChangeKind = "title"
+	// MilestoneChange is an issue which was moved to another milestone
+	MilestoneChange ChangeKind = "milestone"
+)
+
+// Change describes a difference between two versions of an issue
+type Change struct {
+	Kind   ChangeKind
+	Number int
+	// Title is the current title of the issue
+	Title string
+	// Path is the path to the current issue file
+	Path string
+	// Time is the time the change happened, if known
+	Time time.Time
+	// Author and Body are set for new issues and comments
+	Author string
+	Body   string
+	// CommentID is set for new comments, if the archive contains comment IDs
+	CommentID int
+	// From and To are set for state, title and milestone changes
+	From string
+	To   string
+}
+
+// Diff returns the changes between the old and new version of an issue.
+// If old is nil, the issue is new and all comments are reported as new.
+func Diff(old, new *Issue) []Change {
+	change := func(kind ChangeKind) Change {
+		return Change{Kind: kind, Number: new.Number, Title: new.Title, Path: new.Path}
+	}
+
+	var changes []Change
+	if old == nil {
+		c := change(NewIssue)
+		c.Time, c.Author, c.Body = new.CreatedAt, new.Author, new.Body
+		changes = append(changes, c)
+		old = &Issue{State: "open", Milestone: new.Milestone, Title: new.Title}
+	}
+
+	if old.State != new.State {
+		c := change(StateChange)
+		c.From, c.To = old.State, new.State
+		c.Time = new.UpdatedAt
+		if new.Closed() && !new.ClosedAt.IsZero() {
+			c.Time = new.ClosedAt
+		}
+		changes = append(changes, c)
+	}
+	if old.Title != new.Title {
+		c := change(TitleChange)
+		c.From, c.To, c.Time = old.Title, new.Title, new.UpdatedAt
+		changes = append(changes, c)
+	}
+	if old.Milestone != new.Milestone {
+		c := change(MilestoneChange)
+		c.From, c.To, c.Time = old.Milestone, new.Milestone, new.UpdatedAt
+		changes = append(changes, c)
+	}
+
+	known := make(map[string]bool)
+	for _, com := range old.Comments {
+		known[com.key()] = true
+	}
+	for _, com := range new.Comments {
+		if known[com.key()] {
+			continue
+		}
+		c := change(NewComment)
+		c.Time, c.Author, c.Body, c.CommentID = com.CreatedAt, com.Author, com.Body, com.ID
+		changes = append(changes, c)
+	}
+	return changes
+}
+
+// key identifies a comment by author and date, since archives of older versions don't contain comment IDs
+func (c Comment) key() string {
+	return c.Author + "@" + c.CreatedAt.UTC().Format(time.RFC3339)
+}
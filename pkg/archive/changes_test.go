@@ -0,0 +1,55 @@
+package archive
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDiff(t *testing.T) {
+	created := time.Date(2019, time.November, 15, 13, 5, 33, 0, time.UTC)
+	closed := created.Add(time.Hour)
+	comment := Comment{ID: 11, Author: "alice", CreatedAt: created, Body: "first"}
+	old := &Issue{Number: 1, Title: "Test", State: "open", Author: "S7evinK", CreatedAt: created, Body: "Hello", Comments: []Comment{comment}}
+
+	tests := []struct {
+		name string
+		old  *Issue
+		new  *Issue
+		want []Change
+	}{
+		{
+			name: "new issue",
+			new:  old,
+			want: []Change{
+				{Kind: NewIssue, Number: 1, Title: "Test", Time: created, Author: "S7evinK", Body: "Hello"},
+				{Kind: NewComment, Number: 1, Title: "Test", Time: created, Author: "alice", Body: "first", CommentID: 11},
+			},
+		},
+		{
+			name: "unchanged",
+			old:  old,
+			new:  old,
+		},
+		{
+			name: "closed with new comment",
+			old:  old,
+			new: &Issue{Number: 1, Title: "Renamed", State: "closed", ClosedAt: closed, Comments: []Comment{
+				{Author: "alice", CreatedAt: created},
+				{Author: "bob", CreatedAt: closed, Body: "done"},
+			}},
+			want: []Change{
+				{Kind: StateChange, Number: 1, Title: "Renamed", Time: closed, From: "open", To: "closed"},
+				{Kind: TitleChange, Number: 1, Title: "Renamed", From: "Test", To: "Renamed"},
+				{Kind: NewComment, Number: 1, Title: "Renamed", Time: closed, Author: "bob", Body: "done"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Diff(tt.old, tt.new); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Diff() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
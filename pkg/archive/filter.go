@@ -0,0 +1,120 @@
+package archive
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Filter selects issues of the archive, empty fields match all issues
+type Filter struct {
+	Numbers   map[int]bool
+	State     string
+	Milestone string
+	Author    string
+	Label     string
+	// Since and Until limit the creation date of the issues, Until is exclusive
+	Since time.Time
+	Until time.Time
+	// Text is searched case-insensitively in the title, body and comments
+	Text string
+}
+
+// Match reports whether the issue is selected by the filter
+func (f *Filter) Match(i *Issue) bool {
+	switch {
+	case len(f.Numbers) > 0 && !f.Numbers[i.Number]:
+		return false
+	case f.State != "" && !strings.EqualFold(i.State, f.State):
+		return false
+	case f.Milestone != "" && !MatchMilestone(i.Milestone, f.Milestone):
+		return false
+	case f.Author != "" && !strings.EqualFold(i.Author, f.Author):
+		return false
+	case f.Label != "" && !containsFold(i.Labels, f.Label):
+		return false
+	case !f.Since.IsZero() && i.CreatedAt.Before(f.Since):
+		return false
+	case !f.Until.IsZero() && !i.CreatedAt.Before(f.Until):
+		return false
+	case f.Text != "" && !i.contains(f.Text):
+		return false
+	}
+	return true
+}
+
+// Apply returns the issues matching the filter
+func (f *Filter) Apply(issues []*Issue) []*Issue {
+	var selected []*Issue
+	for _, i := range issues {
+		if f.Match(i) {
+			selected = append(selected, i)
+		}
+	}
+	return selected
+}
+
+// contains reports whether the title, body or a comment of the issue contains text, ignoring case
+func (i *Issue) contains(text string) bool {
+	text = strings.ToLower(text)
+	if strings.Contains(strings.ToLower(i.Title), text) || strings.Contains(strings.ToLower(i.Body), text) {
+		return true
+	}
+	for _, c := range i.Comments {
+		if strings.Contains(strings.ToLower(c.Body), text) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsFold(list []string, s string) bool {
+	for _, l := range list {
+		if strings.EqualFold(l, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// sortKeys defines the order of issues for each sort key
+var sortKeys = map[string]func(a, b *Issue) bool{
+	"number":   func(a, b *Issue) bool { return a.Number < b.Number },
+	"title":    func(a, b *Issue) bool { return strings.ToLower(a.Title) < strings.ToLower(b.Title) },
+	"created":  func(a, b *Issue) bool { return a.CreatedAt.Before(b.CreatedAt) },
+	"updated":  func(a, b *Issue) bool { return a.LastActivity().Before(b.LastActivity()) },
+	"comments": func(a, b *Issue) bool { return len(a.Comments) < len(b.Comments) },
+}
+
+// SortKeys returns the keys accepted by Sort
+func SortKeys() []string {
+	var keys []string
+	for k := range sortKeys {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Sort sorts the issues by the given key, ties are sorted by number
+func Sort(issues []*Issue, key string, reverse bool) error {
+	less, ok := sortKeys[key]
+	if !ok {
+		return fmt.Errorf("unknown sort key %q, available: %s", key, strings.Join(SortKeys(), ", "))
+	}
+	sort.SliceStable(issues, func(i, j int) bool {
+		a, b := issues[i], issues[j]
+		if reverse {
+			a, b = b, a
+		}
+		if less(a, b) {
+			return true
+		}
+		if less(b, a) {
+			return false
+		}
+		return a.Number < b.Number
+	})
+	return nil
+}
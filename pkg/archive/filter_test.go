@@ -0,0 +1,91 @@
+package archive
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFilter(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2019, time.November, d, 12, 0, 0, 0, time.UTC) }
+	issues := []*Issue{
+		{Number: 1, Title: "Crash on start", State: "open", Author: "alice", CreatedAt: day(1), Milestone: "v1", Labels: []string{"bug"}},
+		{Number: 2, Title: "Docs", State: "closed", Author: "bob", CreatedAt: day(2), Comments: []Comment{{Body: "It CRASHES here too"}}},
+		{Number: 3, Title: "Feature", State: "open", Author: "Alice", CreatedAt: day(3)},
+	}
+
+	tests := []struct {
+		name   string
+		filter Filter
+		want   []int
+	}{
+		{name: "empty filter", want: []int{1, 2, 3}},
+		{name: "numbers", filter: Filter{Numbers: map[int]bool{2: true}}, want: []int{2}},
+		{name: "state", filter: Filter{State: "Open"}, want: []int{1, 3}},
+		{name: "author ignores case", filter: Filter{Author: "alice"}, want: []int{1, 3}},
+		{name: "milestone and label", filter: Filter{Milestone: "v1", Label: "BUG"}, want: []int{1}},
+		{name: "date range", filter: Filter{Since: day(2), Until: day(3)}, want: []int{2}},
+		{name: "text in title and comments", filter: Filter{Text: "crash"}, want: []int{1, 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := numbers(tt.filter.Apply(issues)); !equalInts(got, tt.want) {
+				t.Errorf("Apply() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSort(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2019, time.November, d, 12, 0, 0, 0, time.UTC) }
+	issues := []*Issue{
+		{Number: 1, Title: "b", CreatedAt: day(2)},
+		{Number: 2, Title: "A", CreatedAt: day(1), Comments: []Comment{{CreatedAt: day(5)}}},
+		{Number: 3, Title: "c", CreatedAt: day(2)},
+	}
+
+	tests := []struct {
+		key     string
+		reverse bool
+		want    []int
+	}{
+		{key: "title", want: []int{2, 1, 3}},
+		{key: "created", want: []int{2, 1, 3}},
+		{key: "created", reverse: true, want: []int{3, 1, 2}},
+		{key: "updated", reverse: true, want: []int{2, 3, 1}},
+		{key: "comments", reverse: true, want: []int{2, 3, 1}},
+		{key: "number", want: []int{1, 2, 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			if err := Sort(issues, tt.key, tt.reverse); err != nil {
+				t.Fatal(err)
+			}
+			if got := numbers(issues); !equalInts(got, tt.want) {
+				t.Errorf("Sort() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+	if err := Sort(issues, "unknown", false); err == nil {
+		t.Error("Sort() with unknown key should fail")
+	}
+}
+
+func numbers(issues []*Issue) []int {
+	var n []int
+	for _, i := range issues {
+		n = append(n, i.Number)
+	}
+	return n
+}
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,78 @@
+package archive
+
+import (
+	"bytes"
+	"time"
+
+	"github.com/pkg/errors"
+	yaml "gopkg.in/yaml.v2"
+)
+
+const frontMatterDelim = "---\n"
+
+// FrontMatter defines the YAML header written at the top of an issue file
+type FrontMatter struct {
+	Number    int        `yaml:"number"`
+	Title     string     `yaml:"title"`
+	State     string     `yaml:"state"`
+	Author    string     `yaml:"author"`
+	CreatedAt time.Time  `yaml:"created"`
+	ClosedAt  *time.Time `yaml:"closed,omitempty"`
+	UpdatedAt time.Time  `yaml:"updated"`
+	Milestone string     `yaml:"milestone,omitempty"`
+	URL       string     `yaml:"url,omitempty"`
+	Labels    []string   `yaml:"labels,omitempty"`
+	Assignees []string   `yaml:"assignees,omitempty"`
+}
+
+// Marshal returns the front matter including the surrounding delimiters
+func (fm *FrontMatter) Marshal() ([]byte, error) {
+	b, err := yaml.Marshal(fm)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(frontMatterDelim)
+	buf.Write(b)
+	buf.WriteString(frontMatterDelim)
+	buf.WriteString("\n")
+	return buf.Bytes(), nil
+}
+
+// HasFrontMatter reports whether b starts with a front matter block
+func HasFrontMatter(b []byte) bool {
+	return bytes.HasPrefix(b, []byte(frontMatterDelim))
+}
+
+// SplitFrontMatter separates the YAML of a front matter block at the start of b from the remaining content.
+// If b doesn't start with front matter, nil and the unchanged content are returned.
+func SplitFrontMatter(b []byte) ([]byte, []byte, error) {
+	if !HasFrontMatter(b) {
+		return nil, b, nil
+	}
+
+	rest := b[len(frontMatterDelim):]
+	end := bytes.Index(rest, []byte("\n"+frontMatterDelim))
+	if end < 0 {
+		return nil, b, errors.New("unterminated front matter")
+	}
+
+	content := rest[end+1+len(frontMatterDelim):]
+	return rest[:end+1], bytes.TrimPrefix(content, []byte("\n")), nil
+}
+
+// ParseFrontMatter extracts the front matter from b and returns it together with the remaining content.
+// If b doesn't start with front matter, a nil FrontMatter and the unchanged content are returned.
+func ParseFrontMatter(b []byte) (*FrontMatter, []byte, error) {
+	header, content, err := SplitFrontMatter(b)
+	if header == nil || err != nil {
+		return nil, b, err
+	}
+
+	fm := &FrontMatter{}
+	if err := yaml.Unmarshal(header, fm); err != nil {
+		return nil, b, errors.Wrap(err, "unable to parse front matter")
+	}
+	return fm, content, nil
+}
@@ -0,0 +1,71 @@
+package archive
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestFrontMatter(t *testing.T) {
+	closed := time.Date(2019, time.November, 16, 9, 12, 1, 0, time.UTC)
+	tests := []struct {
+		name string
+		fm   *FrontMatter
+	}{
+		{
+			name: "open issue",
+			fm: &FrontMatter{
+				Number:    1,
+				Title:     "Test issue",
+				State:     "open",
+				Author:    "S7evinK",
+				CreatedAt: time.Date(2019, time.November, 15, 13, 5, 33, 0, time.UTC),
+				UpdatedAt: time.Date(2019, time.November, 15, 13, 7, 38, 0, time.UTC),
+				URL:       "https://github.com/S7evinK/issues-to-go/issues/1",
+			},
+		},
+		{
+			name: "closed issue with labels",
+			fm: &FrontMatter{
+				Number:    2,
+				Title:     "title: with --- special chars",
+				State:     "closed",
+				Author:    "S7evinK",
+				CreatedAt: time.Date(2019, time.November, 15, 13, 5, 33, 0, time.UTC),
+				ClosedAt:  &closed,
+				UpdatedAt: closed,
+				Milestone: "v1.0",
+				Labels:    []string{"bug", "help wanted"},
+				Assignees: []string{"S7evinK"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := tt.fm.Marshal()
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+			content := "Test issue\n---\n\nHello World!\n"
+			fm, rest, err := ParseFrontMatter(append(b, content...))
+			if err != nil {
+				t.Fatalf("ParseFrontMatter() error = %v", err)
+			}
+			if !reflect.DeepEqual(fm, tt.fm) {
+				t.Errorf("ParseFrontMatter() = %+v, want %+v", fm, tt.fm)
+			}
+			if string(rest) != content {
+				t.Errorf("ParseFrontMatter() content = %q, want %q", rest, content)
+			}
+		})
+	}
+}
+
+func TestParseFrontMatterWithout(t *testing.T) {
+	content := []byte("Test issue\n---\n\nHello World!\n")
+	fm, rest, err := ParseFrontMatter(content)
+	if err != nil || fm != nil || string(rest) != string(content) {
+		t.Errorf("ParseFrontMatter() = %v, %q, %v; want nil, %q, nil", fm, rest, err, content)
+	}
+}
@@ -0,0 +1,267 @@
+package archive
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// BacklinksStart marks the beginning of the "Referenced by" section
+	BacklinksStart = "<!-- referenced-by -->"
+	// BacklinksEnd marks the end of the "Referenced by" section
+	BacklinksEnd = "<!-- /referenced-by -->"
+
+	// timeLayout is the layout used for dates in issue files
+	timeLayout = "2006-01-02 15:04:05.999999999 -0700 MST"
+)
+
+var (
+	// States contains the folders issues are stored in
+	States = []string{"open", "closed"}
+
+	regexBacklinks = regexp.MustCompile(`(?s)\n\n` + regexp.QuoteMeta(BacklinksStart) + `.*?` + regexp.QuoteMeta(BacklinksEnd) + `\n?`)
+	regexHeader    = regexp.MustCompile(`^(.*)\n---\n\nCreated by (\S*) on ([^\n]+):\n\n`)
+	regexSeparator = regexp.MustCompile(`\n\n---\n(?:\n(?:<a id="issuecomment-(\d+)"></a>)?(\S*) commented on ([^\n]+):\n\n|Closed on ([^\n]+)$|$)`)
+	// regexIssueURL matches the URL of an issue on Github or GitLab
+	regexIssueURL = regexp.MustCompile(`^https?://[^/]+/(.+?)(?:/-)?/issues/\d+`)
+)
+
+type (
+	// Issue is an issue read from the archive
+	Issue struct {
+		Number    int
+		Title     string
+		State     string
+		Author    string
+		CreatedAt time.Time
+		ClosedAt  time.Time
+		UpdatedAt time.Time
+		Milestone string
+		URL       string
+		Labels    []string
+		Assignees []string
+		Body      string
+		Comments  []Comment
+		// Path is the path to the issue file
+		Path string
+		// FrontMatter is set if the file has front matter. Otherwise labels, assignees and the URL are unknown
+		// and the milestone is only known from the milestone symlinks, see Load.
+		FrontMatter bool
+	}
+
+	// Comment is a comment of an issue read from the archive
+	Comment struct {
+		ID        int
+		Author    string
+		CreatedAt time.Time
+		Body      string
+	}
+)
+
+// Closed reports whether the issue is closed
+func (i *Issue) Closed() bool {
+	return i.State == "closed"
+}
+
+// Repository returns the repository (OWNER/REPOSITORY) of the issue, taken from its URL on Github or GitLab.
+// Returns an empty string, if the URL is unknown.
+func (i *Issue) Repository() string {
+	m := regexIssueURL.FindStringSubmatch(i.URL)
+	if m == nil {
+		return ""
+	}
+	return m[1]
+}
+
+// LastActivity returns the time of the last known change to the issue
+func (i *Issue) LastActivity() time.Time {
+	last := i.UpdatedAt
+	for _, t := range []time.Time{i.CreatedAt, i.ClosedAt} {
+		if t.After(last) {
+			last = t
+		}
+	}
+	for _, c := range i.Comments {
+		if c.CreatedAt.After(last) {
+			last = c.CreatedAt
+		}
+	}
+	return last
+}
+
+// StripBacklinks removes the "Referenced by" section from content
+func StripBacklinks(content []byte) []byte {
+	return regexBacklinks.ReplaceAll(content, nil)
+}
+
+// Parse parses the content of an issue file.
+// Metadata is taken from the front matter, if present.
+func Parse(content []byte) (*Issue, error) {
+	fm, rest, err := ParseFrontMatter(content)
+	if err != nil {
+		return nil, err
+	}
+	s := string(StripBacklinks(rest))
+
+	header := regexHeader.FindStringSubmatch(s)
+	if header == nil {
+		return nil, errors.New("unable to find issue header")
+	}
+	issue := &Issue{Title: header[1], Author: header[2]}
+	if issue.CreatedAt, err = parseTime(header[3]); err != nil {
+		return nil, err
+	}
+	s = s[len(header[0]):]
+
+	// the content is split into the issue body and comments by the separators
+	var (
+		start   int
+		comment *Comment
+	)
+	issue.Body = strings.TrimSuffix(s, "\n")
+separators:
+	for _, m := range regexSeparator.FindAllStringSubmatchIndex(s, -1) {
+		group := func(i int) string {
+			if m[2*i] < 0 {
+				return ""
+			}
+			return s[m[2*i]:m[2*i+1]]
+		}
+		if comment == nil {
+			issue.Body = s[start:m[0]]
+		} else {
+			comment.Body = s[start:m[0]]
+			issue.Comments = append(issue.Comments, *comment)
+		}
+		start = m[1]
+
+		switch {
+		case group(3) != "":
+			comment = &Comment{Author: group(2)}
+			comment.ID, _ = strconv.Atoi(group(1))
+			if comment.CreatedAt, err = parseTime(group(3)); err != nil {
+				return nil, err
+			}
+		case group(4) != "":
+			issue.State = "closed"
+			if issue.ClosedAt, err = parseTime(group(4)); err != nil {
+				return nil, err
+			}
+			break separators
+		default:
+			break separators
+		}
+	}
+
+	if fm != nil {
+		issue.applyFrontMatter(fm)
+	}
+	return issue, nil
+}
+
+func (i *Issue) applyFrontMatter(fm *FrontMatter) {
+	i.FrontMatter = true
+	i.Number = fm.Number
+	i.Title = fm.Title
+	i.State = fm.State
+	i.Author = fm.Author
+	i.CreatedAt = fm.CreatedAt
+	if fm.ClosedAt != nil {
+		i.ClosedAt = *fm.ClosedAt
+	}
+	i.UpdatedAt = fm.UpdatedAt
+	i.Milestone = fm.Milestone
+	i.URL = fm.URL
+	i.Labels = fm.Labels
+	i.Assignees = fm.Assignees
+}
+
+func parseTime(s string) (time.Time, error) {
+	t, err := time.Parse(timeLayout, s)
+	if err != nil {
+		return t, errors.Wrap(err, "unable to parse date")
+	}
+	return t, nil
+}
+
+// ReadFile reads and parses an issue file.
+// The number and state are derived from the path, if the file has no front matter.
+func ReadFile(path string) (*Issue, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	issue, err := Parse(b)
+	if err != nil {
+		return nil, errors.Wrap(err, path)
+	}
+	issue.Path = path
+	if issue.Number == 0 {
+		if issue.Number, err = strconv.Atoi(strings.TrimSuffix(filepath.Base(path), ".md")); err != nil {
+			return nil, errors.Wrap(err, path)
+		}
+	}
+	if issue.State == "" {
+		issue.State = filepath.Base(filepath.Dir(path))
+	}
+	return issue, nil
+}
+
+// Load reads all issues of the archive in dir, sorted by number. The milestones of issues without
+// front matter are taken from the milestone symlinks, see SetMilestones.
+// Files which can't be parsed are skipped and returned as error together with the issues.
+func Load(dir string) ([]*Issue, error) {
+	var (
+		issues []*Issue
+		errs   []string
+	)
+	for _, state := range States {
+		files, err := ioutil.ReadDir(filepath.Join(dir, state))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range files {
+			if !f.Mode().IsRegular() || filepath.Ext(f.Name()) != ".md" {
+				continue
+			}
+			issue, err := ReadFile(filepath.Join(dir, state, f.Name()))
+			if err != nil {
+				errs = append(errs, err.Error())
+				continue
+			}
+			issues = append(issues, issue)
+		}
+	}
+	sort.Slice(issues, func(i, j int) bool { return issues[i].Number < issues[j].Number })
+
+	if err := SetMilestones(dir, issues); err != nil {
+		return nil, err
+	}
+
+	if len(errs) > 0 {
+		return issues, errors.New("unable to read issues: " + strings.Join(errs, "; "))
+	}
+	return issues, nil
+}
+
+// Find reads the issue with the given number from the archive in dir, see ReadIssue
+func Find(dir string, number int) (*Issue, error) {
+	for _, state := range States {
+		path := filepath.Join(dir, state, strconv.Itoa(number)+".md")
+		if _, err := os.Stat(path); err == nil {
+			return ReadIssue(dir, path)
+		}
+	}
+	return nil, os.ErrNotExist
+}
@@ -0,0 +1,106 @@
+package archive
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	cet := time.FixedZone("CET", 3600)
+	tests := []struct {
+		name    string
+		content string
+		want    *Issue
+	}{
+		{
+			name: "open issue without comments",
+			content: "Test issue\n---\n\nCreated by S7evinK on 2019-11-15 13:05:33 +0100 CET:\n\n" +
+				"Hello World!\n\n---\nnot a comment\n\n---\n",
+			want: &Issue{
+				Title:     "Test issue",
+				Author:    "S7evinK",
+				CreatedAt: time.Date(2019, time.November, 15, 13, 5, 33, 0, cet),
+				Body:      "Hello World!\n\n---\nnot a comment",
+			},
+		},
+		{
+			name: "closed issue with comments and backlinks",
+			content: "Test issue\n---\n\nCreated by S7evinK on 2019-11-15 13:05:33 +0100 CET:\n\n" +
+				"Hello World!\n\n---\n" +
+				"\nS7evinK commented on 2019-11-15 13:07:38 +0100 CET:\n\n**This is a dummy comment.**\n\n---\n" +
+				"\n<a id=\"issuecomment-42\"></a>ghost commented on 2019-11-16 09:00:00 +0100 CET:\n\n\n\n---\n" +
+				"Closed on 2019-11-16 09:12:01 +0100 CET" +
+				"\n\n" + BacklinksStart + "\nReferenced by\n---\n\n- [#2](2.md)\n" + BacklinksEnd + "\n",
+			want: &Issue{
+				Title:     "Test issue",
+				State:     "closed",
+				Author:    "S7evinK",
+				CreatedAt: time.Date(2019, time.November, 15, 13, 5, 33, 0, cet),
+				ClosedAt:  time.Date(2019, time.November, 16, 9, 12, 1, 0, cet),
+				Body:      "Hello World!",
+				Comments: []Comment{
+					{Author: "S7evinK", CreatedAt: time.Date(2019, time.November, 15, 13, 7, 38, 0, cet), Body: "**This is a dummy comment.**"},
+					{ID: 42, Author: "ghost", CreatedAt: time.Date(2019, time.November, 16, 9, 0, 0, 0, cet)},
+				},
+			},
+		},
+		{
+			name: "front matter",
+			content: "---\nnumber: 3\ntitle: 'Title: from front matter'\nstate: open\nauthor: S7evinK\n" +
+				"created: 2019-11-15T13:05:33+01:00\nupdated: 2019-11-15T13:05:33+01:00\nmilestone: v1\nlabels:\n- bug\n---\n\n" +
+				"Title: from front matter\n---\n\nCreated by S7evinK on 2019-11-15 13:05:33 +0100 CET:\n\nHello\n\n---\n",
+			want: &Issue{
+				Number:      3,
+				Title:       "Title: from front matter",
+				State:       "open",
+				Author:      "S7evinK",
+				CreatedAt:   time.Date(2019, time.November, 15, 13, 5, 33, 0, cet),
+				UpdatedAt:   time.Date(2019, time.November, 15, 13, 5, 33, 0, cet),
+				Milestone:   "v1",
+				Labels:      []string{"bug"},
+				Body:        "Hello",
+				FrontMatter: true,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse([]byte(tt.content))
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if !reflect.DeepEqual(inUTC(got), inUTC(tt.want)) {
+				t.Errorf("Parse() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// inUTC converts all dates of an issue to UTC, since parsed locations differ depending on the local time zone
+func inUTC(i *Issue) *Issue {
+	i.CreatedAt, i.ClosedAt, i.UpdatedAt = i.CreatedAt.UTC(), i.ClosedAt.UTC(), i.UpdatedAt.UTC()
+	for n := range i.Comments {
+		i.Comments[n].CreatedAt = i.Comments[n].CreatedAt.UTC()
+	}
+	return i
+}
+
+func TestRepository(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{url: "https://github.com/S7evinK/issues-to-go/issues/12", want: "S7evinK/issues-to-go"},
+		{url: "https://gitlab.com/gitlab-org/gitlab-runner/-/issues/3", want: "gitlab-org/gitlab-runner"},
+		{url: "https://git.example.com/group/sub/project/-/issues/3", want: "group/sub/project"},
+		{url: "", want: ""},
+		{url: "https://github.com/S7evinK", want: ""},
+	}
+	for _, tt := range tests {
+		if got := (&Issue{URL: tt.url}).Repository(); got != tt.want {
+			t.Errorf("Repository() of %q = %q, want %q", tt.url, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package archive
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	regexLocalLink   = regexp.MustCompile(`^(?:(?:\.\.)?/(?:open|closed)/)?(\d+)\.md(?:#([\w-]+))?$`)
+	regexForeignLink = regexp.MustCompile(`^(?:\.\./)+(?:[^/]+/)*?([\w.-]+)/([\w.-]+)/(?:open|closed)/(\d+)\.md(?:#([\w-]+))?$`)
+	// regexForgeURL splits the URL of an issue on Github or GitLab into the URL of the forge, the project and the
+	// separator of GitLab's project pages
+	regexForgeURL = regexp.MustCompile(`^(https?://[^/]+)/(.+?)(/-)?/issues/\d+`)
+)
+
+// Link is a link between issue files
+type Link struct {
+	// Owner and Repo are empty for links to issues of the same archive
+	Owner    string
+	Repo     string
+	Number   int
+	Fragment string
+}
+
+// ParseLink parses the destination of a link in an issue file, which is relative to the file or,
+// if it starts with a slash, to the archive. Returns false, if the destination doesn't point to another issue file.
+func ParseLink(dest string) (Link, bool) {
+	if m := regexLocalLink.FindStringSubmatch(dest); m != nil {
+		n, err := strconv.Atoi(m[1])
+		return Link{Number: n, Fragment: m[2]}, err == nil
+	}
+	if m := regexForeignLink.FindStringSubmatch(dest); m != nil {
+		n, err := strconv.Atoi(m[3])
+		return Link{Owner: m[1], Repo: m[2], Number: n, Fragment: m[4]}, err == nil
+	}
+	return Link{}, false
+}
+
+// URL returns the URL of the linked issue on the forge of the issue at issueURL, which is an issue of the linking
+// archive. Links to the same archive point to its project, links to other archives to a project on the same forge.
+// Without issueURL, the issue is linked to Github. Returns an empty string, if the project of the link is unknown.
+func (l Link) URL(issueURL string) string {
+	base, project, sep := "https://github.com", "", ""
+	if l.Owner != "" {
+		project = l.Owner + "/" + l.Repo
+	}
+	if m := regexForgeURL.FindStringSubmatch(issueURL); m != nil {
+		base, sep = m[1], m[3]
+		if project == "" {
+			project = m[2]
+		}
+	}
+	if project == "" {
+		return ""
+	}
+	url := fmt.Sprintf("%s/%s%s/issues/%d", base, project, sep, l.Number)
+	switch {
+	case l.Fragment == "":
+	case sep != "" && strings.HasPrefix(l.Fragment, "issuecomment-"):
+		// comments are notes on GitLab
+		url += "#note_" + strings.TrimPrefix(l.Fragment, "issuecomment-")
+	default:
+		url += "#" + l.Fragment
+	}
+	return url
+}
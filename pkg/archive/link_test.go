@@ -0,0 +1,42 @@
+package archive
+
+import "testing"
+
+func TestLinkURL(t *testing.T) {
+	tests := []struct {
+		link     Link
+		issueURL string
+		want     string
+	}{
+		{
+			link:     Link{Number: 3, Fragment: "issuecomment-42"},
+			issueURL: "https://github.com/S7evinK/issues-to-go/issues/12",
+			want:     "https://github.com/S7evinK/issues-to-go/issues/3#issuecomment-42",
+		},
+		{
+			link:     Link{Owner: "other", Repo: "repo", Number: 3},
+			issueURL: "",
+			want:     "https://github.com/other/repo/issues/3",
+		},
+		{
+			link:     Link{Number: 3, Fragment: "issuecomment-42"},
+			issueURL: "https://git.example.com/group/sub/project/-/issues/12",
+			want:     "https://git.example.com/group/sub/project/-/issues/3#note_42",
+		},
+		{
+			link:     Link{Owner: "group", Repo: "other", Number: 3},
+			issueURL: "https://gitlab.com/group/project/-/issues/12",
+			want:     "https://gitlab.com/group/other/-/issues/3",
+		},
+		{
+			link:     Link{Number: 3},
+			issueURL: "",
+			want:     "",
+		},
+	}
+	for _, tt := range tests {
+		if got := tt.link.URL(tt.issueURL); got != tt.want {
+			t.Errorf("%+v.URL(%q) = %q, want %q", tt.link, tt.issueURL, got, tt.want)
+		}
+	}
+}
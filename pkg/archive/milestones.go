@@ -0,0 +1,89 @@
+package archive
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// MilestonesDir is the folder below the output folder, which contains the symlinks to the issues of every milestone
+const MilestonesDir = "milestones"
+
+// Milestones returns the milestones of the issues in the archive in dir by number, taken from the symlinks
+// in the milestones folder. Slashes can't be part of folder names, so they are returned as underscores.
+func Milestones(dir string) (map[int]string, error) {
+	milestones := make(map[int]string)
+	root := filepath.Join(dir, MilestonesDir)
+	folders, err := ioutil.ReadDir(root)
+	if os.IsNotExist(err) {
+		return milestones, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, ms := range folders {
+		if !ms.IsDir() {
+			continue
+		}
+		for _, state := range States {
+			entries, err := ioutil.ReadDir(filepath.Join(root, ms.Name(), state))
+			if os.IsNotExist(err) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			for _, e := range entries {
+				if e.Mode()&os.ModeSymlink == 0 || filepath.Ext(e.Name()) != ".md" {
+					continue
+				}
+				number, err := strconv.Atoi(strings.TrimSuffix(e.Name(), ".md"))
+				if err != nil {
+					continue
+				}
+				if _, ok := milestones[number]; !ok {
+					milestones[number] = ms.Name()
+				}
+			}
+		}
+	}
+	return milestones, nil
+}
+
+// SetMilestones sets the milestones of the issues without front matter from the symlinks of the archive in dir
+func SetMilestones(dir string, issues []*Issue) error {
+	var milestones map[int]string
+	for _, i := range issues {
+		if i.FrontMatter {
+			continue
+		}
+		if milestones == nil {
+			var err error
+			if milestones, err = Milestones(dir); err != nil {
+				return err
+			}
+		}
+		i.Milestone = milestones[i.Number]
+	}
+	return nil
+}
+
+// ReadIssue is like ReadFile for an issue file of the archive in dir, see SetMilestones
+func ReadIssue(dir, path string) (*Issue, error) {
+	issue, err := ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := SetMilestones(dir, []*Issue{issue}); err != nil {
+		return nil, err
+	}
+	return issue, nil
+}
+
+// MatchMilestone reports whether the milestone is the given one, ignoring the case. Milestones taken
+// from the symlinks have underscores instead of slashes, so slashes and underscores are the same.
+func MatchMilestone(milestone, value string) bool {
+	return strings.EqualFold(strings.Replace(milestone, "/", "_", -1), strings.Replace(value, "/", "_", -1))
+}
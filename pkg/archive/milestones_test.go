@@ -0,0 +1,84 @@
+package archive
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadMilestones(t *testing.T) {
+	dir, err := ioutil.TempDir("", "archive")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"open/1.md":   "First\n---\n\nCreated by alice on 2019-11-15 13:05:33 +0100 CET:\n\nHello\n\n---\n",
+		"closed/2.md": "Second\n---\n\nCreated by bob on 2019-11-15 13:05:33 +0100 CET:\n\nHello\n\n---\n",
+		"open/3.md":   "---\nnumber: 3\ntitle: Third\nstate: open\nmilestone: v2/beta\n---\n\nThird\n---\n\nCreated by bob on 2019-11-15 13:05:33 +0100 CET:\n\nHello\n\n---\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	links := map[string]string{
+		"milestones/v1.0/open/1.md":    "../../../open/1.md",
+		"milestones/v2_beta/open/3.md": "../../../open/3.md",
+	}
+	for name, target := range links {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Symlink(target, path); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	issues, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[int]string{1: "v1.0", 2: "", 3: "v2/beta"}
+	if len(issues) != len(want) {
+		t.Fatalf("got %d issues, want %d", len(issues), len(want))
+	}
+	for _, i := range issues {
+		if i.Milestone != want[i.Number] {
+			t.Errorf("issue %d has milestone %q, want %q", i.Number, i.Milestone, want[i.Number])
+		}
+	}
+
+	issue, err := Find(dir, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if issue.Milestone != "v1.0" {
+		t.Errorf("Find() returned milestone %q, want v1.0", issue.Milestone)
+	}
+}
+
+func TestMatchMilestone(t *testing.T) {
+	tests := []struct {
+		milestone, value string
+		want             bool
+	}{
+		{"v1.0", "V1.0", true},
+		{"v2_beta", "v2/beta", true},
+		{"v2/beta", "v2/beta", true},
+		{"v2", "v2/beta", false},
+		{"", "v1.0", false},
+	}
+	for _, tt := range tests {
+		if got := MatchMilestone(tt.milestone, tt.value); got != tt.want {
+			t.Errorf("MatchMilestone(%q, %q) = %v, want %v", tt.milestone, tt.value, got, tt.want)
+		}
+	}
+}
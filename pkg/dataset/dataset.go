@@ -0,0 +1,213 @@
+// Package dataset writes the archive as Parquet tables for data analysis.
+//
+// Issues, comments and events are written to separate tables, which are partitioned by repository:
+//
+//	DIR/issues/owner=OWNER/repo=REPOSITORY/data.parquet
+//	DIR/comments/owner=OWNER/repo=REPOSITORY/data.parquet
+//	DIR/events/owner=OWNER/repo=REPOSITORY/data.parquet
+package dataset
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/S7evinK/issues-to-go/pkg/archive"
+	"github.com/pkg/errors"
+	"github.com/xitongsys/parquet-go-source/local"
+	"github.com/xitongsys/parquet-go/parquet"
+	"github.com/xitongsys/parquet-go/writer"
+)
+
+// Tables contains the names of the written tables
+var Tables = []string{"issues", "comments", "events"}
+
+type (
+	// Issue is a row of the issues table
+	Issue struct {
+		Repository string   `parquet:"name=repository, type=UTF8, encoding=PLAIN_DICTIONARY"`
+		Number     int64    `parquet:"name=number, type=INT64"`
+		Title      string   `parquet:"name=title, type=UTF8"`
+		State      string   `parquet:"name=state, type=UTF8, encoding=PLAIN_DICTIONARY"`
+		Author     string   `parquet:"name=author, type=UTF8, encoding=PLAIN_DICTIONARY"`
+		CreatedAt  int64    `parquet:"name=created_at, type=TIMESTAMP_MILLIS"`
+		ClosedAt   *int64   `parquet:"name=closed_at, type=TIMESTAMP_MILLIS, repetitiontype=OPTIONAL"`
+		UpdatedAt  *int64   `parquet:"name=updated_at, type=TIMESTAMP_MILLIS, repetitiontype=OPTIONAL"`
+		Milestone  *string  `parquet:"name=milestone, type=UTF8, encoding=PLAIN_DICTIONARY, repetitiontype=OPTIONAL"`
+		Labels     []string `parquet:"name=labels, type=LIST, valuetype=UTF8"`
+		Assignees  []string `parquet:"name=assignees, type=LIST, valuetype=UTF8"`
+		Comments   int64    `parquet:"name=comments, type=INT64"`
+		URL        *string  `parquet:"name=url, type=UTF8, repetitiontype=OPTIONAL"`
+		Body       string   `parquet:"name=body, type=UTF8"`
+	}
+
+	// Comment is a row of the comments table
+	Comment struct {
+		Repository  string `parquet:"name=repository, type=UTF8, encoding=PLAIN_DICTIONARY"`
+		IssueNumber int64  `parquet:"name=issue_number, type=INT64"`
+		// Position is the position of the comment in the issue, starting at 1
+		Position  int64  `parquet:"name=position, type=INT64"`
+		CommentID *int64 `parquet:"name=comment_id, type=INT64, repetitiontype=OPTIONAL"`
+		Author    string `parquet:"name=author, type=UTF8, encoding=PLAIN_DICTIONARY"`
+		CreatedAt int64  `parquet:"name=created_at, type=TIMESTAMP_MILLIS"`
+		Body      string `parquet:"name=body, type=UTF8"`
+	}
+
+	// Event is a row of the events table: an issue was opened, commented on or closed
+	Event struct {
+		Repository  string  `parquet:"name=repository, type=UTF8, encoding=PLAIN_DICTIONARY"`
+		IssueNumber int64   `parquet:"name=issue_number, type=INT64"`
+		Event       string  `parquet:"name=event, type=UTF8, encoding=PLAIN_DICTIONARY"`
+		Actor       *string `parquet:"name=actor, type=UTF8, encoding=PLAIN_DICTIONARY, repetitiontype=OPTIONAL"`
+		CreatedAt   int64   `parquet:"name=created_at, type=TIMESTAMP_MILLIS"`
+		CommentID   *int64  `parquet:"name=comment_id, type=INT64, repetitiontype=OPTIONAL"`
+	}
+)
+
+// events maps the changes of a new issue to the event names
+var events = map[archive.ChangeKind]string{
+	archive.NewIssue:    "opened",
+	archive.NewComment:  "commented",
+	archive.StateChange: "closed",
+}
+
+// Write writes the issues of repo (OWNER/REPOSITORY) to the tables in dir. The owner of GitLab
+// projects may contain subgroups, which are part of the owner partition with slashes replaced by underscores.
+// Earlier exports of the repository are replaced, other repositories are left untouched.
+func Write(dir, repo string, issues []*archive.Issue) error {
+	i := strings.LastIndex(repo, "/")
+	if i <= 0 || i == len(repo)-1 {
+		return fmt.Errorf("invalid repository %q", repo)
+	}
+	owner, name := strings.Replace(repo[:i], "/", "_", -1), repo[i+1:]
+
+	var (
+		issueRows   []interface{}
+		commentRows []interface{}
+		eventRows   []interface{}
+	)
+	for _, issue := range issues {
+		issueRows = append(issueRows, issueRow(repo, issue))
+		for i, c := range issue.Comments {
+			commentRows = append(commentRows, Comment{
+				Repository:  repo,
+				IssueNumber: int64(issue.Number),
+				Position:    int64(i + 1),
+				CommentID:   optionalInt(c.ID),
+				Author:      c.Author,
+				CreatedAt:   millis(c.CreatedAt),
+				Body:        c.Body,
+			})
+		}
+		for _, c := range archive.Diff(nil, issue) {
+			name, ok := events[c.Kind]
+			if !ok || c.Time.IsZero() {
+				continue
+			}
+			eventRows = append(eventRows, Event{
+				Repository:  repo,
+				IssueNumber: int64(issue.Number),
+				Event:       name,
+				Actor:       optionalString(c.Author),
+				CreatedAt:   millis(c.Time),
+				CommentID:   optionalInt(c.CommentID),
+			})
+		}
+	}
+
+	tables := []struct {
+		name string
+		obj  interface{}
+		rows []interface{}
+	}{
+		{name: "issues", obj: new(Issue), rows: issueRows},
+		{name: "comments", obj: new(Comment), rows: commentRows},
+		{name: "events", obj: new(Event), rows: eventRows},
+	}
+	for _, t := range tables {
+		path := filepath.Join(dir, t.name, "owner="+owner, "repo="+name, "data.parquet")
+		if err := writeTable(path, t.obj, t.rows); err != nil {
+			return errors.Wrap(err, fmt.Sprintf("unable to write %s of %s", t.name, repo))
+		}
+	}
+	return nil
+}
+
+func issueRow(repo string, issue *archive.Issue) Issue {
+	row := Issue{
+		Repository: repo,
+		Number:     int64(issue.Number),
+		Title:      issue.Title,
+		State:      issue.State,
+		Author:     issue.Author,
+		CreatedAt:  millis(issue.CreatedAt),
+		Milestone:  optionalString(issue.Milestone),
+		Labels:     issue.Labels,
+		Assignees:  issue.Assignees,
+		Comments:   int64(len(issue.Comments)),
+		URL:        optionalString(issue.URL),
+		Body:       issue.Body,
+	}
+	if !issue.ClosedAt.IsZero() {
+		t := millis(issue.ClosedAt)
+		row.ClosedAt = &t
+	}
+	if !issue.UpdatedAt.IsZero() {
+		t := millis(issue.UpdatedAt)
+		row.UpdatedAt = &t
+	}
+	return row
+}
+
+// writeTable writes the rows to a temporary file, which replaces the file at path when done
+func writeTable(path string, obj interface{}, rows []interface{}) error {
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	fw, err := local.NewLocalFileWriter(tmp)
+	if err != nil {
+		return err
+	}
+	pw, err := writer.NewParquetWriter(fw, obj, 1)
+	if err != nil {
+		fw.Close()
+		return err
+	}
+	pw.CompressionType = parquet.CompressionCodec_SNAPPY
+	for _, row := range rows {
+		if err := pw.Write(row); err != nil {
+			fw.Close()
+			return err
+		}
+	}
+	if err := pw.WriteStop(); err != nil {
+		fw.Close()
+		return err
+	}
+	if err := fw.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func millis(t time.Time) int64 {
+	return t.UnixNano() / int64(time.Millisecond)
+}
+
+func optionalInt(i int) *int64 {
+	if i == 0 {
+		return nil
+	}
+	v := int64(i)
+	return &v
+}
+
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
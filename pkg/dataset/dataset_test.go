@@ -0,0 +1,119 @@
+package dataset
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/S7evinK/issues-to-go/pkg/archive"
+	"github.com/xitongsys/parquet-go-source/local"
+	"github.com/xitongsys/parquet-go/reader"
+)
+
+func TestWrite(t *testing.T) {
+	dir, err := ioutil.TempDir("", "issues-to-go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	created := time.Date(2019, time.November, 15, 13, 5, 33, 0, time.UTC)
+	issues := []*archive.Issue{
+		{
+			Number: 1, Title: "Test", State: "closed", Author: "S7evinK", CreatedAt: created, ClosedAt: created.Add(time.Hour),
+			Labels: []string{"bug", "help wanted"}, Body: "Hello",
+			Comments: []archive.Comment{{ID: 11, Author: "alice", CreatedAt: created.Add(time.Minute), Body: "first"}},
+		},
+		{Number: 2, Title: "Open", State: "open", Author: "bob", CreatedAt: created, Milestone: "v1"},
+	}
+	if err := Write(dir, "S7evinK/issues-to-go", issues); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	// writing again replaces the tables
+	if err := Write(dir, "S7evinK/issues-to-go", issues); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	gotIssues := make([]Issue, 2)
+	read(t, filepath.Join(dir, "issues", "owner=S7evinK", "repo=issues-to-go", "data.parquet"), new(Issue), &gotIssues, 2)
+	if got := gotIssues[0]; got.Number != 1 || got.ClosedAt == nil || *got.ClosedAt != millis(created.Add(time.Hour)) || len(got.Labels) != 2 || got.Comments != 1 {
+		t.Errorf("issue 1 = %+v", got)
+	}
+	if got := gotIssues[1]; got.ClosedAt != nil || got.Milestone == nil || *got.Milestone != "v1" {
+		t.Errorf("issue 2 = %+v", got)
+	}
+
+	gotComments := make([]Comment, 1)
+	read(t, filepath.Join(dir, "comments", "owner=S7evinK", "repo=issues-to-go", "data.parquet"), new(Comment), &gotComments, 1)
+	if got := gotComments[0]; got.IssueNumber != 1 || got.CommentID == nil || *got.CommentID != 11 || got.CreatedAt != millis(created.Add(time.Minute)) {
+		t.Errorf("comment = %+v", got)
+	}
+
+	gotEvents := make([]Event, 4)
+	read(t, filepath.Join(dir, "events", "owner=S7evinK", "repo=issues-to-go", "data.parquet"), new(Event), &gotEvents, 4)
+	var names []string
+	for _, e := range gotEvents {
+		names = append(names, e.Event)
+	}
+	if want := []string{"opened", "closed", "commented", "opened"}; !equal(names, want) {
+		t.Errorf("events = %v, want %v", names, want)
+	}
+}
+
+func TestWriteSubgroup(t *testing.T) {
+	dir, err := ioutil.TempDir("", "issues-to-go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	issues := []*archive.Issue{{Number: 1, Title: "Test", State: "open", Author: "alice", CreatedAt: time.Date(2019, time.November, 15, 13, 5, 33, 0, time.UTC)}}
+	if err := Write(dir, "group/sub/project", issues); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	gotIssues := make([]Issue, 1)
+	read(t, filepath.Join(dir, "issues", "owner=group_sub", "repo=project", "data.parquet"), new(Issue), &gotIssues, 1)
+	if got := gotIssues[0]; got.Repository != "group/sub/project" {
+		t.Errorf("issue = %+v", got)
+	}
+
+	for _, repo := range []string{"project", "/project", "group/"} {
+		if err := Write(dir, repo, issues); err == nil {
+			t.Errorf("Write(%q) succeeded, want an error", repo)
+		}
+	}
+}
+
+func read(t *testing.T, path string, obj, rows interface{}, want int) {
+	t.Helper()
+	fr, err := local.NewLocalFileReader(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fr.Close()
+	pr, err := reader.NewParquetReader(fr, obj, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pr.ReadStop()
+	if n := int(pr.GetNumRows()); n != want {
+		t.Fatalf("%s contains %d rows, want %d", path, n, want)
+	}
+	if err := pr.Read(rows); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
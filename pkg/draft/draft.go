@@ -0,0 +1,159 @@
+// Package draft reads and writes comments and issues written offline. Drafts of comments are stored as
+// NUMBER.md in the drafts folder of the archive, the number is the issue the comment is posted to.
+// New issues are stored in the folder new.
+package draft
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/S7evinK/issues-to-go/pkg/archive"
+	"github.com/pkg/errors"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Dir is the folder of the drafts in the output folder
+const Dir = "drafts"
+
+// Draft is a comment written offline
+type Draft struct {
+	Number int
+	// Comments is the number of comments of the issue when the draft was started, -1 if unknown
+	Comments int
+	Started  time.Time
+	Body     string
+	Path     string
+}
+
+// header is the front matter of a draft, it records the state of the issue when the draft was started
+type header struct {
+	Comments int       `yaml:"comments"`
+	Started  time.Time `yaml:"started"`
+}
+
+// Path returns the path of the draft for an issue
+func Path(dir string, number int) string {
+	return filepath.Join(dir, Dir, strconv.Itoa(number)+".md")
+}
+
+// Create writes an empty draft for the issue, recording its current number of comments.
+// Returns an error satisfying os.IsExist if there is a draft for the issue already.
+func Create(dir string, issue *archive.Issue, now time.Time) (*Draft, error) {
+	d := &Draft{Number: issue.Number, Comments: len(issue.Comments), Started: now, Path: Path(dir, issue.Number)}
+	if err := os.MkdirAll(filepath.Dir(d.Path), os.ModePerm); err != nil {
+		return nil, err
+	}
+	b, err := d.marshal()
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(d.Path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if _, err := f.Write(b); err != nil {
+		return nil, err
+	}
+	return d, f.Close()
+}
+
+// WriteFile writes the draft to its path
+func (d *Draft) WriteFile() error {
+	b, err := d.marshal()
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(d.Path, b, 0644)
+}
+
+func (d *Draft) marshal() ([]byte, error) {
+	b, err := yaml.Marshal(header{Comments: d.Comments, Started: d.Started})
+	if err != nil {
+		return nil, err
+	}
+	content := "---\n" + string(b) + "---\n\n"
+	if d.Body != "" {
+		content += d.Body + "\n"
+	}
+	return []byte(content), nil
+}
+
+// ReadFile reads a draft. The front matter is optional, without it the number of comments is unknown.
+func ReadFile(path string) (*Draft, error) {
+	number, err := strconv.Atoi(strings.TrimSuffix(filepath.Base(path), ".md"))
+	if err != nil {
+		return nil, errors.Errorf("%s: the file name must be the number of the issue", path)
+	}
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	yml, content, err := archive.SplitFrontMatter(b)
+	if err != nil {
+		return nil, errors.Wrap(err, path)
+	}
+	d := &Draft{Number: number, Comments: -1, Body: string(bytes.TrimSpace(content)), Path: path}
+	if yml != nil {
+		var h header
+		if err := yaml.Unmarshal(yml, &h); err != nil {
+			return nil, errors.Wrap(err, path)
+		}
+		d.Comments, d.Started = h.Comments, h.Started
+	}
+	return d, nil
+}
+
+// Load reads all drafts in the output folder, sorted by issue number.
+// Files which can't be read are skipped and returned as error together with the drafts.
+func Load(dir string) ([]*Draft, error) {
+	files, err := ioutil.ReadDir(filepath.Join(dir, Dir))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var (
+		drafts []*Draft
+		errs   []string
+	)
+	for _, f := range files {
+		if f.IsDir() || filepath.Ext(f.Name()) != ".md" {
+			continue
+		}
+		d, err := ReadFile(filepath.Join(dir, Dir, f.Name()))
+		if err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		drafts = append(drafts, d)
+	}
+	sort.Slice(drafts, func(i, j int) bool { return drafts[i].Number < drafts[j].Number })
+	if len(errs) > 0 {
+		return drafts, errors.New(strings.Join(errs, "; "))
+	}
+	return drafts, nil
+}
+
+// Conflict returns the number of comments added to the issue since the draft was started.
+// If that's unknown, the comments of the issue in the archive are taken as the state the draft is based on.
+func (d *Draft) Conflict(archived *archive.Issue, comments int) int {
+	base := d.Comments
+	if base < 0 {
+		base = 0
+		if archived != nil {
+			base = len(archived.Comments)
+		}
+	}
+	if comments < base {
+		return 0
+	}
+	return comments - base
+}
@@ -0,0 +1,101 @@
+package draft
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/S7evinK/issues-to-go/pkg/archive"
+)
+
+func TestCreateAndRead(t *testing.T) {
+	dir, err := ioutil.TempDir("", "draft")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	now := time.Date(2019, 11, 20, 10, 0, 0, 0, time.UTC)
+	issue := &archive.Issue{Number: 12, Comments: []archive.Comment{{Author: "alice"}, {Author: "bob"}}}
+	if _, err := Create(dir, issue, now); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Create(dir, issue, now); !os.IsExist(err) {
+		t.Errorf("got error %v for an existing draft, want os.IsExist", err)
+	}
+
+	path := Path(dir, 12)
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path, append(b, "Thanks, fixed in v2.\n"...), 0644); err != nil {
+		t.Fatal(err)
+	}
+	d, err := ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Number != 12 || d.Comments != 2 || !d.Started.Equal(now) || d.Body != "Thanks, fixed in v2." {
+		t.Errorf("unexpected draft %+v", d)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "draft")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := os.MkdirAll(filepath.Join(dir, Dir), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"7.md":      "Written without front matter\n",
+		"3.md":      "---\ncomments: 1\nstarted: 2019-11-20T10:00:00Z\n---\n\nReply\n",
+		"todo.md":   "not a draft",
+		"notes.txt": "ignored",
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, Dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	drafts, err := Load(dir)
+	if err == nil {
+		t.Error("expected an error for todo.md")
+	}
+	if len(drafts) != 2 || drafts[0].Number != 3 || drafts[1].Number != 7 {
+		t.Fatalf("got drafts %+v, want 3 and 7", drafts)
+	}
+	if drafts[0].Comments != 1 || drafts[1].Comments != -1 || drafts[1].Body != "Written without front matter" {
+		t.Errorf("unexpected drafts %+v %+v", drafts[0], drafts[1])
+	}
+}
+
+func TestConflict(t *testing.T) {
+	archived := &archive.Issue{Comments: []archive.Comment{{}, {}, {}}}
+	tests := []struct {
+		name     string
+		draft    Draft
+		archived *archive.Issue
+		comments int
+		want     int
+	}{
+		{name: "no new comments", draft: Draft{Comments: 2}, comments: 2, want: 0},
+		{name: "new comments", draft: Draft{Comments: 2}, comments: 5, want: 3},
+		{name: "deleted comments", draft: Draft{Comments: 2}, comments: 1, want: 0},
+		{name: "unknown base, archived", draft: Draft{Comments: -1}, archived: archived, comments: 4, want: 1},
+		{name: "unknown base, not archived", draft: Draft{Comments: -1}, comments: 4, want: 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.draft.Conflict(tt.archived, tt.comments); got != tt.want {
+				t.Errorf("Conflict() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
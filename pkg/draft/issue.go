@@ -0,0 +1,122 @@
+package draft
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/S7evinK/issues-to-go/pkg/archive"
+	"github.com/pkg/errors"
+)
+
+// NewDir is the folder of new issues in the output folder
+const NewDir = "new"
+
+// Issue is an issue written offline. It's stored as a Markdown file with front matter in the folder
+// of new issues, the file name doesn't matter.
+type Issue struct {
+	Title     string
+	Body      string
+	Labels    []string
+	Assignees []string
+	Milestone string
+	Path      string
+}
+
+// ReadIssue reads a new issue. The front matter must contain the title.
+func ReadIssue(path string) (*Issue, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	fm, content, err := archive.ParseFrontMatter(b)
+	if err != nil {
+		return nil, errors.Wrap(err, path)
+	}
+	if fm == nil || strings.TrimSpace(fm.Title) == "" {
+		return nil, errors.Errorf("%s: the front matter must contain the title", path)
+	}
+	if fm.Number > 0 {
+		return nil, errors.Errorf("%s: already published as issue %d", path, fm.Number)
+	}
+	return &Issue{
+		Title:     strings.TrimSpace(fm.Title),
+		Body:      strings.TrimSpace(string(content)),
+		Labels:    fm.Labels,
+		Assignees: fm.Assignees,
+		Milestone: fm.Milestone,
+		Path:      path,
+	}, nil
+}
+
+// LoadIssues reads all new issues in the output folder, sorted by path.
+// Files which can't be read are skipped and returned as error together with the issues.
+func LoadIssues(dir string) ([]*Issue, error) {
+	files, err := ioutil.ReadDir(filepath.Join(dir, NewDir))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var (
+		issues []*Issue
+		errs   []string
+	)
+	for _, f := range files {
+		if f.IsDir() || filepath.Ext(f.Name()) != ".md" {
+			continue
+		}
+		i, err := ReadIssue(filepath.Join(dir, NewDir, f.Name()))
+		if err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		issues = append(issues, i)
+	}
+	sort.Slice(issues, func(i, j int) bool { return issues[i].Path < issues[j].Path })
+	if len(errs) > 0 {
+		return issues, errors.New(strings.Join(errs, "; "))
+	}
+	return issues, nil
+}
+
+// Archive moves the published issue to open/NUMBER.md in the output folder, rewritten in the format
+// of downloaded issues. Returns the new path.
+func (i *Issue) Archive(dir string, number int, author, url string, created time.Time) (string, error) {
+	fm := &archive.FrontMatter{
+		Number:    number,
+		Title:     i.Title,
+		State:     "open",
+		Author:    author,
+		CreatedAt: created,
+		UpdatedAt: created,
+		Milestone: i.Milestone,
+		URL:       url,
+		Labels:    i.Labels,
+		Assignees: i.Assignees,
+	}
+	b, err := fm.Marshal()
+	if err != nil {
+		return "", err
+	}
+	b = append(b, fmt.Sprintf("%s\n---\n\nCreated by %s on %v:\n\n%s\n\n---\n", i.Title, author, created, i.Body)...)
+
+	path := filepath.Join(dir, "open", strconv.Itoa(number)+".md")
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return "", err
+	}
+	if err := ioutil.WriteFile(i.Path, b, os.ModePerm); err != nil {
+		return "", err
+	}
+	if err := os.Rename(i.Path, path); err != nil {
+		return "", err
+	}
+	i.Path = path
+	return path, nil
+}
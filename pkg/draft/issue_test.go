@@ -0,0 +1,103 @@
+package draft
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/S7evinK/issues-to-go/pkg/archive"
+)
+
+func TestReadIssue(t *testing.T) {
+	dir, err := ioutil.TempDir("", "draft")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		name    string
+		content string
+		want    *Issue
+		wantErr bool
+	}{
+		{
+			name:    "all fields",
+			content: "---\ntitle: Crash on start\nlabels:\n- bug\nassignees:\n- S7evinK\nmilestone: v1.0\n---\n\nSteps to reproduce\n",
+			want:    &Issue{Title: "Crash on start", Body: "Steps to reproduce", Labels: []string{"bug"}, Assignees: []string{"S7evinK"}, Milestone: "v1.0"},
+		},
+		{
+			name:    "title only",
+			content: "---\ntitle: Idea\n---\n",
+			want:    &Issue{Title: "Idea"},
+		},
+		{name: "no front matter", content: "Crash on start\n", wantErr: true},
+		{name: "no title", content: "---\nlabels:\n- bug\n---\n\nBody\n", wantErr: true},
+		{name: "already published", content: "---\nnumber: 3\ntitle: Crash\n---\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, "issue.md")
+			if err := ioutil.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			got, err := ReadIssue(path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ReadIssue() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			tt.want.Path = path
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ReadIssue() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestArchive(t *testing.T) {
+	dir, err := ioutil.TempDir("", "draft")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := os.MkdirAll(filepath.Join(dir, NewDir), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, NewDir, "crash.md")
+	if err := ioutil.WriteFile(path, []byte("---\ntitle: Crash on start\nlabels:\n- bug\n---\n\nSteps to reproduce\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	i, err := ReadIssue(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	created := time.Date(2019, 11, 20, 10, 0, 0, 0, time.UTC)
+	archived, err := i.Archive(dir, 42, "S7evinK", "https://github.com/S7evinK/issues-to-go/issues/42", created)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if archived != filepath.Join(dir, "open", "42.md") {
+		t.Errorf("got path %s, want open/42.md", archived)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("the file of the new issue wasn't moved")
+	}
+
+	issue, err := archive.ReadFile(archived)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if issue.Number != 42 || issue.Title != "Crash on start" || issue.Author != "S7evinK" || issue.Body != "Steps to reproduce" ||
+		!reflect.DeepEqual(issue.Labels, []string{"bug"}) || !issue.CreatedAt.Equal(created) {
+		t.Errorf("unexpected archived issue %+v", issue)
+	}
+	if _, err := ReadIssue(archived); err == nil {
+		t.Error("the archived issue can be published again")
+	}
+}
@@ -0,0 +1,370 @@
+// Package epub creates EPUB 3 books, which are also readable by EPUB 2 readers.
+package epub
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"html/template"
+	"io"
+	"path"
+	"strings"
+	"time"
+)
+
+type (
+	// Book defines the content of an EPUB file
+	Book struct {
+		Title      string
+		Language   string
+		Identifier string
+		Modified   time.Time
+		chapters   []*Chapter
+		images     []image
+		skipped    []string
+	}
+
+	// Fetcher downloads a remote image and returns its content and content type
+	Fetcher func(url string) ([]byte, string, error)
+
+	// Chapter is a single XHTML document of the book
+	Chapter struct {
+		ID       string
+		Title    string
+		Sections []Section
+		// Body is the XHTML content of the body element
+		Body template.HTML
+	}
+
+	// Section is an entry in the table of contents pointing into a chapter
+	Section struct {
+		ID    string
+		Title string
+	}
+
+	image struct {
+		name      string
+		href      string
+		mediaType string
+		data      []byte
+	}
+)
+
+const stylesheet = `body { font-family: serif; line-height: 1.4; }
+h1 { font-size: 1.4em; }
+h2 { font-size: 1.1em; border-top: 1px solid #999; padding-top: 0.5em; }
+.meta { font-size: 0.9em; color: #555; }
+pre { white-space: pre-wrap; font-size: 0.85em; }
+code { font-family: monospace; }
+blockquote { margin-left: 1em; padding-left: 0.5em; border-left: 2px solid #999; }
+img { max-width: 100%; }
+`
+
+var mediaTypes = map[string]string{
+	".gif":  "image/gif",
+	".jpeg": "image/jpeg",
+	".jpg":  "image/jpeg",
+	".png":  "image/png",
+	".svg":  "image/svg+xml",
+	".webp": "image/webp",
+}
+
+// extensions are the file extensions of the media types of downloaded images
+var extensions = map[string]string{
+	"image/gif":     ".gif",
+	"image/jpeg":    ".jpg",
+	"image/png":     ".png",
+	"image/svg+xml": ".svg",
+	"image/webp":    ".webp",
+}
+
+// New creates a new, empty book
+func New(title, identifier string) *Book {
+	return &Book{Title: title, Language: "en", Identifier: identifier, Modified: time.Now()}
+}
+
+// AddChapter adds a chapter to the end of the book
+func (b *Book) AddChapter(c *Chapter) {
+	b.chapters = append(b.chapters, c)
+}
+
+// Href returns the file name of a chapter
+func (c *Chapter) Href() string {
+	return c.ID + ".xhtml"
+}
+
+// AddImage adds an image to the book and returns its path relative to the chapters.
+// Images with the same name are only added once. Returns false, if the image format isn't supported.
+func (b *Book) AddImage(name string, data []byte) (string, bool) {
+	if href, ok := b.image(name); ok {
+		return href, true
+	}
+	ext := strings.ToLower(path.Ext(name))
+	mediaType, ok := mediaTypes[ext]
+	if !ok {
+		return "", false
+	}
+	return b.addImage(name, ext, mediaType, data), true
+}
+
+// AddRemoteImage downloads the image at url with fetch and adds it to the book like AddImage. The format is
+// taken from the content type or the extension. Images which aren't added are recorded, see Skipped.
+func (b *Book) AddRemoteImage(url string, fetch Fetcher) (string, bool) {
+	if href, ok := b.image(url); ok {
+		return href, true
+	}
+	if fetch == nil {
+		b.skip(url)
+		return "", false
+	}
+	data, contentType, err := fetch(url)
+	if err != nil {
+		b.skip(fmt.Sprintf("%s (%v)", url, err))
+		return "", false
+	}
+	mediaType := strings.TrimSpace(strings.Split(contentType, ";")[0])
+	ext, ok := extensions[mediaType]
+	if !ok {
+		// servers may send a generic content type like application/octet-stream
+		ext = strings.ToLower(path.Ext(strings.Split(url, "?")[0]))
+		if mediaType, ok = mediaTypes[ext]; !ok {
+			b.skip(fmt.Sprintf("%s (unsupported format %s)", url, contentType))
+			return "", false
+		}
+	}
+	return b.addImage(url, ext, mediaType, data), true
+}
+
+// Skipped returns the remote images which weren't embedded, followed by the reason if they couldn't be added
+func (b *Book) Skipped() []string {
+	return b.skipped
+}
+
+// image returns the path of the image with the name, if it was added already
+func (b *Book) image(name string) (string, bool) {
+	for _, img := range b.images {
+		if img.name == name {
+			return img.href, true
+		}
+	}
+	return "", false
+}
+
+func (b *Book) addImage(name, ext, mediaType string, data []byte) string {
+	href := fmt.Sprintf("images/%d%s", len(b.images), ext)
+	b.images = append(b.images, image{name: name, href: href, mediaType: mediaType, data: data})
+	return href
+}
+
+func (b *Book) skip(msg string) {
+	for _, s := range b.skipped {
+		if s == msg {
+			return
+		}
+	}
+	b.skipped = append(b.skipped, msg)
+}
+
+// Write writes the book as EPUB file to w
+func (b *Book) Write(w io.Writer) error {
+	z := zip.NewWriter(w)
+
+	// the mimetype must be the first file and must not be compressed
+	mt, err := z.CreateHeader(&zip.FileHeader{Name: "mimetype", Method: zip.Store})
+	if err != nil {
+		return err
+	}
+	if _, err := mt.Write([]byte("application/epub+zip")); err != nil {
+		return err
+	}
+
+	files := []struct {
+		name string
+		tmpl *template.Template
+		data interface{}
+	}{
+		{name: "META-INF/container.xml", tmpl: containerTemplate},
+		{name: "OEBPS/content.opf", tmpl: opfTemplate, data: b.templateData()},
+		{name: "OEBPS/nav.xhtml", tmpl: navTemplate, data: b.templateData()},
+		{name: "OEBPS/toc.ncx", tmpl: ncxTemplate, data: b.templateData()},
+	}
+	for _, c := range b.chapters {
+		files = append(files, struct {
+			name string
+			tmpl *template.Template
+			data interface{}
+		}{name: "OEBPS/" + c.Href(), tmpl: chapterTemplate, data: map[string]interface{}{"Book": b, "Chapter": c}})
+	}
+
+	for _, f := range files {
+		var buf bytes.Buffer
+		if err := f.tmpl.Execute(&buf, f.data); err != nil {
+			return err
+		}
+		if err := writeFile(z, f.name, buf.Bytes()); err != nil {
+			return err
+		}
+	}
+
+	if err := writeFile(z, "OEBPS/style.css", []byte(stylesheet)); err != nil {
+		return err
+	}
+	for _, img := range b.images {
+		if err := writeFile(z, "OEBPS/"+img.href, img.data); err != nil {
+			return err
+		}
+	}
+
+	return z.Close()
+}
+
+func writeFile(z *zip.Writer, name string, data []byte) error {
+	f, err := z.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	return err
+}
+
+type navPoint struct {
+	ID        string
+	Title     string
+	Href      string
+	PlayOrder int
+	Children  []navPoint
+}
+
+func (b *Book) templateData() map[string]interface{} {
+	var (
+		points []navPoint
+		order  = 1
+		images []map[string]string
+	)
+	for _, c := range b.chapters {
+		p := navPoint{ID: c.ID, Title: c.Title, Href: c.Href(), PlayOrder: order}
+		order++
+		for _, s := range c.Sections {
+			p.Children = append(p.Children, navPoint{ID: c.ID + "-" + s.ID, Title: s.Title, Href: c.Href() + "#" + s.ID, PlayOrder: order})
+			order++
+		}
+		points = append(points, p)
+	}
+	for i, img := range b.images {
+		images = append(images, map[string]string{"ID": fmt.Sprintf("image%d", i), "Href": img.href, "MediaType": img.mediaType})
+	}
+	return map[string]interface{}{
+		"Book":     b,
+		"Modified": b.Modified.UTC().Format("2006-01-02T15:04:05Z"),
+		"Chapters": b.chapters,
+		"Points":   points,
+		"Images":   images,
+	}
+}
+
+var (
+	funcs = template.FuncMap{
+		// xml prevents html/template from escaping XML declarations
+		"xml": func(s string) template.HTML { return template.HTML(s) },
+	}
+
+	containerTemplate = template.Must(template.New("container").Funcs(funcs).Parse(`{{xml "<?xml version=\"1.0\" encoding=\"UTF-8\"?>"}}
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+  <rootfiles>
+    <rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/>
+  </rootfiles>
+</container>
+`))
+
+	opfTemplate = template.Must(template.New("opf").Funcs(funcs).Parse(`{{xml "<?xml version=\"1.0\" encoding=\"UTF-8\"?>"}}
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0" unique-identifier="book-id" xml:lang="{{.Book.Language}}">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+    <dc:identifier id="book-id">{{.Book.Identifier}}</dc:identifier>
+    <dc:title>{{.Book.Title}}</dc:title>
+    <dc:language>{{.Book.Language}}</dc:language>
+    <meta property="dcterms:modified">{{.Modified}}</meta>
+  </metadata>
+  <manifest>
+    <item id="nav" href="nav.xhtml" media-type="application/xhtml+xml" properties="nav"/>
+    <item id="ncx" href="toc.ncx" media-type="application/x-dtbncx+xml"/>
+    <item id="style" href="style.css" media-type="text/css"/>
+{{- range .Chapters}}
+    <item id="{{.ID}}" href="{{.Href}}" media-type="application/xhtml+xml"/>
+{{- end}}
+{{- range .Images}}
+    <item id="{{.ID}}" href="{{.Href}}" media-type="{{.MediaType}}"/>
+{{- end}}
+  </manifest>
+  <spine toc="ncx">
+{{- range .Chapters}}
+    <itemref idref="{{.ID}}"/>
+{{- end}}
+  </spine>
+</package>
+`))
+
+	navTemplate = template.Must(template.New("nav").Funcs(funcs).Parse(`{{xml "<?xml version=\"1.0\" encoding=\"UTF-8\"?>"}}
+<!DOCTYPE html>
+<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops" xml:lang="{{.Book.Language}}" lang="{{.Book.Language}}">
+<head>
+  <title>{{.Book.Title}}</title>
+</head>
+<body>
+  <nav epub:type="toc" id="toc">
+    <h1>{{.Book.Title}}</h1>
+    <ol>
+{{- range .Points}}
+      <li><a href="{{.Href}}">{{.Title}}</a>
+{{- if .Children}}
+        <ol>
+{{- range .Children}}
+          <li><a href="{{.Href}}">{{.Title}}</a></li>
+{{- end}}
+        </ol>
+{{- end}}
+      </li>
+{{- end}}
+    </ol>
+  </nav>
+</body>
+</html>
+`))
+
+	ncxTemplate = template.Must(template.New("ncx").Funcs(funcs).Parse(`{{xml "<?xml version=\"1.0\" encoding=\"UTF-8\"?>"}}
+<ncx xmlns="http://www.daisy.org/z3986/2005/ncx/" version="2005-1">
+  <head>
+    <meta name="dtb:uid" content="{{.Book.Identifier}}"/>
+  </head>
+  <docTitle><text>{{.Book.Title}}</text></docTitle>
+  <navMap>
+{{- range .Points}}
+    <navPoint id="{{.ID}}" playOrder="{{.PlayOrder}}">
+      <navLabel><text>{{.Title}}</text></navLabel>
+      <content src="{{.Href}}"/>
+{{- range .Children}}
+      <navPoint id="{{.ID}}" playOrder="{{.PlayOrder}}">
+        <navLabel><text>{{.Title}}</text></navLabel>
+        <content src="{{.Href}}"/>
+      </navPoint>
+{{- end}}
+    </navPoint>
+{{- end}}
+  </navMap>
+</ncx>
+`))
+
+	chapterTemplate = template.Must(template.New("chapter").Funcs(funcs).Parse(`{{xml "<?xml version=\"1.0\" encoding=\"UTF-8\"?>"}}
+<!DOCTYPE html>
+<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops" xml:lang="{{.Book.Language}}" lang="{{.Book.Language}}">
+<head>
+  <title>{{.Chapter.Title}}</title>
+  <link rel="stylesheet" type="text/css" href="style.css"/>
+</head>
+<body>
+<section epub:type="chapter" id="{{.Chapter.ID}}">
+{{.Chapter.Body}}
+</section>
+</body>
+</html>
+`))
+)
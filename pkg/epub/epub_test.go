@@ -0,0 +1,145 @@
+package epub
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/S7evinK/issues-to-go/pkg/archive"
+)
+
+func TestFromIssues(t *testing.T) {
+	created := time.Date(2019, time.November, 15, 13, 5, 33, 0, time.UTC)
+	issues := []*archive.Issue{
+		{
+			Number:    1,
+			Title:     "First <issue>",
+			State:     "open",
+			Author:    "alice",
+			CreatedAt: created,
+			Body:      "See [#2](../closed/2.md) and [#3](3.md) <b>raw</b>",
+			Comments:  []archive.Comment{{ID: 11, Author: "bob", CreatedAt: created, Body: "[comment](../closed/2.md#issuecomment-21)"}},
+			URL:       "https://github.com/o/r/issues/1",
+		},
+		{
+			Number:    2,
+			Title:     "Second",
+			State:     "closed",
+			Author:    "bob",
+			CreatedAt: created,
+			Body:      "Hello",
+			Comments:  []archive.Comment{{ID: 21, Author: "alice", CreatedAt: created, Body: "ok"}},
+		},
+	}
+
+	book, err := FromIssues("Test", issues, nil)
+	if err != nil {
+		t.Fatalf("FromIssues() error = %v", err)
+	}
+	var buf bytes.Buffer
+	if err := book.Write(&buf); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	z, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if z.File[0].Name != "mimetype" || z.File[0].Method != zip.Store {
+		t.Errorf("first file = %s (method %d), want uncompressed mimetype", z.File[0].Name, z.File[0].Method)
+	}
+
+	files := make(map[string]string)
+	for _, f := range z.File {
+		r, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := ioutil.ReadAll(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[f.Name] = string(b)
+
+		if strings.HasSuffix(f.Name, ".xhtml") || strings.HasSuffix(f.Name, ".opf") || strings.HasSuffix(f.Name, ".ncx") {
+			d := xml.NewDecoder(bytes.NewReader(b))
+			d.Strict = true
+			for {
+				if _, err := d.Token(); err == io.EOF {
+					break
+				} else if err != nil {
+					t.Errorf("%s is not well-formed: %v", f.Name, err)
+					break
+				}
+			}
+		}
+	}
+
+	chapter := files["OEBPS/issue-1.xhtml"]
+	for _, want := range []string{
+		`<a href="issue-2.xhtml">#2</a>`,
+		`<a href="https://github.com/o/r/issues/3">#3</a>`,
+		`<a href="issue-2.xhtml#issuecomment-21">comment</a>`,
+		`<section id="issuecomment-11">`,
+		`#1 First &lt;issue&gt;`,
+	} {
+		if !strings.Contains(chapter, want) {
+			t.Errorf("chapter doesn't contain %q:\n%s", want, chapter)
+		}
+	}
+	if !strings.Contains(files["OEBPS/nav.xhtml"], `href="issue-2.xhtml#issuecomment-21"`) {
+		t.Errorf("table of contents doesn't contain comment section:\n%s", files["OEBPS/nav.xhtml"])
+	}
+}
+
+func TestRemoteImages(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/logo":
+			w.Header().Set("Content-Type", "image/png")
+			_, _ = w.Write([]byte("png"))
+		case "/photo.jpg":
+			w.Header().Set("Content-Type", "application/octet-stream")
+			_, _ = w.Write([]byte("jpg"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	issues := []*archive.Issue{{
+		Number: 1,
+		Title:  "Images",
+		Author: "alice",
+		Body:   "![logo](" + srv.URL + "/logo) ![photo](" + srv.URL + "/photo.jpg) ![missing](" + srv.URL + "/missing.png) ![again](" + srv.URL + "/logo)",
+	}}
+
+	book, err := FromIssues("Test", issues, Download(srv.Client()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(book.images) != 2 || book.images[0].href != "images/0.png" || book.images[1].href != "images/1.jpg" || book.images[1].mediaType != "image/jpeg" {
+		t.Errorf("got images %+v, want the png and the jpg", book.images)
+	}
+	if skipped := book.Skipped(); len(skipped) != 1 || !strings.HasPrefix(skipped[0], srv.URL+"/missing.png (404") {
+		t.Errorf("got skipped images %q, want missing.png", skipped)
+	}
+	if body := string(book.chapters[0].Body); !strings.Contains(body, `src="images/0.png"`) || !strings.Contains(body, `src="`+srv.URL+`/missing.png"`) {
+		t.Errorf("unexpected chapter:\n%s", body)
+	}
+
+	book, err = FromIssues("Test", issues, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(book.images) != 0 || len(book.Skipped()) != 3 {
+		t.Errorf("got %d images and skipped %q, want the 3 remote images skipped", len(book.images), book.Skipped())
+	}
+}
@@ -0,0 +1,185 @@
+package epub
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"fmt"
+	"html/template"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/S7evinK/issues-to-go/pkg/archive"
+	"github.com/S7evinK/issues-to-go/pkg/render"
+)
+
+const dateLayout = "2006-01-02 15:04 MST"
+
+// FromIssues creates a book with one chapter per issue and one section per comment.
+// Links between the issues are converted to links between the chapters, local images are embedded.
+// Remote images are downloaded by fetch and embedded, they are only linked if fetch is nil, see Skipped.
+func FromIssues(title string, issues []*archive.Issue, fetch Fetcher) (*Book, error) {
+	h := sha1.New()
+	_, _ = h.Write([]byte(title))
+	var modified time.Time
+	for _, issue := range issues {
+		_, _ = h.Write([]byte(strconv.Itoa(issue.Number)))
+		if last := issue.LastActivity(); last.After(modified) {
+			modified = last
+		}
+	}
+
+	b := New(title, fmt.Sprintf("urn:issues-to-go:%x", h.Sum(nil)))
+	if !modified.IsZero() {
+		b.Modified = modified
+	}
+
+	chapters := make(map[int]*Chapter)
+	for _, issue := range issues {
+		chapters[issue.Number] = &Chapter{ID: fmt.Sprintf("issue-%d", issue.Number), Title: fmt.Sprintf("#%d %s", issue.Number, issue.Title)}
+	}
+
+	for _, issue := range issues {
+		c := chapters[issue.Number]
+		body, err := b.issueBody(issue, chapters, fetch)
+		if err != nil {
+			return nil, err
+		}
+		c.Body = body
+		for i, com := range issue.Comments {
+			c.Sections = append(c.Sections, Section{ID: commentID(com, i), Title: commentTitle(com)})
+		}
+		b.AddChapter(c)
+	}
+	return b, nil
+}
+
+// maxImageSize is the maximum size of a downloaded image
+const maxImageSize = 20 << 20
+
+// Download returns a fetcher downloading images with the client
+func Download(client *http.Client) Fetcher {
+	return func(url string) ([]byte, string, error) {
+		resp, err := client.Get(url)
+		if err != nil {
+			return nil, "", err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, "", fmt.Errorf("%s", resp.Status)
+		}
+		data, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxImageSize+1))
+		if err != nil {
+			return nil, "", err
+		}
+		if len(data) > maxImageSize {
+			return nil, "", fmt.Errorf("larger than %d MB", maxImageSize>>20)
+		}
+		return data, resp.Header.Get("Content-Type"), nil
+	}
+}
+
+func commentID(c archive.Comment, i int) string {
+	if c.ID > 0 {
+		return fmt.Sprintf("issuecomment-%d", c.ID)
+	}
+	return fmt.Sprintf("comment-%d", i+1)
+}
+
+func commentTitle(c archive.Comment) string {
+	return fmt.Sprintf("%s commented on %s", c.Author, c.CreatedAt.Format(dateLayout))
+}
+
+// issueBody renders the issue including all comments as XHTML
+func (b *Book) issueBody(issue *archive.Issue, chapters map[int]*Chapter, fetch Fetcher) (template.HTML, error) {
+	var (
+		buf     bytes.Buffer
+		esc     = template.HTMLEscapeString
+		rewrite = b.linkFunc(issue, chapters, fetch)
+	)
+
+	buf.WriteString(fmt.Sprintf("<h1>#%d %s</h1>\n", issue.Number, esc(issue.Title)))
+
+	meta := []string{fmt.Sprintf("Created by %s on %s", esc(issue.Author), issue.CreatedAt.Format(dateLayout))}
+	if issue.Closed() && !issue.ClosedAt.IsZero() {
+		meta = append(meta, "closed on "+issue.ClosedAt.Format(dateLayout))
+	}
+	if issue.Milestone != "" {
+		meta = append(meta, "milestone "+esc(issue.Milestone))
+	}
+	if len(issue.Labels) > 0 {
+		meta = append(meta, "labels: "+esc(strings.Join(issue.Labels, ", ")))
+	}
+	buf.WriteString(`<p class="meta">` + strings.Join(meta, " · ") + "</p>\n")
+
+	body, err := render.HTML([]byte(issue.Body), rewrite)
+	if err != nil {
+		return "", err
+	}
+	buf.Write(body)
+
+	for i, com := range issue.Comments {
+		body, err := render.HTML([]byte(com.Body), rewrite)
+		if err != nil {
+			return "", err
+		}
+		buf.WriteString(fmt.Sprintf("<section id=\"%s\">\n<h2>%s</h2>\n", commentID(com, i), esc(commentTitle(com))))
+		buf.Write(body)
+		buf.WriteString("</section>\n")
+	}
+
+	return template.HTML(buf.String()), nil
+}
+
+// linkFunc returns a function rewriting links to issues and embedding images
+func (b *Book) linkFunc(issue *archive.Issue, chapters map[int]*Chapter, fetch Fetcher) render.LinkFunc {
+	return func(dest string, image bool) string {
+		if u, err := url.Parse(dest); err != nil || u.Scheme != "" || u.Host != "" {
+			if image && err == nil && (u.Scheme == "http" || u.Scheme == "https") {
+				if href, ok := b.AddRemoteImage(dest, fetch); ok {
+					return href
+				}
+			}
+			return dest
+		}
+
+		if image {
+			if issue.Path == "" {
+				return dest
+			}
+			path := filepath.Join(filepath.Dir(issue.Path), filepath.FromSlash(dest))
+			data, err := ioutil.ReadFile(path)
+			if err != nil {
+				return dest
+			}
+			if href, ok := b.AddImage(path, data); ok {
+				return href
+			}
+			return dest
+		}
+
+		link, ok := archive.ParseLink(dest)
+		if !ok {
+			return dest
+		}
+		if link.Owner != "" {
+			return link.URL(issue.URL)
+		}
+		if c, ok := chapters[link.Number]; ok {
+			if link.Fragment != "" {
+				return c.Href() + "#" + link.Fragment
+			}
+			return c.Href()
+		}
+		// issues which aren't part of the book are linked to the forge, if the URL is known
+		if u := link.URL(issue.URL); u != "" {
+			return u
+		}
+		return dest
+	}
+}
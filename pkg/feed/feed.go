@@ -0,0 +1,218 @@
+// Package feed maintains an Atom feed of the changes made to an archive.
+package feed
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/S7evinK/issues-to-go/pkg/archive"
+	"github.com/S7evinK/issues-to-go/pkg/render"
+	"github.com/pkg/errors"
+)
+
+type (
+	// Feed is an Atom feed document
+	Feed struct {
+		XMLName xml.Name `xml:"http://www.w3.org/2005/Atom feed"`
+		ID      string   `xml:"id"`
+		Title   string   `xml:"title"`
+		Updated string   `xml:"updated"`
+		Entries []Entry  `xml:"entry"`
+	}
+
+	// Entry is a single change in the feed
+	Entry struct {
+		ID      string  `xml:"id"`
+		Title   string  `xml:"title"`
+		Updated string  `xml:"updated"`
+		Author  Person  `xml:"author"`
+		Link    Link    `xml:"link"`
+		Content Content `xml:"content"`
+	}
+
+	// Person is the author of an entry
+	Person struct {
+		Name string `xml:"name"`
+	}
+
+	// Link points to the issue file of an entry, relative to the feed
+	Link struct {
+		Href string `xml:"href,attr"`
+	}
+
+	// Content is the HTML content of an entry
+	Content struct {
+		Type string `xml:"type,attr"`
+		Body string `xml:",chardata"`
+	}
+)
+
+// Update adds entries for new issues, new comments and state changes to the feed at path.
+// Entries older than window are removed. Returns the number of added entries.
+func Update(path, repo string, changes []archive.Change, window time.Duration, now time.Time) (int, error) {
+	f, err := read(path)
+	if err != nil {
+		return 0, err
+	}
+	f.ID = "urn:issues-to-go:" + repo
+	f.Title = "Issues of " + repo
+
+	known := make(map[string]bool)
+	for _, e := range f.Entries {
+		known[e.ID] = true
+	}
+	added := 0
+	before := now.Add(-window)
+	for _, c := range changes {
+		e, ok := entry(filepath.Dir(path), repo, c, now)
+		if !ok || known[e.ID] || (!c.Time.IsZero() && c.Time.Before(before)) {
+			continue
+		}
+		known[e.ID] = true
+		f.Entries = append(f.Entries, e)
+		added++
+	}
+
+	f.expire(before)
+	f.Updated = now.UTC().Format(time.RFC3339)
+	if len(f.Entries) > 0 {
+		f.Updated = f.Entries[0].Updated
+	}
+
+	return added, write(path, f)
+}
+
+// read reads the feed at path, a missing file results in an empty feed
+func read(path string) (*Feed, error) {
+	f := &Feed{}
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return f, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := xml.Unmarshal(b, f); err != nil {
+		return nil, errors.Wrap(err, "unable to parse feed")
+	}
+	return f, nil
+}
+
+// write writes the feed atomically to path
+func write(path string, f *Feed) error {
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	enc := xml.NewEncoder(&buf)
+	enc.Indent("", "  ")
+	if err := enc.Encode(f); err != nil {
+		return err
+	}
+	buf.WriteString("\n")
+
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, buf.Bytes(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// expire removes all entries updated before the given time and sorts the remaining ones, newest first
+func (f *Feed) expire(before time.Time) {
+	entries := f.Entries[:0]
+	for _, e := range f.Entries {
+		t, err := time.Parse(time.RFC3339, e.Updated)
+		if err != nil || t.Before(before) {
+			continue
+		}
+		entries = append(entries, e)
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Updated > entries[j].Updated })
+	f.Entries = entries
+}
+
+// entry converts a change to a feed entry, only new issues, new comments and state changes are used
+func entry(dir, repo string, c archive.Change, now time.Time) (Entry, bool) {
+	t := c.Time
+	if t.IsZero() {
+		t = now
+	}
+	e := Entry{
+		Updated: t.UTC().Format(time.RFC3339),
+		Author:  Person{Name: c.Author},
+		Link:    Link{Href: href(dir, c.Path)},
+	}
+	id := fmt.Sprintf("urn:issues-to-go:%s:issue:%d", repo, c.Number)
+
+	var body string
+	switch c.Kind {
+	case archive.NewIssue:
+		e.ID = id
+		e.Title = fmt.Sprintf("New issue #%d: %s", c.Number, c.Title)
+		body = c.Body
+	case archive.NewComment:
+		if c.CommentID > 0 {
+			e.ID = fmt.Sprintf("%s:comment:%d", id, c.CommentID)
+			e.Link.Href += fmt.Sprintf("#issuecomment-%d", c.CommentID)
+		} else {
+			e.ID = fmt.Sprintf("%s:comment:%s:%d", id, c.Author, c.Time.Unix())
+		}
+		e.Title = fmt.Sprintf("%s commented on #%d: %s", c.Author, c.Number, c.Title)
+		body = c.Body
+	case archive.StateChange:
+		action := "reopened"
+		if c.To == "closed" {
+			action = "closed"
+		}
+		e.ID = fmt.Sprintf("%s:%s:%d", id, action, t.Unix())
+		e.Title = fmt.Sprintf("#%d %s: %s", c.Number, action, c.Title)
+		e.Author = Person{}
+		body = fmt.Sprintf("The issue was %s.", action)
+	default:
+		return e, false
+	}
+	if e.Author.Name == "" {
+		e.Author.Name = repo
+	}
+
+	html, err := render.HTML([]byte(body), linkFunc(e.Link.Href))
+	if err != nil {
+		html = []byte(xmlEscape(body))
+	}
+	e.Content = Content{Type: "html", Body: string(html)}
+	return e, true
+}
+
+// href returns the path of an issue file relative to the feed
+func href(dir, file string) string {
+	rel, err := filepath.Rel(dir, file)
+	if err != nil {
+		return filepath.ToSlash(file)
+	}
+	return filepath.ToSlash(rel)
+}
+
+// linkFunc rewrites relative links in the content, which are relative to the issue file, to be relative to the feed
+func linkFunc(issueHref string) render.LinkFunc {
+	base := path.Dir(strings.SplitN(issueHref, "#", 2)[0])
+	return func(dest string, image bool) string {
+		if u, err := url.Parse(dest); err != nil || u.Scheme != "" || u.Host != "" || strings.HasPrefix(dest, "#") || strings.HasPrefix(dest, "/") {
+			return dest
+		}
+		return path.Join(base, dest)
+	}
+}
+
+func xmlEscape(s string) string {
+	var buf bytes.Buffer
+	_ = xml.EscapeText(&buf, []byte(s))
+	return buf.String()
+}
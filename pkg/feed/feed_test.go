@@ -0,0 +1,67 @@
+package feed
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/S7evinK/issues-to-go/pkg/archive"
+)
+
+func TestUpdate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "issues-to-go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "feed.atom")
+
+	now := time.Date(2019, time.December, 1, 12, 0, 0, 0, time.UTC)
+	issue := filepath.Join(dir, "open", "1.md")
+	changes := []archive.Change{
+		{Kind: archive.NewIssue, Number: 1, Title: "Test", Path: issue, Time: now.Add(-40 * 24 * time.Hour), Author: "S7evinK", Body: "Hello"},
+		{Kind: archive.NewComment, Number: 1, Title: "Test", Path: issue, Time: now.Add(-time.Hour), Author: "alice", Body: "See [#2](../closed/2.md)", CommentID: 11},
+		{Kind: archive.TitleChange, Number: 1, Title: "Test", Path: issue, Time: now, From: "Old", To: "Test"},
+		{Kind: archive.StateChange, Number: 1, Title: "Test", Path: issue, Time: now, From: "open", To: "closed"},
+	}
+
+	tests := []struct {
+		name    string
+		changes []archive.Change
+		want    int
+	}{
+		{name: "new changes", changes: changes, want: 2},
+		{name: "same changes", changes: changes, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			count, err := Update(path, "S7evinK/issues-to-go", tt.changes, 30*24*time.Hour, now)
+			if err != nil {
+				t.Fatalf("Update() error = %v", err)
+			}
+			if count != tt.want {
+				t.Errorf("Update() = %d, want %d", count, tt.want)
+			}
+		})
+	}
+
+	f, err := read(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(f.Entries) != 2 {
+		t.Fatalf("got %d entries, want 2 (the new issue is outside of the window)", len(f.Entries))
+	}
+	if got := f.Entries[0].Title; got != "#1 closed: Test" {
+		t.Errorf("first entry = %q, want the state change", got)
+	}
+	if got := f.Entries[1].Link.Href; got != "open/1.md#issuecomment-11" {
+		t.Errorf("comment link = %q", got)
+	}
+	if got := f.Entries[1].Content.Body; !strings.Contains(got, `href="closed/2.md"`) {
+		t.Errorf("comment content = %q, want links relative to the feed", got)
+	}
+}
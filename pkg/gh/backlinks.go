@@ -0,0 +1,177 @@
+package gh
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/S7evinK/issues-to-go/pkg/archive"
+)
+
+const referencesFile = "references.json"
+
+type (
+	// referenceIndex maps issue numbers to the issues they reference
+	referenceIndex map[int]*referenceEntry
+
+	// referenceEntry contains the issues referenced by an issue
+	referenceEntry struct {
+		Title      string `json:"title"`
+		References []int  `json:"references"`
+	}
+)
+
+// readReferenceIndex reads the reference index of an archive. A missing index results in an empty index.
+func readReferenceIndex(outputPath string) (referenceIndex, error) {
+	index := make(referenceIndex)
+	b, err := ioutil.ReadFile(filepath.Join(outputPath, archive.MetaDir, referencesFile))
+	if os.IsNotExist(err) {
+		return index, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, &index); err != nil {
+		return nil, err
+	}
+	return index, nil
+}
+
+// write stores the reference index in the archive
+func (ri referenceIndex) write(outputPath string) error {
+	b, err := json.MarshalIndent(ri, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Join(outputPath, archive.MetaDir), os.ModePerm); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(outputPath, archive.MetaDir, referencesFile), b, os.ModePerm)
+}
+
+// set replaces the references of an issue
+func (ri referenceIndex) set(number int, title string, references []int) {
+	ri[number] = &referenceEntry{Title: title, References: references}
+}
+
+// backlinks returns the reverse index, mapping issue numbers to the issues referencing them
+func (ri referenceIndex) backlinks() map[int][]int {
+	backlinks := make(map[int][]int)
+	for number, entry := range ri {
+		for _, ref := range entry.References {
+			if ref == number {
+				continue
+			}
+			backlinks[ref] = append(backlinks[ref], number)
+		}
+	}
+	for _, numbers := range backlinks {
+		sort.Ints(numbers)
+	}
+	return backlinks
+}
+
+// referencedIssues returns the distinct numbers of all references to the downloaded repository
+func (gh *GH) referencedIssues(refs []reference) []int {
+	var (
+		numbers []int
+		seen    = make(map[int]bool)
+	)
+	for _, ref := range refs {
+		if ref.pull || !gh.sameRepo(ref) || seen[ref.number] {
+			continue
+		}
+		seen[ref.number] = true
+		numbers = append(numbers, ref.number)
+	}
+	sort.Ints(numbers)
+	return numbers
+}
+
+// backlinksSection creates the "Referenced by" section for the file at from
+func (gh *GH) backlinksSection(from string, numbers []int) string {
+	if len(numbers) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString("\n\n" + archive.BacklinksStart + "\nReferenced by\n---\n\n")
+	for _, number := range numbers {
+		ref := reference{owner: gh.opts.User, repo: gh.opts.Repo, number: number}
+		sb.WriteString(fmt.Sprintf("- [#%d](%s)", number, gh.linkTarget(from, ref)))
+		if entry, ok := gh.references[number]; ok && entry.Title != "" {
+			sb.WriteString(" " + entry.Title)
+		}
+		sb.WriteString("\n")
+	}
+	sb.WriteString(archive.BacklinksEnd + "\n")
+	return sb.String()
+}
+
+// setBacklinks replaces the "Referenced by" section of content with section
+func setBacklinks(content []byte, section string) []byte {
+	return append(archive.StripBacklinks(content), section...)
+}
+
+// updateLinks updates the links and the "Referenced by" section of the issues affected by the download,
+// since referenced issues might have been moved between the open and closed folder,
+// might have been downloaded for the first time or might reference other issues now.
+// Links to other archives are only updated when the linking issue is downloaded again.
+func (gh *GH) updateLinks() error {
+	backlinks := gh.references.backlinks()
+	for number := range gh.affectedIssues(backlinks) {
+		path, ok := gh.index[number]
+		if !ok {
+			continue
+		}
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		updated := gh.relinkFile(content, path)
+		updated = setBacklinks(updated, gh.backlinksSection(path, backlinks[number]))
+		if string(updated) == string(content) {
+			continue
+		}
+		if err := ioutil.WriteFile(path, updated, os.ModePerm); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// affectedIssues returns the issues, whose links or "Referenced by" section might have been changed by the
+// download: the downloaded issues, the issues they reference, the issues linking to downloaded issues which
+// were moved or are new and the issues with other backlinks than before
+func (gh *GH) affectedIssues(backlinks map[int][]int) map[int]bool {
+	affected := make(map[int]bool)
+	for number := range gh.written {
+		affected[number] = true
+		// the title or the path in their "Referenced by" section might have changed
+		if entry, ok := gh.references[number]; ok {
+			for _, ref := range entry.References {
+				affected[ref] = true
+			}
+		}
+		if gh.previousPaths[number] != gh.index[number] {
+			for _, n := range backlinks[number] {
+				affected[n] = true
+			}
+		}
+	}
+	for number, numbers := range backlinks {
+		if !reflect.DeepEqual(numbers, gh.previousBacklinks[number]) {
+			affected[number] = true
+		}
+	}
+	for number := range gh.previousBacklinks {
+		if _, ok := backlinks[number]; !ok {
+			affected[number] = true
+		}
+	}
+	return affected
+}
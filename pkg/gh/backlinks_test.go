@@ -0,0 +1,118 @@
+package gh
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestBacklinks(t *testing.T) {
+	gh := &GH{
+		opts: Options{User: "S7evinK", Repo: "issues-to-go", OutputPath: "issues"},
+		index: issueIndex{
+			1: filepath.Join("issues", "open", "1.md"),
+			2: filepath.Join("issues", "closed", "2.md"),
+			3: filepath.Join("issues", "open", "3.md"),
+		},
+		references: referenceIndex{
+			1: {Title: "First", References: []int{1, 3}},
+			2: {Title: "Second", References: []int{3}},
+		},
+	}
+
+	backlinks := gh.references.backlinks()
+	if want := map[int][]int{3: {1, 2}}; !reflect.DeepEqual(backlinks, want) {
+		t.Fatalf("backlinks() = %v, want %v", backlinks, want)
+	}
+
+	content := "Third\n---\n\nCreated by S7evinK on 2019-11-15 13:05:33 +0100 CET:\n\nHello World!\n\n---\n"
+	section := gh.backlinksSection(gh.index[3], backlinks[3])
+	want := content + "\n\n<!-- referenced-by -->\nReferenced by\n---\n\n" +
+		"- [#1](1.md) First\n" +
+		"- [#2](../closed/2.md) Second\n" +
+		"<!-- /referenced-by -->\n"
+
+	got := string(setBacklinks([]byte(content), section))
+	if got != want {
+		t.Errorf("setBacklinks() = %q, want %q", got, want)
+	}
+
+	// updating the section must not duplicate it
+	if got := string(setBacklinks([]byte(want), section)); got != want {
+		t.Errorf("setBacklinks() = %q, want %q", got, want)
+	}
+
+	// removing all backlinks restores the original content
+	if got := string(setBacklinks([]byte(want), "")); got != content {
+		t.Errorf("setBacklinks() = %q, want %q", got, content)
+	}
+}
+
+func TestUpdateLinks(t *testing.T) {
+	dir, err := ioutil.TempDir("", "issues-to-go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// issue 2 was downloaded and moved to the closed folder, issue 1 references it
+	files := map[string]string{
+		"open/1.md":   "First\n---\n\nSee [#2](2.md)\n",
+		"closed/2.md": "Second\n---\n\nClosed\n",
+		"open/3.md":   "Third\n---\n\nSee [#4](4.md)\n",
+		"closed/4.md": "Fourth\n---\n\nClosed\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+	}
+	gh := &GH{
+		opts: Options{User: "S7evinK", Repo: "issues-to-go", OutputPath: dir},
+		index: issueIndex{
+			1: filepath.Join(dir, "open", "1.md"),
+			2: filepath.Join(dir, "closed", "2.md"),
+			3: filepath.Join(dir, "open", "3.md"),
+			4: filepath.Join(dir, "closed", "4.md"),
+		},
+		references: referenceIndex{
+			1: {Title: "First", References: []int{2}},
+			3: {Title: "Third", References: []int{4}},
+		},
+		previousPaths: issueIndex{
+			1: filepath.Join(dir, "open", "1.md"),
+			2: filepath.Join(dir, "open", "2.md"),
+			3: filepath.Join(dir, "open", "3.md"),
+			4: filepath.Join(dir, "closed", "4.md"),
+		},
+		previousBacklinks: map[int][]int{2: {1}, 4: {3}},
+		written:           map[int]bool{2: true},
+	}
+	if err := gh.updateLinks(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{
+		"open/1.md": "First\n---\n\nSee [#2](../closed/2.md)\n",
+		"closed/2.md": "Second\n---\n\nClosed\n\n\n<!-- referenced-by -->\nReferenced by\n---\n\n" +
+			"- [#1](../open/1.md) First\n<!-- /referenced-by -->\n",
+		// the files of issues 3 and 4 aren't affected by the download and left untouched
+		"open/3.md":   files["open/3.md"],
+		"closed/4.md": files["closed/4.md"],
+	}
+	for name, content := range want {
+		b, err := ioutil.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != content {
+			t.Errorf("got %s\n%q\nwant\n%q", name, b, content)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package gh
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+	github "github.com/shurcooL/githubv4"
+)
+
+type (
+	// Thread is the current state of the comments of an issue
+	Thread struct {
+		// ID is the node ID of the issue
+		ID string
+		// Comments is the total number of comments
+		Comments int
+		// Latest contains the most recent comments, oldest first
+		Latest []Comment
+	}
+
+	// QueryThread is the query executed to check an issue for new comments before commenting
+	QueryThread struct {
+		Repository struct {
+			Issue struct {
+				ID       string `graphql:"id"`
+				Comments struct {
+					TotalCount int
+					Nodes      []Comment
+				} `graphql:"comments(last: $count)"`
+			} `graphql:"issue(number: $issueNumber)"`
+		} `graphql:"repository(owner: $owner, name: $name)"`
+	}
+
+	// PostedComment is a comment added by AddComment
+	PostedComment struct {
+		// ID is the node ID of the comment
+		ID  string `graphql:"id"`
+		URL string `graphql:"url"`
+	}
+
+	// MutationAddComment is the mutation executed to add a comment to an issue
+	MutationAddComment struct {
+		AddComment struct {
+			CommentEdge struct {
+				Node PostedComment
+			}
+		} `graphql:"addComment(input: $input)"`
+	}
+
+	// MutationUpdateIssueComment is the mutation executed to edit a comment
+	MutationUpdateIssueComment struct {
+		UpdateIssueComment struct {
+			ClientMutationID string `graphql:"clientMutationId"`
+		} `graphql:"updateIssueComment(input: $input)"`
+	}
+)
+
+// Thread returns the total number of comments of an issue and the latest comments, up to count
+func (gh *GH) Thread(ctx context.Context, number, count int) (*Thread, error) {
+	var q QueryThread
+	variables := map[string]interface{}{
+		"issueNumber": github.Int(number),
+		"count":       github.Int(count),
+		"owner":       github.String(gh.opts.User),
+		"name":        github.String(gh.opts.Repo),
+	}
+	if err := gh.client.Query(ctx, &q, variables); err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("unable to get comments of issue %d", number))
+	}
+	issue := q.Repository.Issue
+	return &Thread{ID: issue.ID, Comments: issue.Comments.TotalCount, Latest: issue.Comments.Nodes}, nil
+}
+
+// AddComment posts a comment to the issue with the node ID
+func (gh *GH) AddComment(ctx context.Context, issueID, body string) (*PostedComment, error) {
+	var m MutationAddComment
+	input := github.AddCommentInput{SubjectID: github.ID(issueID), Body: github.String(body)}
+	if err := gh.client.Mutate(ctx, &m, input, nil); err != nil {
+		return nil, err
+	}
+	return &m.AddComment.CommentEdge.Node, nil
+}
+
+// EditComment replaces the body of the comment with the node ID
+func (gh *GH) EditComment(ctx context.Context, id, body string) error {
+	var m MutationUpdateIssueComment
+	return gh.client.Mutate(ctx, &m, github.UpdateIssueCommentInput{ID: github.ID(id), Body: github.String(body)}, nil)
+}
@@ -0,0 +1,222 @@
+package gh
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	github "github.com/shurcooL/githubv4"
+)
+
+type (
+	// NewIssue is an issue to create, labels, assignees and the milestone are given by name
+	NewIssue struct {
+		Title     string
+		Body      string
+		Labels    []string
+		Assignees []string
+		Milestone string
+	}
+
+	// CreatedIssue is an issue created by CreateIssue
+	CreatedIssue struct {
+		// ID is the node ID of the issue
+		ID        string
+		Number    int
+		URL       string
+		Author    string
+		CreatedAt time.Time
+	}
+
+	// LabelNode is used in gql queries
+	LabelNode struct {
+		ID   string `graphql:"id"`
+		Name string `graphql:"name"`
+	}
+
+	// MilestoneNode is used in gql queries
+	MilestoneNode struct {
+		ID    string `graphql:"id"`
+		Title string `graphql:"title"`
+	}
+
+	// QueryRepository is the query executed to resolve the names of labels and milestones
+	QueryRepository struct {
+		Repository struct {
+			ID     string `graphql:"id"`
+			Labels struct {
+				Nodes    []LabelNode
+				PageInfo PageInfo `graphql:"pageInfo"`
+			} `graphql:"labels(first: 100, after: $labelsCursor)"`
+			Milestones struct {
+				Nodes    []MilestoneNode
+				PageInfo PageInfo `graphql:"pageInfo"`
+			} `graphql:"milestones(first: 100, after: $milestonesCursor)"`
+		} `graphql:"repository(owner: $owner, name: $name)"`
+	}
+
+	// QueryUser is the query executed to resolve the login of an assignee
+	QueryUser struct {
+		User struct {
+			ID string `graphql:"id"`
+		} `graphql:"user(login: $login)"`
+	}
+
+	// MutationCreateIssue is the mutation executed to create an issue
+	MutationCreateIssue struct {
+		CreateIssue struct {
+			Issue struct {
+				ID        string    `graphql:"id"`
+				Number    int       `graphql:"number"`
+				URL       string    `graphql:"url"`
+				Author    Author    `graphql:"author"`
+				CreatedAt time.Time `graphql:"createdAt"`
+			}
+		} `graphql:"createIssue(input: $input)"`
+	}
+)
+
+// CreateIssue creates an issue. The names of labels, assignees and the milestone are resolved to IDs first,
+// the issue isn't created if one of them doesn't exist.
+func (gh *GH) CreateIssue(ctx context.Context, issue NewIssue) (*CreatedIssue, error) {
+	input, err := gh.ResolveIssue(ctx, issue)
+	if err != nil {
+		return nil, err
+	}
+	var m MutationCreateIssue
+	if err := gh.client.Mutate(ctx, &m, *input, nil); err != nil {
+		return nil, err
+	}
+	created := m.CreateIssue.Issue
+	return &CreatedIssue{ID: created.ID, Number: created.Number, URL: created.URL, Author: created.Author.Name, CreatedAt: created.CreatedAt}, nil
+}
+
+// ResolveIssue returns the input to create the issue, with the names of labels, assignees and the milestone resolved to IDs
+func (gh *GH) ResolveIssue(ctx context.Context, issue NewIssue) (*github.CreateIssueInput, error) {
+	repo, err := gh.repositoryIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	input := &github.CreateIssueInput{
+		RepositoryID: github.ID(repo.Repository.ID),
+		Title:        github.String(issue.Title),
+		Body:         github.NewString(github.String(issue.Body)),
+	}
+	if len(issue.Labels) > 0 {
+		ids, err := gh.labelIDs(ctx, issue.Labels)
+		if err != nil {
+			return nil, err
+		}
+		input.LabelIDs = &ids
+	}
+	if issue.Milestone != "" {
+		id, ok := findMilestone(repo.Repository.Milestones.Nodes, issue.Milestone)
+		if !ok {
+			return nil, fmt.Errorf("milestone %q doesn't exist", issue.Milestone)
+		}
+		input.MilestoneID = &id
+	}
+	if len(issue.Assignees) > 0 {
+		var ids []github.ID
+		for _, login := range issue.Assignees {
+			var q QueryUser
+			if err := gh.client.Query(ctx, &q, map[string]interface{}{"login": github.String(login)}); err != nil {
+				return nil, errors.Wrap(err, fmt.Sprintf("unable to get user %q", login))
+			}
+			ids = append(ids, github.ID(q.User.ID))
+		}
+		input.AssigneeIDs = &ids
+	}
+	return input, nil
+}
+
+// RepositoryNames returns the names of the labels and the titles of the milestones of the repository
+func (gh *GH) RepositoryNames(ctx context.Context) ([]string, []string, error) {
+	repo, err := gh.repositoryIDs(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	var labels, milestones []string
+	for _, l := range repo.Repository.Labels.Nodes {
+		labels = append(labels, l.Name)
+	}
+	for _, m := range repo.Repository.Milestones.Nodes {
+		milestones = append(milestones, m.Title)
+	}
+	return labels, milestones, nil
+}
+
+// repositoryIDs returns the IDs of the repository, all its labels and milestones. They are queried only once.
+func (gh *GH) repositoryIDs(ctx context.Context) (*QueryRepository, error) {
+	if gh.repository != nil {
+		return gh.repository, nil
+	}
+	var repo QueryRepository
+	variables := map[string]interface{}{
+		"owner":            github.String(gh.opts.User),
+		"name":             github.String(gh.opts.Repo),
+		"labelsCursor":     (*github.String)(nil),
+		"milestonesCursor": (*github.String)(nil),
+	}
+	// labels and milestones are paged together, a connection is skipped once its last page was read
+	labelsDone, milestonesDone := false, false
+	for !labelsDone || !milestonesDone {
+		var q QueryRepository
+		if err := gh.client.Query(ctx, &q, variables); err != nil {
+			return nil, errors.Wrap(err, "unable to get labels and milestones")
+		}
+		repo.Repository.ID = q.Repository.ID
+		if labels := q.Repository.Labels; !labelsDone {
+			repo.Repository.Labels.Nodes = append(repo.Repository.Labels.Nodes, labels.Nodes...)
+			labelsDone = !labels.PageInfo.HasNextPage
+			variables["labelsCursor"] = github.NewString(labels.PageInfo.EndCursor)
+		}
+		if milestones := q.Repository.Milestones; !milestonesDone {
+			repo.Repository.Milestones.Nodes = append(repo.Repository.Milestones.Nodes, milestones.Nodes...)
+			milestonesDone = !milestones.PageInfo.HasNextPage
+			variables["milestonesCursor"] = github.NewString(milestones.PageInfo.EndCursor)
+		}
+	}
+	gh.repository = &repo
+	return gh.repository, nil
+}
+
+// labelIDs resolves the names of labels to IDs
+func (gh *GH) labelIDs(ctx context.Context, labels []string) ([]github.ID, error) {
+	repo, err := gh.repositoryIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var ids []github.ID
+	for _, label := range labels {
+		id, ok := findLabel(repo.Repository.Labels.Nodes, label)
+		if !ok {
+			return nil, fmt.Errorf("label %q doesn't exist", label)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// findLabel returns the ID of the label with the name, ignoring case like Github does
+func findLabel(labels []LabelNode, name string) (github.ID, bool) {
+	for _, l := range labels {
+		if strings.EqualFold(l.Name, name) {
+			return github.ID(l.ID), true
+		}
+	}
+	return nil, false
+}
+
+// findMilestone returns the ID of the milestone with the title
+func findMilestone(milestones []MilestoneNode, title string) (github.ID, bool) {
+	for _, m := range milestones {
+		if m.Title == title {
+			return github.ID(m.ID), true
+		}
+	}
+	return nil, false
+}
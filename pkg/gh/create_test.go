@@ -0,0 +1,78 @@
+package gh
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	github "github.com/shurcooL/githubv4"
+)
+
+func TestFindLabelAndMilestone(t *testing.T) {
+	labels := []LabelNode{{ID: "L1", Name: "bug"}, {ID: "L2", Name: "Good first issue"}}
+	milestones := []MilestoneNode{{ID: "M1", Title: "v1.0"}}
+
+	tests := []struct {
+		name   string
+		find   func() (github.ID, bool)
+		want   github.ID
+		wantOK bool
+	}{
+		{name: "label", find: func() (github.ID, bool) { return findLabel(labels, "bug") }, want: github.ID("L1"), wantOK: true},
+		{name: "label ignoring case", find: func() (github.ID, bool) { return findLabel(labels, "good first issue") }, want: github.ID("L2"), wantOK: true},
+		{name: "missing label", find: func() (github.ID, bool) { return findLabel(labels, "feature") }},
+		{name: "milestone", find: func() (github.ID, bool) { return findMilestone(milestones, "v1.0") }, want: github.ID("M1"), wantOK: true},
+		{name: "missing milestone", find: func() (github.ID, bool) { return findMilestone(milestones, "v2.0") }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := tt.find()
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("got %v, %v, want %v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestRepositoryIDsPaginated(t *testing.T) {
+	// the labels have two pages, the milestones one
+	pages := []string{
+		`{"data":{"repository":{"id":"R1",` +
+			`"labels":{"nodes":[{"id":"L1","name":"bug"}],"pageInfo":{"endCursor":"c1","hasNextPage":true}},` +
+			`"milestones":{"nodes":[{"id":"M1","title":"v1.0"}],"pageInfo":{"endCursor":"m1","hasNextPage":false}}}}}`,
+		`{"data":{"repository":{"id":"R1",` +
+			`"labels":{"nodes":[{"id":"L2","name":"feature"}],"pageInfo":{"endCursor":"c2","hasNextPage":false}},` +
+			`"milestones":{"nodes":[],"pageInfo":{"endCursor":null,"hasNextPage":false}}}}}`,
+	}
+	var cursors []interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Variables map[string]interface{}
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Error(err)
+		}
+		cursors = append(cursors, req.Variables["labelsCursor"])
+		_, _ = io.WriteString(w, pages[len(cursors)-1])
+	}))
+	defer srv.Close()
+
+	gh := &GH{client: github.NewEnterpriseClient(srv.URL, srv.Client()), opts: Options{User: "S7evinK", Repo: "issues-to-go"}}
+	labels, milestones, err := gh.RepositoryNames(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"bug", "feature"}; !reflect.DeepEqual(labels, want) {
+		t.Errorf("got labels %v, want %v", labels, want)
+	}
+	if want := []string{"v1.0"}; !reflect.DeepEqual(milestones, want) {
+		t.Errorf("got milestones %v, want %v", milestones, want)
+	}
+	if want := []interface{}{nil, "c1"}; !reflect.DeepEqual(cursors, want) {
+		t.Errorf("queried with label cursors %v, want %v", cursors, want)
+	}
+}
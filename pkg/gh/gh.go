@@ -12,6 +12,8 @@ import (
 	"strings"
 	"time"
 
+	"github.com/S7evinK/issues-to-go/pkg/archive"
+	"github.com/S7evinK/issues-to-go/pkg/draft"
 	"github.com/pkg/errors"
 	github "github.com/shurcooL/githubv4"
 	"golang.org/x/oauth2"
@@ -22,9 +24,17 @@ type (
 	GH struct {
 		client         *github.Client
 		opts           Options
-		variables      map[string]interface{}
-		states         []github.IssueState
 		regexMilestone *regexp.Regexp
+		index          issueIndex
+		milestones     map[int]string
+		references     referenceIndex
+		changes        []archive.Change
+		repository     *QueryRepository
+		// previousPaths and previousBacklinks are the index and the backlinks before the download,
+		// written contains the downloaded issues. They select the files updated by updateLinks.
+		previousPaths     issueIndex
+		previousBacklinks map[int][]int
+		written           map[int]bool
 	}
 
 	// IssueConnection is used in gql queries
@@ -58,6 +68,10 @@ type (
 		State     string    `graphql:"state"`
 		Closed    bool      `graphql:"closed"`
 		ClosedAt  time.Time `graphql:"closedAt"`
+		UpdatedAt time.Time `graphql:"updatedAt"`
+		URL       string    `graphql:"url"`
+		Labels    Labels    `graphql:"labels(first: 100)"`
+		Assignees Assignees `graphql:"assignees(first: 100)"`
 	}
 
 	// Author is used in gql queries
@@ -65,6 +79,21 @@ type (
 		Name string `graphql:"login"`
 	}
 
+	// Labels is used in gql queries
+	Labels struct {
+		Nodes []Label
+	}
+
+	// Label is used in gql queries
+	Label struct {
+		Name string `graphql:"name"`
+	}
+
+	// Assignees is used in gql queries
+	Assignees struct {
+		Nodes []Author
+	}
+
 	// Milestone is used in gql queries
 	Milestone struct {
 		Title string `graphql:"title"`
@@ -78,8 +107,9 @@ type (
 
 	// Comment is used in gql queries
 	Comment struct {
-		Body   string
-		Author struct {
+		DatabaseID int `graphql:"databaseId"`
+		Body       string
+		Author     struct {
 			Login string
 		}
 		CreatedAt time.Time `graphql:"createdAt"`
@@ -104,15 +134,19 @@ type (
 
 	// Options defines all available options for the application
 	Options struct {
-		Token      string
-		User       string
-		Repo       string
-		OutputPath string
-		Count      int
-		AllIssues  bool
-		Since      time.Time
-		Milestones bool
-		TZ         *time.Location
+		Token       string
+		User        string
+		Repo        string
+		OutputPath  string
+		Count       int
+		AllIssues   bool
+		Since       time.Time
+		Milestones  bool
+		FrontMatter bool
+		Root        string
+		TZ          *time.Location
+		// Source provides the issues, Github if it isn't set
+		Source Source
 	}
 )
 
@@ -129,15 +163,16 @@ const (
 	ErrNoRepository = Error("could not determine repository. Make sure it is in the format USER/REPOSITORY")
 )
 
-// Repo extracts the user and repo from a full repo name (eg. S7evinK/issues-to-go)
+// Repo extracts the user and repo from a full repo name (eg. S7evinK/issues-to-go).
+// The user of projects in GitLab subgroups contains the groups (eg. group/subgroup).
 func Repo(r string) Option {
 	return func(o *Options) error {
-		s := strings.Split(r, "/")
-		if len(s) != 2 {
+		i := strings.LastIndex(r, "/")
+		if i <= 0 || i == len(r)-1 || strings.HasPrefix(r, "/") {
 			return ErrNoRepository
 		}
-		o.User = s[0]
-		o.Repo = s[1]
+		o.User = r[:i]
+		o.Repo = r[i+1:]
 		return nil
 	}
 }
@@ -210,6 +245,31 @@ func Milestones(b bool) Option {
 	}
 }
 
+// FrontMatter sets the option to write YAML front matter to issue files and returns an option
+func FrontMatter(b bool) Option {
+	return func(o *Options) error {
+		o.FrontMatter = b
+		return nil
+	}
+}
+
+// Root sets the folder containing archives of other repositories and returns an option.
+// The archives are expected in the folder OWNER/REPOSITORY below the root.
+func Root(r string) Option {
+	return func(o *Options) error {
+		o.Root = r
+		return nil
+	}
+}
+
+// From sets the source of the issues and returns an option
+func From(s Source) Option {
+	return func(o *Options) error {
+		o.Source = s
+		return nil
+	}
+}
+
 // New creates a new github v4 client and prepares the folders and queries
 func New(opts ...Option) (*GH, error) {
 	o := Options{}
@@ -227,19 +287,13 @@ func New(opts ...Option) (*GH, error) {
 	httpClient.Timeout = 30 * time.Second
 
 	client := github.NewClient(httpClient)
-
-	variables := map[string]interface{}{
-		"owner":          github.String(o.User),
-		"name":           github.String(o.Repo),
-		"issueCursor":    (*github.String)(nil),
-		"commentsCursor": (*github.String)(nil),
-		"count":          github.Int(o.Count),
+	if o.Source == nil {
+		o.Source = &githubSource{client: client, owner: o.User, repo: o.Repo, count: o.Count}
 	}
 
 	gh := &GH{
 		client:         client,
 		opts:           o,
-		variables:      variables,
 		regexMilestone: regexp.MustCompile(`\/`),
 	}
 
@@ -252,91 +306,248 @@ func New(opts ...Option) (*GH, error) {
 
 // FetchIssues gets all requested issues from a given repository.
 func (gh *GH) FetchIssues() error {
+	return gh.FetchIssuesContext(context.Background())
+}
+
+// FetchIssuesContext is like FetchIssues, but stops after the current issue when ctx is cancelled.
+// The reference index and the links are still updated for the downloaded issues, ctx.Err() is returned afterwards.
+func (gh *GH) FetchIssuesContext(ctx context.Context) error {
 	var (
-		count = 0
-		since = gh.opts.Since
-		tz    = gh.opts.TZ
-		q     Query
+		count  = 0
+		since  = gh.opts.Since
+		tz     = gh.opts.TZ
+		states = []string{"open"}
+		cursor string
 	)
-	gh.states = []github.IssueState{github.IssueStateOpen}
 
 	if gh.opts.AllIssues {
-		gh.states = append(gh.states, github.IssueStateClosed)
+		states = append(states, "closed")
 	}
 
-	gh.variables["filterBy"] = github.IssueFilters{Since: &github.DateTime{since.UTC()}, States: &gh.states}
-
-	existing, err := readExistingIssues(gh.opts.OutputPath)
-	if err != nil && err != os.ErrNotExist {
-		return errors.Wrap(err, "unable to read existing issues")
+	existing, err := gh.readArchive()
+	if err != nil {
+		return err
 	}
 
-	var downloadedIssues []string
-	for {
-		err := gh.client.Query(context.Background(), &q, gh.variables)
+	for ctx.Err() == nil {
+		issues, next, err := gh.opts.Source.Issues(ctx, since, states, cursor)
+		if ctx.Err() != nil {
+			break
+		}
 		if err != nil {
 			return err
 		}
 
-		if len(q.Repository.IssueConnection.Edges) == 0 {
+		if len(issues) == 0 {
 			return ErrNoIssues
 		}
 
-		downloadedIssues, count, err = gh.extractIssues(q, tz, existing, downloadedIssues, count)
+		count, err = gh.extractIssues(ctx, issues, tz, existing, count)
 		if err != nil {
 			return err
 		}
 
 		// break endless loop if we're on the last page
-		if !q.Repository.IssueConnection.PageInfo.HasNextPage {
+		if next == "" {
 			break
 		}
 
-		gh.variables["issueCursor"] = q.Repository.IssueConnection.PageInfo.EndCursor
+		cursor = next
+	}
+
+	if err := gh.writeArchive(); err != nil {
+		return err
+	}
+
+	log.Printf("Downloaded %d issue(s) including comments\n", count)
+
+	return ctx.Err()
+}
+
+// SyncIssues downloads the given issues regardless of their state and time of the last update
+func (gh *GH) SyncIssues(ctx context.Context, numbers ...int) error {
+	existing, err := gh.readArchive()
+	if err != nil {
+		return err
 	}
 
-	log.Printf("Downloaded %d issue(s) including comments:", count)
+	count := 0
+	for _, number := range numbers {
+		issue, err := gh.opts.Source.Issue(ctx, number)
+		if err != nil {
+			return err
+		}
+		if count, err = gh.extractIssues(ctx, []Issue{*issue}, gh.opts.TZ, existing, count); err != nil {
+			return err
+		}
+	}
 
-	for _, fp := range downloadedIssues {
-		fmt.Println(fp)
+	if err := gh.writeArchive(); err != nil {
+		return err
 	}
 
+	log.Printf("Downloaded %d issue(s) including comments\n", count)
+
 	return nil
 }
 
-func (gh *GH) extractIssues(q Query, tz *time.Location, existing map[string][]string, downloadedIssues []string, count int) ([]string, int, error) {
-	for _, issue := range q.Repository.IssueConnection.Edges {
-		comments, err := gh.extractComments(&issue, tz)
-		if err != nil {
-			return nil, 0, errors.Wrap(err, "unable to extract comments")
+// readArchive reads the existing issues and the reference index of the archive and
+// returns the paths of the existing issues
+func (gh *GH) readArchive() (map[int][]string, error) {
+	existing, err := readExistingIssues(gh.opts.OutputPath)
+	if err != nil && err != os.ErrNotExist {
+		return nil, errors.Wrap(err, "unable to read existing issues")
+	}
+	gh.index = newIssueIndex(gh.opts.OutputPath, existing)
+
+	gh.milestones, err = archive.Milestones(gh.opts.OutputPath)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to read milestones")
+	}
+
+	gh.references, err = readReferenceIndex(gh.opts.OutputPath)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to read reference index")
+	}
+
+	gh.previousPaths = make(issueIndex, len(gh.index))
+	for number, path := range gh.index {
+		gh.previousPaths[number] = path
+	}
+	gh.previousBacklinks = gh.references.backlinks()
+	gh.written = make(map[int]bool)
+	return existing, nil
+}
+
+// writeArchive writes the reference index and updates the links between the downloaded issues
+func (gh *GH) writeArchive() error {
+	if err := gh.references.write(gh.opts.OutputPath); err != nil {
+		return errors.Wrap(err, "unable to write reference index")
+	}
+
+	if err := gh.updateLinks(); err != nil {
+		return errors.Wrap(err, "unable to update links between issues")
+	}
+	return nil
+}
+
+func (gh *GH) extractIssues(ctx context.Context, issues []Issue, tz *time.Location, existing map[int][]string, count int) (int, error) {
+	for n := range issues {
+		if ctx.Err() != nil {
+			break
 		}
-		if issue.Node.Closed {
-			footer := []byte(fmt.Sprintf("Closed on %v", issue.Node.ClosedAt.In(tz)))
+		issue := &issues[n]
+		outputFile := filepath.Join(gh.opts.OutputPath, strings.ToLower(issue.State), strconv.Itoa(issue.Number)+".md")
+		previous := gh.readPrevious(issue.Number)
+		gh.index[issue.Number] = outputFile
+		gh.written[issue.Number] = true
+
+		comments := gh.extractComments(issue, tz, outputFile)
+		if issue.Closed {
+			footer := []byte(fmt.Sprintf("Closed on %v", issue.ClosedAt.In(tz)))
 			comments = append(comments, footer...)
 		}
 
-		if err := deleteIssueFile(existing, issue.Node.Number); err != nil {
-			return nil, 0, err
+		if gh.opts.FrontMatter {
+			fm, err := frontMatter(issue, tz).Marshal()
+			if err != nil {
+				return 0, errors.Wrap(err, fmt.Sprintf("error creating front matter for issue %d", issue.Number))
+			}
+			comments = append(fm, comments...)
+		}
+
+		gh.recordChanges(previous, comments, outputFile, issue)
+
+		if err := deleteIssueFile(existing, issue.Number); err != nil {
+			return 0, err
 		}
 
-		outputFile := filepath.Join(gh.opts.OutputPath, strings.ToLower(issue.Node.State), strconv.Itoa(issue.Node.Number)+".md")
 		if err := ioutil.WriteFile(outputFile, comments, os.ModePerm); err != nil {
-			return nil, 0, errors.Wrap(err, fmt.Sprintf("error writing issue %d", issue.Node.Number))
+			return 0, errors.Wrap(err, fmt.Sprintf("error writing issue %d", issue.Number))
 		}
 
-		if err := gh.writeMilestone(&issue, gh.regexMilestone, outputFile); err != nil {
-			return nil, 0, errors.Wrap(err, fmt.Sprintf("error creating symlink for issue %d", issue.Node.Number))
+		if err := gh.writeMilestone(issue, gh.regexMilestone, outputFile); err != nil {
+			return 0, errors.Wrap(err, fmt.Sprintf("error creating symlink for issue %d", issue.Number))
 		}
 
-		downloadedIssues = append(downloadedIssues, outputFile)
 		count++
 	}
-	return downloadedIssues, count, nil
+	return count, nil
+}
+
+// frontMatter creates the front matter for an issue
+func frontMatter(issue *Issue, tz *time.Location) *archive.FrontMatter {
+	fm := &archive.FrontMatter{
+		Number:    issue.Number,
+		Title:     issue.Title,
+		State:     strings.ToLower(issue.State),
+		Author:    issue.Author.Name,
+		CreatedAt: issue.CreatedAt.In(tz),
+		UpdatedAt: issue.UpdatedAt.In(tz),
+		Milestone: issue.Milestone.Title,
+		URL:       issue.URL,
+	}
+	if issue.Closed {
+		closedAt := issue.ClosedAt.In(tz)
+		fm.ClosedAt = &closedAt
+	}
+	for _, l := range issue.Labels.Nodes {
+		fm.Labels = append(fm.Labels, l.Name)
+	}
+	for _, a := range issue.Assignees.Nodes {
+		fm.Assignees = append(fm.Assignees, a.Name)
+	}
+	return fm
 }
 
-func deleteIssueFile(existing map[string][]string, issue int) error {
+// readPrevious reads the version of an issue currently stored in the archive, if any.
+// Without front matter the milestone is taken from the milestone symlinks.
+func (gh *GH) readPrevious(number int) *archive.Issue {
+	path, ok := gh.index[number]
+	if !ok {
+		return nil
+	}
+	previous, err := archive.ReadFile(path)
+	if err != nil {
+		log.Printf("Unable to read previous version of issue %d: %v\n", number, err)
+		return nil
+	}
+	if !previous.FrontMatter {
+		previous.Milestone = gh.milestones[number]
+	}
+	return previous
+}
+
+// recordChanges compares the previous and the new version of an issue and records all changes
+func (gh *GH) recordChanges(previous *archive.Issue, content []byte, outputFile string, issue *Issue) {
+	current, err := archive.Parse(content)
+	if err != nil {
+		log.Printf("Unable to parse issue %d: %v\n", issue.Number, err)
+		return
+	}
+	current.Number = issue.Number
+	current.State = strings.ToLower(issue.State)
+	current.UpdatedAt = issue.UpdatedAt
+	current.Milestone = issue.Milestone.Title
+	current.Path = outputFile
+	if previous != nil && !previous.FrontMatter {
+		// a missing symlink doesn't tell whether the issue had no milestone or the symlinks weren't
+		// written, and the folders have underscores instead of slashes, so only other milestones are changes
+		if previous.Milestone == "" || archive.MatchMilestone(previous.Milestone, current.Milestone) {
+			previous.Milestone = current.Milestone
+		}
+	}
+	gh.changes = append(gh.changes, archive.Diff(previous, current)...)
+}
+
+// Changes returns the changes to the archive made by FetchIssues
+func (gh *GH) Changes() []archive.Change {
+	return gh.changes
+}
+
+func deleteIssueFile(existing map[int][]string, issue int) error {
 	// delete existing issues, since we'll write new ones
-	if delPaths, ok := existing[strconv.Itoa(issue)+".md"]; ok {
+	if delPaths, ok := existing[issue]; ok {
 		for _, path := range delPaths {
 			if err := os.Remove(path); err != nil {
 				return errors.Wrap(err, "unable to delete existing issue")
@@ -346,9 +557,9 @@ func deleteIssueFile(existing map[string][]string, issue int) error {
 	return nil
 }
 
-func (gh *GH) writeMilestone(issue *IssueEdge, regexMilestones *regexp.Regexp, outputFile string) error {
-	if gh.opts.Milestones && issue.Node.Milestone.Title != "" {
-		ms := regexMilestones.ReplaceAllString(issue.Node.Milestone.Title, "_")
+func (gh *GH) writeMilestone(issue *Issue, regexMilestones *regexp.Regexp, outputFile string) error {
+	if gh.opts.Milestones && issue.Milestone.Title != "" {
+		ms := regexMilestones.ReplaceAllString(issue.Milestone.Title, "_")
 		if err := gh.createMilestoneDir(ms); err != nil {
 			return err
 		}
@@ -359,73 +570,49 @@ func (gh *GH) writeMilestone(issue *IssueEdge, regexMilestones *regexp.Regexp, o
 	return nil
 }
 
-func (gh *GH) createSymlink(outputFile string, ms string, issue *IssueEdge) error {
+func (gh *GH) createSymlink(outputFile string, ms string, issue *Issue) error {
 	oldPath := filepath.Join(outputFile)
 	if !filepath.IsAbs(oldPath) {
 		oldPath = filepath.Join("..", "..", "..", "..", outputFile)
 	}
-	newPath := filepath.Join(gh.opts.OutputPath, "milestones", ms, strings.ToLower(issue.Node.State), strconv.Itoa(issue.Node.Number)+".md")
+	newPath := filepath.Join(gh.opts.OutputPath, "milestones", ms, strings.ToLower(issue.State), strconv.Itoa(issue.Number)+".md")
 	if err := os.Symlink(oldPath, newPath); err != nil && !os.IsExist(err) {
 		return err
 	}
 	return nil
 }
 
-func (gh *GH) extractComments(issue *IssueEdge, tz *time.Location) ([]byte, error) {
-	var (
-		result    []byte
-		q         QueryComments
-		comments  = issue.Node.Comments
-		regex     = regexp.MustCompile(`(#(\d+))`)
-		variables = map[string]interface{}{
-			"issueNumber": github.Int(issue.Node.Number),
-			"count":       github.Int(gh.opts.Count),
-			"owner":       github.String(gh.opts.User),
-			"name":        github.String(gh.opts.Repo),
-		}
-	)
+func (gh *GH) extractComments(issue *Issue, tz *time.Location, outputFile string) []byte {
+	var result []byte
 
+	body, refs := gh.rewriteReferences(issue.Body, outputFile)
 	header := []byte(
 		fmt.Sprintf("%s\n---\n\nCreated by %s on %v:\n\n%s\n\n---\n",
-			issue.Node.Title,
-			issue.Node.Author.Name,
-			issue.Node.CreatedAt.In(tz),
-			regex.ReplaceAllString(issue.Node.Body, "[#$2]($2.md)"),
+			issue.Title,
+			issue.Author.Name,
+			issue.CreatedAt.In(tz),
+			body,
 		),
 	)
 
 	result = append(result, header...)
 
-	for {
-		for _, com := range comments.Nodes {
-			b := []byte(fmt.Sprintf("\n%s commented on %v:\n\n%s\n\n---\n",
-				com.Author.Login,
-				com.CreatedAt.In(tz),
-				regex.ReplaceAllString(com.Body, "[#$2]($2.md)"),
-				//com.Body,
-			),
-			)
-			result = append(result, b...)
-		}
-
-		// break endless loop if we're on the last page
-		if !comments.PageInfo.HasNextPage {
-			break
-		}
-
-		variables["commentsCursor"] = comments.PageInfo.EndCursor
-
-		err := gh.client.Query(context.Background(), &q, variables)
-		if err != nil {
-			return nil, err
-		}
-
-		comments = q.Repository.Issue.Comments
-
-		log.Println("Getting next page of comments")
+	for _, com := range issue.Comments.Nodes {
+		body, commentRefs := gh.rewriteReferences(com.Body, outputFile)
+		refs = append(refs, commentRefs...)
+		b := []byte(fmt.Sprintf("\n<a id=\"issuecomment-%d\"></a>%s commented on %v:\n\n%s\n\n---\n",
+			com.DatabaseID,
+			com.Author.Login,
+			com.CreatedAt.In(tz),
+			body,
+		),
+		)
+		result = append(result, b...)
 	}
 
-	return result, nil
+	gh.references.set(issue.Number, issue.Title, gh.referencedIssues(refs))
+
+	return result
 }
 
 func (gh *GH) createDirs() error {
@@ -454,14 +641,46 @@ func (gh *GH) createMilestoneDir(milestone string) error {
 	return nil
 }
 
-func readExistingIssues(path string) (map[string][]string, error) {
-	existing := make(map[string][]string)
-	err := filepath.Walk(path, func(path string, info os.FileInfo, err error) error {
+// readExistingIssues returns the paths of all issue files found in path, keyed by issue number.
+// The number is taken from the front matter if present, otherwise from the file name.
+// Files in path itself (eg. change reports), drafts and new issues aren't issues of the archive.
+func readExistingIssues(root string) (map[int][]string, error) {
+	existing := make(map[int][]string)
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
-		existing[info.Name()] = append(existing[info.Name()], path)
+		if info.IsDir() && (path == filepath.Join(root, draft.Dir) || path == filepath.Join(root, draft.NewDir)) {
+			return filepath.SkipDir
+		}
+		if info.IsDir() || filepath.Ext(info.Name()) != ".md" || filepath.Dir(path) == filepath.Clean(root) {
+			return nil
+		}
+		number, err := issueNumber(path, info)
+		if err != nil {
+			log.Printf("Skipping %s: %v\n", path, err)
+			return nil
+		}
+		existing[number] = append(existing[number], path)
 		return nil
 	})
 	return existing, err
 }
+
+// issueNumber determines the issue number of an existing file
+func issueNumber(path string, info os.FileInfo) (int, error) {
+	if info.Mode().IsRegular() {
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			return 0, err
+		}
+		fm, _, err := archive.ParseFrontMatter(b)
+		if err != nil {
+			return 0, err
+		}
+		if fm != nil && fm.Number > 0 {
+			return fm.Number, nil
+		}
+	}
+	return strconv.Atoi(strings.TrimSuffix(info.Name(), ".md"))
+}
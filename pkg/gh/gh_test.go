@@ -4,6 +4,8 @@ import (
 	"reflect"
 	"testing"
 	"time"
+
+	"github.com/S7evinK/issues-to-go/pkg/archive"
 )
 
 func TestOptions(t *testing.T) {
@@ -154,3 +156,37 @@ func TestOptions(t *testing.T) {
 		})
 	}
 }
+
+func TestRecordChangesMilestone(t *testing.T) {
+	content := []byte("Title\n---\n\nCreated by S7evinK on 2019-11-15 13:05:33 +0100 CET:\n\nHello\n\n---\n")
+	tests := []struct {
+		name        string
+		frontMatter bool
+		previous    string
+		current     string
+		want        bool
+	}{
+		{name: "unknown without front matter", previous: "", current: "v1"},
+		{name: "symlink folder", previous: "v2_beta", current: "v2/beta"},
+		{name: "other symlink folder", previous: "v1", current: "v2", want: true},
+		{name: "added with front matter", frontMatter: true, previous: "", current: "v1", want: true},
+		{name: "unchanged with front matter", frontMatter: true, previous: "v1", current: "v1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gh := &GH{}
+			previous := &archive.Issue{Number: 1, Title: "Title", State: "open", Milestone: tt.previous, FrontMatter: tt.frontMatter}
+			issue := &Issue{Number: 1, State: "OPEN"}
+			issue.Milestone.Title = tt.current
+			gh.recordChanges(previous, content, "open/1.md", issue)
+
+			var got bool
+			for _, c := range gh.Changes() {
+				got = got || c.Kind == archive.MilestoneChange
+			}
+			if got != tt.want {
+				t.Errorf("got milestone change %v, want %v (changes %+v)", got, tt.want, gh.Changes())
+			}
+		})
+	}
+}
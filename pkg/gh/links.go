@@ -0,0 +1,235 @@
+package gh
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// regexReference matches all of Github's reference syntaxes:
+	// issue and comment URLs, owner/repo#123, GH-123 and #123
+	regexReference = regexp.MustCompile(`https?://github\.com/([\w.-]+)/([\w.-]+)/(issues|pull)/(\d+)(?:#(issuecomment-\d+))?|([\w.-]+)/([\w.-]+)#(\d+)|GH-(\d+)|#(\d+)`)
+	// regexIssueLink matches links created by rewriteReferences
+	regexIssueLink = regexp.MustCompile(`\[((?:[\w.-]+/[\w.-]+)?#\d+(?: \(comment\))?|GH-\d+)\]\(([^)\s]+)\)`)
+	// regexLocalTarget matches link targets pointing to a file in an archive
+	regexLocalTarget = regexp.MustCompile(`^/?(?:[\w.-]+/)*(?:open|closed)/(\d+)\.md(?:#(issuecomment-\d+))?$|^(\d+)\.md$`)
+	// regexCommentAnchor matches the comment anchor of a link target
+	regexCommentAnchor = regexp.MustCompile(`#(issuecomment-\d+)$`)
+)
+
+// issueIndex maps issue numbers to the path of the issue file in the open or closed folder
+type issueIndex map[int]string
+
+// reference defines a reference to an issue, pull request or comment on Github
+type reference struct {
+	owner   string
+	repo    string
+	number  int
+	pull    bool
+	comment string
+	// github is set for references given as Github URL
+	github bool
+}
+
+// newIssueIndex creates an index from the existing files, ignoring the milestone symlinks
+func newIssueIndex(outputPath string, existing map[int][]string) issueIndex {
+	index := make(issueIndex)
+	for number, paths := range existing {
+		for _, path := range paths {
+			if isStateDir(outputPath, filepath.Dir(path)) {
+				index[number] = path
+			}
+		}
+	}
+	return index
+}
+
+// isStateDir reports whether dir is the open or closed folder of the output path
+func isStateDir(outputPath, dir string) bool {
+	for _, state := range []string{"open", "closed"} {
+		if filepath.Clean(dir) == filepath.Join(outputPath, state) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseReference parses the reference at match, which are the submatch indices of regexReference.
+// Returns false, if the match isn't a reference, eg. because it's part of a word.
+func (gh *GH) parseReference(s string, match []int) (reference, bool) {
+	group := func(i int) string {
+		if match[2*i] < 0 {
+			return ""
+		}
+		return s[match[2*i]:match[2*i+1]]
+	}
+	ref := reference{owner: gh.opts.User, repo: gh.opts.Repo}
+
+	// the reference must not be followed by something which makes it a different word or path
+	if match[1] < len(s) && (isWordChar(s[match[1]]) || strings.ContainsRune("/-#", rune(s[match[1]]))) {
+		return ref, false
+	}
+
+	var number string
+	switch {
+	case group(4) != "":
+		ref.owner, ref.repo, ref.pull, number, ref.comment = group(1), group(2), group(3) == "pull", group(4), group(5)
+		ref.github = true
+	case group(8) != "":
+		ref.owner, ref.repo, number = group(6), group(7), group(8)
+	case group(9) != "":
+		number = group(9)
+	default:
+		number = group(10)
+	}
+
+	// references which aren't URLs must start a new word
+	if group(4) == "" && match[0] > 0 && (isWordChar(s[match[0]-1]) || strings.ContainsRune("/&#.-\\", rune(s[match[0]-1]))) {
+		return ref, false
+	}
+
+	n, err := strconv.Atoi(number)
+	if err != nil || strings.HasPrefix(number, "0") {
+		return ref, false
+	}
+	ref.number = n
+	return ref, true
+}
+
+func isWordChar(c byte) bool {
+	return c == '_' || '0' <= c && c <= '9' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+// sameRepo reports whether ref points to the downloaded repository
+func (gh *GH) sameRepo(ref reference) bool {
+	return strings.EqualFold(ref.owner, gh.opts.User) && strings.EqualFold(ref.repo, gh.opts.Repo)
+}
+
+// url returns the Github URL of a reference
+func (ref reference) url() string {
+	kind := "issues"
+	if ref.pull {
+		kind = "pull"
+	}
+	url := fmt.Sprintf("https://github.com/%s/%s/%s/%d", ref.owner, ref.repo, kind, ref.number)
+	if ref.comment != "" {
+		url += "#" + ref.comment
+	}
+	return url
+}
+
+// issueURL returns the URL of a reference in the source of the issues.
+// Pull requests and references given as Github URL always point to Github.
+func (gh *GH) issueURL(ref reference) string {
+	if gh.opts.Source == nil || ref.pull || ref.github {
+		return ref.url()
+	}
+	return gh.opts.Source.IssueURL(ref.owner, ref.repo, ref.number, ref.comment)
+}
+
+// localPath returns the path to the referenced issue, if it is downloaded
+func (gh *GH) localPath(ref reference) (string, bool) {
+	if ref.pull {
+		return "", false
+	}
+	if gh.sameRepo(ref) {
+		path, ok := gh.index[ref.number]
+		return path, ok
+	}
+	if gh.opts.Root == "" {
+		return "", false
+	}
+	for _, state := range []string{"open", "closed"} {
+		path := filepath.Join(gh.opts.Root, ref.owner, ref.repo, state, strconv.Itoa(ref.number)+".md")
+		if _, err := os.Stat(path); err == nil {
+			return path, true
+		}
+	}
+	return "", false
+}
+
+// linkTarget returns the link to a reference relative to the file at from. With milestones the link is relative
+// to the output folder and starts with a slash, since the files are also opened through the milestone symlinks.
+// Links to other archives are always relative to from, since they are outside of the output folder.
+// References which aren't downloaded are linked to the source of the issues.
+func (gh *GH) linkTarget(from string, ref reference) string {
+	target, ok := gh.localPath(ref)
+	if !ok {
+		return gh.issueURL(ref)
+	}
+	rootLink := gh.opts.Milestones && gh.sameRepo(ref)
+	base := filepath.Dir(from)
+	if rootLink {
+		base = gh.opts.OutputPath
+	}
+	rel, err := filepath.Rel(base, target)
+	if err != nil {
+		return gh.issueURL(ref)
+	}
+	rel = filepath.ToSlash(rel)
+	if rootLink {
+		rel = "/" + rel
+	}
+	if ref.comment != "" {
+		rel += "#" + ref.comment
+	}
+	return rel
+}
+
+// linkText returns the text used for links to references given as URL
+func (gh *GH) linkText(ref reference) string {
+	text := fmt.Sprintf("#%d", ref.number)
+	if !gh.sameRepo(ref) {
+		text = ref.owner + "/" + ref.repo + text
+	}
+	if ref.comment != "" {
+		text += " (comment)"
+	}
+	return text
+}
+
+// parseIssueLink parses a link created by rewriteReferences.
+// Returns false, if the link wasn't created by rewriteReferences.
+func (gh *GH) parseIssueLink(text, target string) (reference, bool) {
+	match := regexReference.FindStringSubmatchIndex(text)
+	if match == nil {
+		return reference{}, false
+	}
+	ref, ok := gh.parseReference(text, match)
+	if !ok {
+		return ref, false
+	}
+	if m := regexCommentAnchor.FindStringSubmatch(target); m != nil {
+		ref.comment = m[1]
+	}
+	if target == gh.issueURL(ref) || target == ref.url() {
+		return ref, true
+	}
+	ref.pull = true
+	if target == ref.url() {
+		return ref, true
+	}
+	ref.pull = false
+
+	m := regexLocalTarget.FindStringSubmatch(target)
+	if m == nil || m[1] != strconv.Itoa(ref.number) && m[3] != strconv.Itoa(ref.number) {
+		return ref, false
+	}
+	return ref, true
+}
+
+// relinkFile updates all issue links in content, so they point to the current location of the issue
+func (gh *GH) relinkFile(content []byte, from string) []byte {
+	return regexIssueLink.ReplaceAllFunc(content, func(link []byte) []byte {
+		m := regexIssueLink.FindSubmatch(link)
+		ref, ok := gh.parseIssueLink(string(m[1]), string(m[2]))
+		if !ok {
+			return link
+		}
+		return []byte(fmt.Sprintf("[%s](%s)", m[1], gh.linkTarget(from, ref)))
+	})
+}
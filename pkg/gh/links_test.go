@@ -0,0 +1,178 @@
+package gh
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRelinkFile(t *testing.T) {
+	root, err := ioutil.TempDir("", "issues-to-go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	if err := os.MkdirAll(filepath.Join(root, "other", "lib", "closed"), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(root, "other", "lib", "closed", "7.md"), nil, os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+
+	gh := &GH{
+		opts: Options{User: "S7evinK", Repo: "issues-to-go", OutputPath: filepath.Join(root, "S7evinK", "issues-to-go"), Root: root},
+		index: issueIndex{
+			1: filepath.Join(root, "S7evinK", "issues-to-go", "open", "1.md"),
+			2: filepath.Join(root, "S7evinK", "issues-to-go", "closed", "2.md"),
+		},
+	}
+	from := filepath.Join(root, "S7evinK", "issues-to-go", "open", "3.md")
+
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{
+			name:    "same state",
+			content: "see #1",
+			want:    "see [#1](1.md)",
+		},
+		{
+			name:    "other state",
+			content: "see #2",
+			want:    "see [#2](../closed/2.md)",
+		},
+		{
+			name:    "not downloaded",
+			content: "see #4",
+			want:    "see [#4](https://github.com/S7evinK/issues-to-go/issues/4)",
+		},
+		{
+			name:    "GH prefix",
+			content: "see GH-2.",
+			want:    "see [GH-2](../closed/2.md).",
+		},
+		{
+			name:    "other repository archived",
+			content: "see other/lib#7",
+			want:    "see [other/lib#7](../../../other/lib/closed/7.md)",
+		},
+		{
+			name:    "other repository not archived",
+			content: "see other/app#7",
+			want:    "see [other/app#7](https://github.com/other/app/issues/7)",
+		},
+		{
+			name:    "issue URL",
+			content: "see https://github.com/S7evinK/issues-to-go/issues/1",
+			want:    "see [#1](1.md)",
+		},
+		{
+			name:    "comment URL",
+			content: "see https://github.com/other/lib/issues/7#issuecomment-42",
+			want:    "see [other/lib#7 (comment)](../../../other/lib/closed/7.md#issuecomment-42)",
+		},
+		{
+			name:    "pull request URL",
+			content: "see https://github.com/S7evinK/issues-to-go/pull/1",
+			want:    "see [#1](https://github.com/S7evinK/issues-to-go/pull/1)",
+		},
+		{
+			name:    "no references",
+			content: "color: #000000; id abc#1 &#123; https://github.com/S7evinK/issues-to-go/pull/1/files",
+			want:    "color: #000000; id abc#1 &#123; https://github.com/S7evinK/issues-to-go/pull/1/files",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, _ := gh.rewriteReferences(tt.content, from); got != tt.want {
+				t.Errorf("rewriteReferences() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	relinkTests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{
+			name:    "moved to closed",
+			content: "see [#2](2.md)",
+			want:    "see [#2](../closed/2.md)",
+		},
+		{
+			name:    "moved to open",
+			content: "see [#1](../closed/1.md)",
+			want:    "see [#1](1.md)",
+		},
+		{
+			name:    "downloaded later",
+			content: "see [#1](https://github.com/S7evinK/issues-to-go/issues/1)",
+			want:    "see [#1](1.md)",
+		},
+		{
+			name:    "other repository archived later",
+			content: "see [other/lib#7](https://github.com/other/lib/issues/7)",
+			want:    "see [other/lib#7](../../../other/lib/closed/7.md)",
+		},
+		{
+			name:    "pull request is kept",
+			content: "see [#2](https://github.com/S7evinK/issues-to-go/pull/2)",
+			want:    "see [#2](https://github.com/S7evinK/issues-to-go/pull/2)",
+		},
+		{
+			name:    "foreign link is kept",
+			content: "see [#1](https://example.com/1)",
+			want:    "see [#1](https://example.com/1)",
+		},
+	}
+
+	for _, tt := range relinkTests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(gh.relinkFile([]byte(tt.content), from)); got != tt.want {
+				t.Errorf("relinkFile() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRootLinks(t *testing.T) {
+	root, err := ioutil.TempDir("", "issues-to-go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	if err := os.MkdirAll(filepath.Join(root, "other", "lib", "closed"), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(root, "other", "lib", "closed", "7.md"), nil, os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+
+	output := filepath.Join(root, "S7evinK", "issues-to-go")
+	gh := &GH{
+		opts: Options{User: "S7evinK", Repo: "issues-to-go", OutputPath: output, Root: root, Milestones: true},
+		index: issueIndex{
+			1: filepath.Join(output, "open", "1.md"),
+			2: filepath.Join(output, "closed", "2.md"),
+		},
+	}
+	from := filepath.Join(output, "open", "3.md")
+
+	content := "see #1, https://github.com/S7evinK/issues-to-go/issues/2#issuecomment-42 and other/lib#7"
+	want := "see [#1](/open/1.md), [#2 (comment)](/closed/2.md#issuecomment-42) and [other/lib#7](../../../other/lib/closed/7.md)"
+	got, _ := gh.rewriteReferences(content, from)
+	if got != want {
+		t.Errorf("rewriteReferences() = %q, want %q", got, want)
+	}
+
+	// relative links are converted and root links are updated when the issue moves
+	gh.index[1] = filepath.Join(output, "closed", "1.md")
+	if got, want := string(gh.relinkFile([]byte("see [#1](/open/1.md) and [#2](../closed/2.md)"), from)), "see [#1](/closed/1.md) and [#2](/closed/2.md)"; got != want {
+		t.Errorf("relinkFile() = %q, want %q", got, want)
+	}
+}
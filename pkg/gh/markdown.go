@@ -0,0 +1,71 @@
+package gh
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/text"
+)
+
+// textRange defines the start and end offset of prose in a Markdown document
+type textRange struct {
+	start, stop int
+}
+
+// proseRanges returns the ranges of source, which contain prose.
+// Code, HTML, links and images are left out, since references in them must not be rewritten.
+func proseRanges(source []byte) []textRange {
+	var ranges []textRange
+	doc := goldmark.DefaultParser().Parse(text.NewReader(source))
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch n := n.(type) {
+		case *ast.Link, *ast.Image, *ast.AutoLink, *ast.CodeSpan, *ast.RawHTML,
+			*ast.CodeBlock, *ast.FencedCodeBlock, *ast.HTMLBlock:
+			return ast.WalkSkipChildren, nil
+		case *ast.Text:
+			seg := n.Segment
+			// merge adjacent text nodes, since the inline parser splits text at special characters
+			if last := len(ranges) - 1; last >= 0 && ranges[last].stop == seg.Start {
+				ranges[last].stop = seg.Stop
+			} else {
+				ranges = append(ranges, textRange{start: seg.Start, stop: seg.Stop})
+			}
+		}
+		return ast.WalkContinue, nil
+	})
+	return ranges
+}
+
+// rewriteReferences replaces every reference in the prose of body with a link to the referenced issue.
+// Returns the rewritten body and all references found.
+func (gh *GH) rewriteReferences(body, from string) (string, []reference) {
+	var (
+		sb   strings.Builder
+		last int
+		refs []reference
+	)
+	for _, r := range proseRanges([]byte(body)) {
+		prose := body[r.start:r.stop]
+		for _, match := range regexReference.FindAllStringSubmatchIndex(prose, -1) {
+			ref, ok := gh.parseReference(prose, match)
+			if !ok {
+				continue
+			}
+			text := prose[match[0]:match[1]]
+			if strings.HasPrefix(text, "http") {
+				text = gh.linkText(ref)
+			}
+			sb.WriteString(body[last : r.start+match[0]])
+			sb.WriteString(fmt.Sprintf("[%s](%s)", text, gh.linkTarget(from, ref)))
+			last = r.start + match[1]
+			refs = append(refs, ref)
+		}
+	}
+	sb.WriteString(body[last:])
+	return sb.String(), refs
+}
@@ -0,0 +1,42 @@
+package gh
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRewriteReferencesCorpus(t *testing.T) {
+	gh := &GH{
+		opts: Options{User: "S7evinK", Repo: "issues-to-go", OutputPath: "issues"},
+		index: issueIndex{
+			1: filepath.Join("issues", "open", "1.md"),
+			2: filepath.Join("issues", "closed", "2.md"),
+		},
+	}
+	from := filepath.Join("issues", "open", "3.md")
+
+	files, err := filepath.Glob(filepath.Join("testdata", "references", "*.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range files {
+		if strings.HasSuffix(file, ".golden.md") {
+			continue
+		}
+		t.Run(filepath.Base(file), func(t *testing.T) {
+			body, err := ioutil.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want, err := ioutil.ReadFile(strings.TrimSuffix(file, ".md") + ".golden.md")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, _ := gh.rewriteReferences(string(body), from); got != string(want) {
+				t.Errorf("rewriteReferences() =\n%s\nwant\n%s", got, want)
+			}
+		})
+	}
+}
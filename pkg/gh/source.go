@@ -0,0 +1,121 @@
+package gh
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	github "github.com/shurcooL/githubv4"
+)
+
+// Source provides the issues written to the archive, eg. from Github or GitLab
+type Source interface {
+	// Issues returns a page of the issues updated since the given time in the given states ("open", "closed"),
+	// starting at cursor. Returns the cursor of the next page, which is empty on the last page.
+	// The returned issues contain all their comments.
+	Issues(ctx context.Context, since time.Time, states []string, cursor string) ([]Issue, string, error)
+	// Issue returns the issue with the number including all its comments, regardless of its state
+	Issue(ctx context.Context, number int) (*Issue, error)
+	// IssueURL returns the URL of an issue of the repository OWNER/REPOSITORY,
+	// pointing to the comment if it's set (eg. "issuecomment-42")
+	IssueURL(owner, repo string, number int, comment string) string
+}
+
+// githubSource reads the issues from the Github v4 api
+type githubSource struct {
+	client *github.Client
+	owner  string
+	repo   string
+	count  int
+}
+
+// Issues returns a page of issues from Github
+func (s *githubSource) Issues(ctx context.Context, since time.Time, states []string, cursor string) ([]Issue, string, error) {
+	var (
+		q           Query
+		issueStates []github.IssueState
+	)
+	for _, state := range states {
+		issueStates = append(issueStates, github.IssueState(strings.ToUpper(state)))
+	}
+	variables := map[string]interface{}{
+		"owner":          github.String(s.owner),
+		"name":           github.String(s.repo),
+		"issueCursor":    (*github.String)(nil),
+		"commentsCursor": (*github.String)(nil),
+		"count":          github.Int(s.count),
+		"filterBy":       github.IssueFilters{Since: &github.DateTime{Time: since.UTC()}, States: &issueStates},
+	}
+	if cursor != "" {
+		variables["issueCursor"] = github.NewString(github.String(cursor))
+	}
+	if err := s.client.Query(ctx, &q, variables); err != nil {
+		return nil, "", err
+	}
+
+	conn := q.Repository.IssueConnection
+	issues := make([]Issue, 0, len(conn.Edges))
+	for _, edge := range conn.Edges {
+		issue := edge.Node
+		if err := s.remainingComments(ctx, &issue); err != nil {
+			return nil, "", err
+		}
+		issues = append(issues, issue)
+	}
+
+	next := ""
+	if conn.PageInfo.HasNextPage {
+		next = string(conn.PageInfo.EndCursor)
+	}
+	return issues, next, nil
+}
+
+// Issue returns a single issue from Github
+func (s *githubSource) Issue(ctx context.Context, number int) (*Issue, error) {
+	var q QueryComments
+	variables := map[string]interface{}{
+		"issueNumber":    github.Int(number),
+		"count":          github.Int(s.count),
+		"commentsCursor": (*github.String)(nil),
+		"owner":          github.String(s.owner),
+		"name":           github.String(s.repo),
+	}
+	if err := s.client.Query(ctx, &q, variables); err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("unable to get issue %d", number))
+	}
+	issue := q.Repository.Issue
+	if err := s.remainingComments(ctx, &issue); err != nil {
+		return nil, err
+	}
+	return &issue, nil
+}
+
+// remainingComments adds the comments of the following pages to the issue
+func (s *githubSource) remainingComments(ctx context.Context, issue *Issue) error {
+	for issue.Comments.PageInfo.HasNextPage {
+		log.Println("Getting next page of comments")
+
+		var q QueryComments
+		variables := map[string]interface{}{
+			"issueNumber":    github.Int(issue.Number),
+			"count":          github.Int(s.count),
+			"commentsCursor": issue.Comments.PageInfo.EndCursor,
+			"owner":          github.String(s.owner),
+			"name":           github.String(s.repo),
+		}
+		if err := s.client.Query(ctx, &q, variables); err != nil {
+			return errors.Wrap(err, fmt.Sprintf("unable to get comments of issue %d", issue.Number))
+		}
+		issue.Comments.Nodes = append(issue.Comments.Nodes, q.Repository.Issue.Comments.Nodes...)
+		issue.Comments.PageInfo = q.Repository.Issue.Comments.PageInfo
+	}
+	return nil
+}
+
+// IssueURL returns the URL of an issue on Github
+func (s *githubSource) IssueURL(owner, repo string, number int, comment string) string {
+	return reference{owner: owner, repo: repo, number: number, comment: comment}.url()
+}
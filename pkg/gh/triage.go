@@ -0,0 +1,142 @@
+package gh
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/S7evinK/issues-to-go/pkg/triage"
+	"github.com/pkg/errors"
+	github "github.com/shurcooL/githubv4"
+)
+
+type (
+	// QueryIssueState is the query executed to check the preconditions of triage actions
+	QueryIssueState struct {
+		Repository struct {
+			Issue struct {
+				ID        string    `graphql:"id"`
+				State     string    `graphql:"state"`
+				Labels    Labels    `graphql:"labels(first: 100)"`
+				Milestone Milestone `graphql:"milestone"`
+			} `graphql:"issue(number: $issueNumber)"`
+		} `graphql:"repository(owner: $owner, name: $name)"`
+	}
+
+	// UpdateIssueInput is used to set or remove the milestone of an issue. Unlike github.UpdateIssueInput
+	// a nil MilestoneID is sent as null, which removes the milestone.
+	UpdateIssueInput struct {
+		ID          github.ID  `json:"id"`
+		MilestoneID *github.ID `json:"milestoneId"`
+	}
+
+	// MutationCloseIssue is the mutation executed to close an issue
+	MutationCloseIssue struct {
+		CloseIssue struct {
+			ClientMutationID string `graphql:"clientMutationId"`
+		} `graphql:"closeIssue(input: $input)"`
+	}
+
+	// MutationReopenIssue is the mutation executed to reopen an issue
+	MutationReopenIssue struct {
+		ReopenIssue struct {
+			ClientMutationID string `graphql:"clientMutationId"`
+		} `graphql:"reopenIssue(input: $input)"`
+	}
+
+	// MutationAddLabels is the mutation executed to add labels to an issue
+	MutationAddLabels struct {
+		AddLabelsToLabelable struct {
+			ClientMutationID string `graphql:"clientMutationId"`
+		} `graphql:"addLabelsToLabelable(input: $input)"`
+	}
+
+	// MutationRemoveLabels is the mutation executed to remove labels from an issue
+	MutationRemoveLabels struct {
+		RemoveLabelsFromLabelable struct {
+			ClientMutationID string `graphql:"clientMutationId"`
+		} `graphql:"removeLabelsFromLabelable(input: $input)"`
+	}
+
+	// MutationUpdateIssue is the mutation executed to change the body or the milestone of an issue
+	MutationUpdateIssue struct {
+		UpdateIssue struct {
+			ClientMutationID string `graphql:"clientMutationId"`
+		} `graphql:"updateIssue(input: $input)"`
+	}
+)
+
+// IssueState returns the node ID, state, labels and milestone of an issue
+func (gh *GH) IssueState(ctx context.Context, number int) (*triage.State, error) {
+	var q QueryIssueState
+	variables := map[string]interface{}{
+		"issueNumber": github.Int(number),
+		"owner":       github.String(gh.opts.User),
+		"name":        github.String(gh.opts.Repo),
+	}
+	if err := gh.client.Query(ctx, &q, variables); err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("unable to get issue %d", number))
+	}
+	issue := q.Repository.Issue
+	s := &triage.State{ID: issue.ID, State: strings.ToLower(issue.State), Milestone: issue.Milestone.Title}
+	for _, l := range issue.Labels.Nodes {
+		s.Labels = append(s.Labels, l.Name)
+	}
+	return s, nil
+}
+
+// CloseIssue closes the issue with the node ID
+func (gh *GH) CloseIssue(ctx context.Context, id string) error {
+	var m MutationCloseIssue
+	return gh.client.Mutate(ctx, &m, github.CloseIssueInput{IssueID: github.ID(id)}, nil)
+}
+
+// ReopenIssue reopens the issue with the node ID
+func (gh *GH) ReopenIssue(ctx context.Context, id string) error {
+	var m MutationReopenIssue
+	return gh.client.Mutate(ctx, &m, github.ReopenIssueInput{IssueID: github.ID(id)}, nil)
+}
+
+// AddLabels adds the labels to the issue with the node ID
+func (gh *GH) AddLabels(ctx context.Context, id string, labels []string) error {
+	ids, err := gh.labelIDs(ctx, labels)
+	if err != nil {
+		return err
+	}
+	var m MutationAddLabels
+	return gh.client.Mutate(ctx, &m, github.AddLabelsToLabelableInput{LabelableID: github.ID(id), LabelIDs: ids}, nil)
+}
+
+// RemoveLabels removes the labels from the issue with the node ID
+func (gh *GH) RemoveLabels(ctx context.Context, id string, labels []string) error {
+	ids, err := gh.labelIDs(ctx, labels)
+	if err != nil {
+		return err
+	}
+	var m MutationRemoveLabels
+	return gh.client.Mutate(ctx, &m, github.RemoveLabelsFromLabelableInput{LabelableID: github.ID(id), LabelIDs: ids}, nil)
+}
+
+// EditIssue replaces the body of the issue with the node ID
+func (gh *GH) EditIssue(ctx context.Context, id, body string) error {
+	var m MutationUpdateIssue
+	return gh.client.Mutate(ctx, &m, github.UpdateIssueInput{ID: github.ID(id), Body: github.NewString(github.String(body))}, nil)
+}
+
+// SetMilestone sets the milestone of the issue with the node ID, an empty milestone removes it
+func (gh *GH) SetMilestone(ctx context.Context, id string, milestone string) error {
+	input := UpdateIssueInput{ID: github.ID(id)}
+	if milestone != "" {
+		repo, err := gh.repositoryIDs(ctx)
+		if err != nil {
+			return err
+		}
+		msID, ok := findMilestone(repo.Repository.Milestones.Nodes, milestone)
+		if !ok {
+			return fmt.Errorf("milestone %q doesn't exist", milestone)
+		}
+		input.MilestoneID = &msID
+	}
+	var m MutationUpdateIssue
+	return gh.client.Mutate(ctx, &m, input, nil)
+}
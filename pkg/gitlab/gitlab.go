@@ -0,0 +1,272 @@
+// Package gitlab reads issues and their notes from the GitLab v4 api, so they are archived like issues from Github
+package gitlab
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/S7evinK/issues-to-go/pkg/gh"
+	"github.com/pkg/errors"
+)
+
+// DefaultURL is the URL of gitlab.com
+const DefaultURL = "https://gitlab.com"
+
+type (
+	// GitLab reads the issues of a project on gitlab.com or a self-hosted instance
+	GitLab struct {
+		client  *http.Client
+		baseURL string
+		project string
+		token   string
+		count   int
+	}
+
+	// issue is returned by the issues endpoints
+	issue struct {
+		ID          int        `json:"id"`
+		IID         int        `json:"iid"`
+		Title       string     `json:"title"`
+		Description string     `json:"description"`
+		State       string     `json:"state"`
+		CreatedAt   time.Time  `json:"created_at"`
+		UpdatedAt   time.Time  `json:"updated_at"`
+		ClosedAt    *time.Time `json:"closed_at"`
+		Labels      []string   `json:"labels"`
+		Milestone   *struct {
+			Title string `json:"title"`
+		} `json:"milestone"`
+		Author    user   `json:"author"`
+		Assignees []user `json:"assignees"`
+		WebURL    string `json:"web_url"`
+	}
+
+	// note is a comment of an issue returned by the notes endpoint
+	note struct {
+		ID        int       `json:"id"`
+		Body      string    `json:"body"`
+		Author    user      `json:"author"`
+		CreatedAt time.Time `json:"created_at"`
+		// System is set for notes created by GitLab, eg. when the milestone changed
+		System bool `json:"system"`
+	}
+
+	user struct {
+		Username string `json:"username"`
+	}
+)
+
+// New creates a client for the project (GROUP/PROJECT) on the GitLab instance at baseURL, which reads
+// count issues or notes per request. The token is a personal access token with the read_api scope.
+func New(baseURL, project, token string, count int) *GitLab {
+	if baseURL == "" {
+		baseURL = DefaultURL
+	}
+	if count <= 0 || count > 100 {
+		count = 100
+	}
+	return &GitLab{
+		client:  &http.Client{Timeout: 30 * time.Second},
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		project: project,
+		token:   token,
+		count:   count,
+	}
+}
+
+// Issues returns a page of the issues updated since the given time
+func (g *GitLab) Issues(ctx context.Context, since time.Time, states []string, cursor string) ([]gh.Issue, string, error) {
+	page := cursor
+	if page == "" {
+		page = "1"
+	}
+	query := url.Values{
+		"state":         {issueState(states)},
+		"updated_after": {since.UTC().Format(time.RFC3339)},
+		"order_by":      {"created_at"},
+		"sort":          {"asc"},
+		"per_page":      {strconv.Itoa(g.count)},
+		"page":          {page},
+	}
+	var issues []issue
+	next, err := g.get(ctx, "/issues", query, &issues)
+	if err != nil {
+		return nil, "", errors.Wrap(err, "unable to get issues")
+	}
+
+	result := make([]gh.Issue, 0, len(issues))
+	for _, i := range issues {
+		converted, err := g.convert(ctx, i)
+		if err != nil {
+			return nil, "", err
+		}
+		result = append(result, converted)
+	}
+	return result, next, nil
+}
+
+// Issue returns a single issue with its notes
+func (g *GitLab) Issue(ctx context.Context, number int) (*gh.Issue, error) {
+	var i issue
+	if _, err := g.get(ctx, "/issues/"+strconv.Itoa(number), nil, &i); err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("unable to get issue %d", number))
+	}
+	converted, err := g.convert(ctx, i)
+	if err != nil {
+		return nil, err
+	}
+	return &converted, nil
+}
+
+// IssueURL returns the URL of an issue on the GitLab instance. Comment anchors of the archive
+// (issuecomment-ID) are converted to the anchors of notes (note_ID).
+func (g *GitLab) IssueURL(owner, repo string, number int, comment string) string {
+	u := fmt.Sprintf("%s/%s/%s/-/issues/%d", g.baseURL, owner, repo, number)
+	if comment != "" {
+		u += "#note_" + strings.TrimPrefix(comment, "issuecomment-")
+	}
+	return u
+}
+
+// convert converts an issue to the issue written to the archive and adds its notes
+func (g *GitLab) convert(ctx context.Context, i issue) (gh.Issue, error) {
+	result := gh.Issue{
+		ID:        strconv.Itoa(i.ID),
+		Number:    i.IID,
+		Body:      i.Description,
+		Title:     i.Title,
+		Author:    gh.Author{Name: i.Author.Username},
+		CreatedAt: i.CreatedAt,
+		State:     "open",
+		UpdatedAt: i.UpdatedAt,
+		URL:       i.WebURL,
+	}
+	if i.State == "closed" {
+		result.State, result.Closed = "closed", true
+		if i.ClosedAt != nil {
+			result.ClosedAt = *i.ClosedAt
+		}
+	}
+	if i.Milestone != nil {
+		result.Milestone.Title = i.Milestone.Title
+	}
+	for _, l := range i.Labels {
+		result.Labels.Nodes = append(result.Labels.Nodes, gh.Label{Name: l})
+	}
+	for _, a := range i.Assignees {
+		result.Assignees.Nodes = append(result.Assignees.Nodes, gh.Author{Name: a.Username})
+	}
+
+	notes, err := g.notes(ctx, i.IID)
+	if err != nil {
+		return result, errors.Wrap(err, fmt.Sprintf("unable to get notes of issue %d", i.IID))
+	}
+	for _, n := range notes {
+		c := gh.Comment{DatabaseID: n.ID, Body: n.Body, CreatedAt: n.CreatedAt}
+		c.Author.Login = n.Author.Username
+		result.Comments.Nodes = append(result.Comments.Nodes, c)
+	}
+	return result, nil
+}
+
+// notes returns the notes of an issue written by users, oldest first
+func (g *GitLab) notes(ctx context.Context, number int) ([]note, error) {
+	var (
+		result []note
+		page   = "1"
+	)
+	for page != "" {
+		query := url.Values{
+			"order_by": {"created_at"},
+			"sort":     {"asc"},
+			"per_page": {strconv.Itoa(g.count)},
+			"page":     {page},
+		}
+		var notes []note
+		next, err := g.get(ctx, "/issues/"+strconv.Itoa(number)+"/notes", query, &notes)
+		if err != nil {
+			return nil, err
+		}
+		for _, n := range notes {
+			if !n.System {
+				result = append(result, n)
+			}
+		}
+		if next != "" {
+			log.Println("Getting next page of comments")
+		}
+		page = next
+	}
+	return result, nil
+}
+
+// get requests the path below the project and decodes the response into v.
+// Returns the next page, which is empty on the last page.
+func (g *GitLab) get(ctx context.Context, path string, query url.Values, v interface{}) (string, error) {
+	u := g.baseURL + "/api/v4/projects/" + url.PathEscape(g.project) + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return "", err
+	}
+	req = req.WithContext(ctx)
+	if g.token != "" {
+		req.Header.Set("PRIVATE-TOKEN", g.token)
+	}
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Message interface{} `json:"message"`
+			Error   string      `json:"error"`
+		}
+		if json.Unmarshal(b, &apiErr) == nil && (apiErr.Message != nil || apiErr.Error != "") {
+			msg := apiErr.Error
+			if apiErr.Message != nil {
+				msg = fmt.Sprint(apiErr.Message)
+			}
+			return "", fmt.Errorf("%s: %s", resp.Status, msg)
+		}
+		return "", fmt.Errorf("%s", resp.Status)
+	}
+	if err := json.Unmarshal(b, v); err != nil {
+		return "", errors.Wrap(err, "unable to decode response")
+	}
+	return resp.Header.Get("X-Next-Page"), nil
+}
+
+// issueState returns the state filter of the issues endpoint for the states of the archive
+func issueState(states []string) string {
+	var open, closed bool
+	for _, s := range states {
+		open = open || s == "open"
+		closed = closed || s == "closed"
+	}
+	switch {
+	case open && closed:
+		return "all"
+	case closed:
+		return "closed"
+	default:
+		return "opened"
+	}
+}
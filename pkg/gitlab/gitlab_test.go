@@ -0,0 +1,152 @@
+package gitlab
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/S7evinK/issues-to-go/pkg/archive"
+	"github.com/S7evinK/issues-to-go/pkg/gh"
+)
+
+// newServer serves two issues of the project group/project on two pages
+func newServer(t *testing.T) *httptest.Server {
+	responses := map[string]string{
+		"/issues?page=1": `[{"id": 11, "iid": 1, "title": "Crash", "description": "Same as #2, see #9", "state": "opened",
+			"created_at": "2019-11-02T17:30:00Z", "updated_at": "2019-11-03T10:00:00Z", "labels": ["bug"],
+			"milestone": {"title": "v1.0"}, "author": {"username": "alice"}, "assignees": [{"username": "bob"}],
+			"web_url": "https://gitlab.example.com/group/project/-/issues/1"}]`,
+		"/issues?page=2": `[{"id": 12, "iid": 2, "title": "Crash again", "description": "Hello", "state": "closed",
+			"created_at": "2019-11-04T17:30:00Z", "updated_at": "2019-11-05T10:00:00Z", "closed_at": "2019-11-05T10:00:00Z",
+			"labels": [], "milestone": null, "author": {"username": "bob"}, "assignees": [],
+			"web_url": "https://gitlab.example.com/group/project/-/issues/2"}]`,
+		"/issues/1/notes?page=1": `[{"id": 101, "body": "Confirmed", "author": {"username": "bob"}, "created_at": "2019-11-02T18:00:00Z"},
+			{"id": 102, "body": "changed milestone to %1", "author": {"username": "bob"}, "created_at": "2019-11-02T18:01:00Z", "system": true}]`,
+		"/issues/1/notes?page=2": `[{"id": 103, "body": "Fixed in #2", "author": {"username": "alice"}, "created_at": "2019-11-03T10:00:00Z"}]`,
+		"/issues/2/notes?page=1": `[]`,
+	}
+	// the single issue has the same fields as the issue in the list
+	issue2 := responses["/issues?page=2"]
+	responses["/issues/2?page=1"] = issue2[1 : len(issue2)-1]
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("PRIVATE-TOKEN") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"message": "401 Unauthorized"}`))
+			return
+		}
+		const prefix = "/api/v4/projects/group%2Fproject"
+		if !strings.HasPrefix(r.URL.RawPath, prefix) {
+			t.Errorf("unexpected path %s", r.URL.RawPath)
+		}
+		path := strings.TrimPrefix(r.URL.Path, "/api/v4/projects/group/project")
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		if page == 0 {
+			page = 1
+		}
+		body, ok := responses[path+"?page="+strconv.Itoa(page)]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"message": "404 Not found"}`))
+			return
+		}
+		if _, ok := responses[path+"?page="+strconv.Itoa(page+1)]; ok {
+			w.Header().Set("X-Next-Page", strconv.Itoa(page+1))
+		}
+		_, _ = w.Write([]byte(body))
+	}))
+}
+
+func TestIssues(t *testing.T) {
+	srv := newServer(t)
+	defer srv.Close()
+	g := New(srv.URL, "group/project", "secret", 1)
+
+	issues, next, err := g.Issues(context.Background(), time.Unix(0, 0), []string{"open", "closed"}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(issues) != 1 || next != "2" {
+		t.Fatalf("got %d issues and next page %q, want 1 and 2", len(issues), next)
+	}
+	i := issues[0]
+	if i.Number != 1 || i.State != "open" || i.Closed || i.Author.Name != "alice" || i.Milestone.Title != "v1.0" ||
+		len(i.Labels.Nodes) != 1 || len(i.Assignees.Nodes) != 1 || i.Assignees.Nodes[0].Name != "bob" {
+		t.Errorf("unexpected issue %+v", i)
+	}
+	if len(i.Comments.Nodes) != 2 || i.Comments.Nodes[0].DatabaseID != 101 || i.Comments.Nodes[1].Author.Login != "alice" {
+		t.Errorf("got comments %+v, want the notes 101 and 103", i.Comments.Nodes)
+	}
+
+	closed, err := g.Issue(context.Background(), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if closed.State != "closed" || !closed.Closed || closed.ClosedAt.IsZero() {
+		t.Errorf("unexpected closed issue %+v", closed)
+	}
+
+	if _, err := New(srv.URL, "group/project", "wrong", 1).Issue(context.Background(), 1); err == nil || !strings.Contains(err.Error(), "401 Unauthorized") {
+		t.Errorf("got error %v, want 401 Unauthorized", err)
+	}
+}
+
+func TestIssueURL(t *testing.T) {
+	g := New("https://gitlab.example.com/", "group/project", "", 0)
+	if got, want := g.IssueURL("group", "project", 3, ""), "https://gitlab.example.com/group/project/-/issues/3"; got != want {
+		t.Errorf("IssueURL() = %q, want %q", got, want)
+	}
+	if got, want := g.IssueURL("group", "project", 3, "issuecomment-42"), "https://gitlab.example.com/group/project/-/issues/3#note_42"; got != want {
+		t.Errorf("IssueURL() = %q, want %q", got, want)
+	}
+}
+
+func TestFetchIssues(t *testing.T) {
+	srv := newServer(t)
+	defer srv.Close()
+	dir, err := ioutil.TempDir("", "gitlab")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	cl, err := gh.New(
+		gh.Output(dir),
+		gh.All(true),
+		gh.Count(1),
+		gh.UTC(true),
+		gh.Repo("group/project"),
+		gh.Milestones(true),
+		gh.FrontMatter(true),
+		gh.From(New(srv.URL, "group/project", "secret", 1)),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cl.FetchIssues(); err != nil {
+		t.Fatal(err)
+	}
+
+	issues, err := archive.Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(issues) != 2 || issues[0].Path != filepath.Join(dir, "open", "1.md") || issues[1].Path != filepath.Join(dir, "closed", "2.md") {
+		t.Fatalf("got issues %+v, want open/1.md and closed/2.md", issues)
+	}
+	if want := "Same as [#2](/closed/2.md), see [#9](" + srv.URL + "/group/project/-/issues/9)"; issues[0].Body != want {
+		t.Errorf("got body %q, want %q", issues[0].Body, want)
+	}
+	if issues[0].Repository() != "group/project" || len(issues[0].Comments) != 2 {
+		t.Errorf("unexpected issue %+v", issues[0])
+	}
+	if _, err := os.Readlink(filepath.Join(dir, "milestones", "v1.0", "open", "1.md")); err != nil {
+		t.Errorf("missing milestone symlink: %v", err)
+	}
+}
@@ -0,0 +1,204 @@
+// Package mbox writes issues as mail threads in the mboxrd format.
+package mbox
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/S7evinK/issues-to-go/pkg/archive"
+)
+
+var (
+	regexMessageID = regexp.MustCompile(`(?i)^Message-ID:\s*(<[^>]+>)`)
+	regexFrom      = regexp.MustCompile(`(?m)^(>*From )`)
+)
+
+// defaultHost is the host of issues without URL, if no other host is given
+const defaultHost = "github.com"
+
+// message is a single mail of an issue thread
+type message struct {
+	id        string
+	inReplyTo string
+	author    string
+	host      string
+	date      time.Time
+	subject   string
+	body      string
+}
+
+// Host returns the host of the issue tracker, taken from the URL of the issue. The URL is unknown
+// for archives without front matter, their issues are on the given host or on Github if it's empty.
+func Host(issue *archive.Issue, host string) string {
+	if u, err := url.Parse(issue.URL); err == nil && u.Host != "" {
+		return u.Hostname()
+	}
+	if host != "" {
+		return host
+	}
+	return defaultHost
+}
+
+// IssueMessageID returns the Message-ID of the mail containing the issue body. For issues on
+// Github the format matches the notification mails sent by Github, so threads can be mixed.
+func IssueMessageID(host, repo string, number int) string {
+	return fmt.Sprintf("<%s/issues/%d@%s>", repo, number, host)
+}
+
+// CommentMessageID returns the Message-ID of the mail containing a comment. Comments without an ID (archives
+// created by older versions) are identified by their author and creation time, which don't change on later syncs.
+func CommentMessageID(host, repo string, number int, c archive.Comment) string {
+	if c.ID > 0 {
+		return fmt.Sprintf("<%s/issues/%d/%d@%s>", repo, number, c.ID, host)
+	}
+	return fmt.Sprintf("<%s/issues/%d/comment-%s-%d@issues-to-go>", repo, number, c.Author, c.CreatedAt.Unix())
+}
+
+// messages converts an issue to a mail thread, the first message is the root of the thread
+func messages(host, repo string, issue *archive.Issue) []message {
+	subject := fmt.Sprintf("[%s] %s (#%d)", repo, issue.Title, issue.Number)
+	host = Host(issue, host)
+	root := IssueMessageID(host, repo, issue.Number)
+
+	msgs := []message{{
+		id:      root,
+		author:  issue.Author,
+		host:    host,
+		date:    issue.CreatedAt,
+		subject: subject,
+		body:    issue.Body,
+	}}
+	for _, c := range issue.Comments {
+		msgs = append(msgs, message{
+			id:        CommentMessageID(host, repo, issue.Number, c),
+			inReplyTo: root,
+			author:    c.Author,
+			host:      host,
+			date:      c.CreatedAt,
+			subject:   "Re: " + subject,
+			body:      c.Body,
+		})
+	}
+	return msgs
+}
+
+// write writes a message in the mboxrd format, the sender is the no-reply address of the author on the host
+func (m message) write(w io.Writer) error {
+	var buf bytes.Buffer
+	author := m.author
+	if author == "" {
+		author = "ghost"
+	}
+	fmt.Fprintf(&buf, "From %s@users.noreply.%s %s\n", author, m.host, m.date.UTC().Format(time.ANSIC))
+	fmt.Fprintf(&buf, "From: %s <%s@users.noreply.%s>\n", mime.QEncoding.Encode("utf-8", author), author, m.host)
+	fmt.Fprintf(&buf, "Date: %s\n", m.date.Format(time.RFC1123Z))
+	fmt.Fprintf(&buf, "Subject: %s\n", mime.QEncoding.Encode("utf-8", m.subject))
+	fmt.Fprintf(&buf, "Message-ID: %s\n", m.id)
+	if m.inReplyTo != "" {
+		fmt.Fprintf(&buf, "In-Reply-To: %s\n", m.inReplyTo)
+		fmt.Fprintf(&buf, "References: %s\n", m.inReplyTo)
+	}
+	buf.WriteString("MIME-Version: 1.0\n")
+	buf.WriteString("Content-Type: text/plain; charset=utf-8\n")
+	buf.WriteString("Content-Transfer-Encoding: 8bit\n\n")
+
+	body := strings.Replace(m.body, "\r\n", "\n", -1)
+	buf.WriteString(regexFrom.ReplaceAllString(body, ">$1"))
+	buf.WriteString("\n\n")
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// Write writes all issues as mail threads to w. Host is the host of the issues without URL, see Host.
+func Write(w io.Writer, host, repo string, issues []*archive.Issue) error {
+	_, err := write(w, host, repo, issues, nil)
+	return err
+}
+
+func write(w io.Writer, host, repo string, issues []*archive.Issue, existing map[string]bool) (int, error) {
+	count := 0
+	for _, issue := range issues {
+		repo := repo
+		if r := issue.Repository(); r != "" {
+			repo = r
+		}
+		if repo == "" {
+			return count, fmt.Errorf("unknown repository of issue %d", issue.Number)
+		}
+		for _, m := range messages(host, repo, issue) {
+			if existing[m.id] {
+				continue
+			}
+			if err := m.write(w); err != nil {
+				return count, err
+			}
+			count++
+		}
+	}
+	return count, nil
+}
+
+// Append adds all messages, which aren't already part of the mbox file at path.
+// The file is created if it doesn't exist. Returns the number of appended messages.
+func Append(path, host, repo string, issues []*archive.Issue) (int, error) {
+	existing, err := messageIDs(path)
+	if err != nil {
+		return 0, err
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return 0, err
+	}
+	w := bufio.NewWriter(f)
+	count, err := write(w, host, repo, issues, existing)
+	if err != nil {
+		f.Close()
+		return count, err
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return count, err
+	}
+	return count, f.Close()
+}
+
+// messageIDs returns the IDs of all messages in the mbox file at path
+func messageIDs(path string) (map[string]bool, error) {
+	ids := make(map[string]bool)
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return ids, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	s := bufio.NewScanner(f)
+	s.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	inHeader := false
+	for s.Scan() {
+		line := s.Text()
+		switch {
+		case strings.HasPrefix(line, "From "):
+			inHeader = true
+		case line == "":
+			inHeader = false
+		case inHeader:
+			if m := regexMessageID.FindStringSubmatch(line); m != nil {
+				ids[m[1]] = true
+			}
+		}
+	}
+	return ids, s.Err()
+}
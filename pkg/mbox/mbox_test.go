@@ -0,0 +1,117 @@
+package mbox
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/S7evinK/issues-to-go/pkg/archive"
+)
+
+func TestAppend(t *testing.T) {
+	dir, err := ioutil.TempDir("", "issues-to-go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "issues.mbox")
+
+	created := time.Date(2019, time.November, 15, 13, 5, 33, 0, time.UTC)
+	issue := &archive.Issue{
+		Number:    1,
+		Title:     "Test issue",
+		Author:    "S7evinK",
+		CreatedAt: created,
+		Body:      "Hello World!\nFrom here on",
+		Comments:  []archive.Comment{{ID: 11, Author: "alice", CreatedAt: created, Body: "first"}},
+	}
+
+	tests := []struct {
+		name    string
+		comment *archive.Comment
+		want    int
+	}{
+		{name: "new issue", want: 2},
+		{name: "no changes", want: 0},
+		{name: "new comment", comment: &archive.Comment{ID: 12, Author: "bob", CreatedAt: created, Body: "second"}, want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.comment != nil {
+				issue.Comments = append(issue.Comments, *tt.comment)
+			}
+			count, err := Append(path, "", "S7evinK/issues-to-go", []*archive.Issue{issue})
+			if err != nil {
+				t.Fatalf("Append() error = %v", err)
+			}
+			if count != tt.want {
+				t.Errorf("Append() = %d, want %d", count, tt.want)
+			}
+		})
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := string(b)
+	for _, want := range []string{
+		"Message-ID: <S7evinK/issues-to-go/issues/1@github.com>\n",
+		"Message-ID: <S7evinK/issues-to-go/issues/1/12@github.com>\nIn-Reply-To: <S7evinK/issues-to-go/issues/1@github.com>\nReferences: <S7evinK/issues-to-go/issues/1@github.com>\n",
+		"Hello World!\n>From here on\n",
+	} {
+		if !strings.Contains(content, want) {
+			t.Errorf("mbox doesn't contain %q:\n%s", want, content)
+		}
+	}
+}
+
+func TestHost(t *testing.T) {
+	created := time.Date(2019, time.November, 15, 13, 5, 33, 0, time.UTC)
+	issue := &archive.Issue{
+		Number:    2,
+		Title:     "Crash",
+		Author:    "alice",
+		CreatedAt: created,
+		URL:       "https://gitlab.example.com/group/sub/project/-/issues/2",
+		Comments:  []archive.Comment{{ID: 21, Author: "bob", CreatedAt: created, Body: "Confirmed"}},
+	}
+	var b strings.Builder
+	if err := Write(&b, "", "", []*archive.Issue{issue}); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"From: alice <alice@users.noreply.gitlab.example.com>\n",
+		"Message-ID: <group/sub/project/issues/2@gitlab.example.com>\n",
+		"Message-ID: <group/sub/project/issues/2/21@gitlab.example.com>\n",
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("mbox doesn't contain %q:\n%s", want, b.String())
+		}
+	}
+	if got := Host(&archive.Issue{}, ""); got != "github.com" {
+		t.Errorf("Host() = %q for an issue without URL, want github.com", got)
+	}
+	if got := Host(&archive.Issue{}, "gitlab.example.com"); got != "gitlab.example.com" {
+		t.Errorf("Host() = %q for an issue without URL on gitlab.example.com", got)
+	}
+}
+
+func TestCommentMessageIDWithoutID(t *testing.T) {
+	created := time.Date(2019, time.November, 15, 13, 5, 33, 0, time.UTC)
+	c := archive.Comment{Author: "alice", CreatedAt: created, Body: "first"}
+	issue := &archive.Issue{Number: 1, Title: "Test issue", Author: "S7evinK", CreatedAt: created, Comments: []archive.Comment{c}}
+	before := messages("", "S7evinK/issues-to-go", issue)[1].id
+
+	// a comment inserted before doesn't change the ID
+	issue.Comments = []archive.Comment{{Author: "bob", CreatedAt: created.Add(-time.Hour)}, c}
+	if after := messages("", "S7evinK/issues-to-go", issue)[2].id; after != before {
+		t.Errorf("Message-ID changed from %s to %s", before, after)
+	}
+	if want := "<S7evinK/issues-to-go/issues/1/comment-alice-1573823133@issues-to-go>"; before != want {
+		t.Errorf("Message-ID = %s, want %s", before, want)
+	}
+}
@@ -0,0 +1,420 @@
+// Package migrate recreates the issues of an archive in another repository.
+//
+// The issues are created in the order of their numbers, followed by their comments. Authors and dates
+// can't be set, so they are added as attribution to the top of every issue and comment. References to
+// other issues of the archive are rewritten to the new numbers, references to issues migrated later
+// are rewritten once all issues are migrated. The migrated issues and comments are recorded in a
+// mapping file, which is used to resume an interrupted migration without creating anything twice.
+package migrate
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/S7evinK/issues-to-go/pkg/archive"
+	"github.com/S7evinK/issues-to-go/pkg/gh"
+)
+
+// timeLayout is the layout of dates in attributions
+const timeLayout = "2006-01-02 15:04 MST"
+
+var (
+	// regexLink matches Markdown links
+	regexLink = regexp.MustCompile(`\[([^\]]*)\]\(([^)\s]+)\)`)
+	// regexIssueURL matches the URL of a Github issue
+	regexIssueURL = regexp.MustCompile(`^https://github\.com/([\w.-]+)/([\w.-]+)/issues/(\d+)(?:#([\w-]+))?$`)
+)
+
+// Target is the repository the issues are migrated to
+type Target interface {
+	RepositoryNames(ctx context.Context) ([]string, []string, error)
+	CreateIssue(ctx context.Context, issue gh.NewIssue) (*gh.CreatedIssue, error)
+	AddComment(ctx context.Context, issueID, body string) (*gh.PostedComment, error)
+	CloseIssue(ctx context.Context, id string) error
+	EditIssue(ctx context.Context, id, body string) error
+	EditComment(ctx context.Context, id, body string) error
+}
+
+// Mapping records the migrated issues and comments
+type Mapping struct {
+	Source string `json:"source"`
+	Target string `json:"target"`
+	// Issues contains the migrated issues by their number in the source repository
+	Issues map[int]*Issue `json:"issues"`
+	path   string
+}
+
+// Issue is a migrated issue
+type Issue struct {
+	Number int    `json:"number"`
+	ID     string `json:"id"`
+	URL    string `json:"url,omitempty"`
+	// Comments contains the node IDs of the migrated comments in their original order
+	Comments []string `json:"comments,omitempty"`
+	// CommentURLs contains the URLs of the migrated comments by the anchor of the original comment
+	CommentURLs map[string]string `json:"comment_urls,omitempty"`
+	Closed      bool              `json:"closed,omitempty"`
+	// Complete is set once all comments are migrated and the issue is closed, if necessary
+	Complete bool `json:"complete,omitempty"`
+	// Unresolved is set if the issue references issues of the archive which weren't migrated yet
+	Unresolved bool `json:"unresolved,omitempty"`
+}
+
+// OpenMapping reads the mapping file at path, a missing file is an empty mapping.
+// The mapping must belong to a migration from source to target.
+func OpenMapping(path, source, target string) (*Mapping, error) {
+	m := &Mapping{Source: source, Target: target, Issues: make(map[int]*Issue), path: path}
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, m); err != nil {
+		return nil, err
+	}
+	if m.Source != source || m.Target != target {
+		return nil, fmt.Errorf("%s belongs to the migration from %s to %s", path, m.Source, m.Target)
+	}
+	if m.Issues == nil {
+		m.Issues = make(map[int]*Issue)
+	}
+	return m, nil
+}
+
+// Save writes the mapping file, replacing it atomically
+func (m *Mapping) Save() error {
+	b, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(m.path), os.ModePerm); err != nil {
+		return err
+	}
+	tmp := m.path + ".tmp"
+	if err := ioutil.WriteFile(tmp, append(b, '\n'), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, m.path)
+}
+
+// Migrator migrates issues to the target repository
+type Migrator struct {
+	Target  Target
+	Mapping *Mapping
+	// Milestones maps milestones of the archive to milestones of the target, other milestones keep their title
+	Milestones map[string]string
+	// DryRun only reports what would be migrated
+	DryRun bool
+	// Log receives progress messages
+	Log func(format string, args ...interface{})
+
+	labels     map[string]bool
+	milestones map[string]bool
+	archived   map[int]bool
+	warned     map[string]bool
+	// issueURL is the URL of an archived issue, empty if the archive has no front matter
+	issueURL string
+}
+
+// Run migrates the issues, which must be sorted by number. Issues migrated completely are skipped.
+// Labels and milestones which don't exist in the target are left out.
+func (m *Migrator) Run(ctx context.Context, issues []*archive.Issue) error {
+	labels, milestones, err := m.Target.RepositoryNames(ctx)
+	if err != nil {
+		return err
+	}
+	m.labels, m.milestones, m.archived, m.warned = make(map[string]bool), make(map[string]bool), make(map[int]bool), make(map[string]bool)
+	for _, l := range labels {
+		m.labels[l] = true
+	}
+	for _, ms := range milestones {
+		m.milestones[ms] = true
+	}
+	for _, i := range issues {
+		m.archived[i.Number] = true
+		if m.issueURL == "" {
+			m.issueURL = i.URL
+		}
+	}
+
+	for _, i := range issues {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := m.migrate(ctx, i); err != nil {
+			return fmt.Errorf("issue %d: %v", i.Number, err)
+		}
+	}
+	if m.DryRun {
+		return nil
+	}
+
+	// rewrite the references to issues which were migrated after the referencing issue
+	for _, i := range issues {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := m.resolve(ctx, i); err != nil {
+			return fmt.Errorf("issue %d: %v", i.Number, err)
+		}
+	}
+	return nil
+}
+
+// migrate creates the issue and its comments, continuing where a previous run stopped
+func (m *Migrator) migrate(ctx context.Context, issue *archive.Issue) error {
+	mi := m.Mapping.Issues[issue.Number]
+	if mi != nil && mi.Complete {
+		return nil
+	}
+	if m.DryRun {
+		done := 0
+		if mi != nil {
+			done = len(mi.Comments)
+		}
+		m.log("#%d %s: %d of %d comment(s) to migrate", issue.Number, issue.Title, len(issue.Comments)-done, len(issue.Comments))
+		m.newIssue(issue)
+		return nil
+	}
+
+	if mi == nil {
+		body, unresolved := m.issueBody(issue)
+		ni := m.newIssue(issue)
+		ni.Body = body
+		created, err := m.Target.CreateIssue(ctx, ni)
+		if err != nil {
+			return err
+		}
+		mi = &Issue{Number: created.Number, ID: created.ID, URL: created.URL, Unresolved: unresolved}
+		m.Mapping.Issues[issue.Number] = mi
+		if err := m.Mapping.Save(); err != nil {
+			return err
+		}
+		m.log("#%d migrated to #%d", issue.Number, created.Number)
+	}
+
+	for n := len(mi.Comments); n < len(issue.Comments); n++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		body, unresolved := m.commentBody(issue.Comments[n])
+		posted, err := m.Target.AddComment(ctx, mi.ID, body)
+		if err != nil {
+			return err
+		}
+		mi.Comments = append(mi.Comments, posted.ID)
+		if id := issue.Comments[n].ID; id != 0 && posted.URL != "" {
+			if mi.CommentURLs == nil {
+				mi.CommentURLs = make(map[string]string)
+			}
+			mi.CommentURLs["issuecomment-"+strconv.Itoa(id)] = posted.URL
+		}
+		mi.Unresolved = mi.Unresolved || unresolved
+		if err := m.Mapping.Save(); err != nil {
+			return err
+		}
+	}
+
+	if issue.Closed() && !mi.Closed {
+		if err := m.Target.CloseIssue(ctx, mi.ID); err != nil {
+			return err
+		}
+		mi.Closed = true
+	}
+	mi.Complete = true
+	return m.Mapping.Save()
+}
+
+// resolve rewrites the body and the comments of a migrated issue, which referenced issues not migrated at the time
+func (m *Migrator) resolve(ctx context.Context, issue *archive.Issue) error {
+	mi := m.Mapping.Issues[issue.Number]
+	if mi == nil || !mi.Unresolved {
+		return nil
+	}
+	body, unresolved := m.issueBody(issue)
+	if err := m.Target.EditIssue(ctx, mi.ID, body); err != nil {
+		return err
+	}
+	for n, id := range mi.Comments {
+		if n >= len(issue.Comments) {
+			break
+		}
+		text, u := m.commentBody(issue.Comments[n])
+		unresolved = unresolved || u
+		if _, _, changed := m.rewriteCount(issue.Comments[n].Body); changed == 0 {
+			continue
+		}
+		if err := m.Target.EditComment(ctx, id, text); err != nil {
+			return err
+		}
+	}
+	mi.Unresolved = unresolved
+	m.log("#%d: updated references in #%d", issue.Number, mi.Number)
+	return m.Mapping.Save()
+}
+
+// newIssue returns the issue to create with the labels and the milestone which exist in the target.
+// Without front matter the labels are unknown and the milestone is taken from the milestone symlinks.
+func (m *Migrator) newIssue(issue *archive.Issue) gh.NewIssue {
+	ni := gh.NewIssue{Title: issue.Title}
+	if !issue.FrontMatter {
+		m.warn("the archive has no front matter, labels are left out")
+	}
+	for _, l := range issue.Labels {
+		if m.labels[l] {
+			ni.Labels = append(ni.Labels, l)
+		} else {
+			m.warn("label %q doesn't exist in the target, it's left out", l)
+		}
+	}
+	if issue.Milestone != "" {
+		ms := issue.Milestone
+		for from, to := range m.Milestones {
+			if archive.MatchMilestone(ms, from) {
+				ms = to
+				break
+			}
+		}
+		if title := m.targetMilestone(ms); title != "" {
+			ni.Milestone = title
+		} else if ms != "" {
+			m.warn("milestone %q doesn't exist in the target, it's left out", ms)
+		}
+	}
+	return ni
+}
+
+// targetMilestone returns the title of the milestone in the target, empty if it doesn't exist. Milestones
+// taken from the symlinks have underscores instead of slashes, so the titles are compared by MatchMilestone.
+func (m *Migrator) targetMilestone(ms string) string {
+	if m.milestones[ms] {
+		return ms
+	}
+	for title := range m.milestones {
+		if archive.MatchMilestone(ms, title) {
+			return title
+		}
+	}
+	return ""
+}
+
+// issueBody returns the body of the migrated issue and whether it references issues not migrated yet
+func (m *Migrator) issueBody(issue *archive.Issue) (string, bool) {
+	url := issue.URL
+	if url == "" {
+		url = m.sourceURL(issue.Number)
+	}
+	body, unresolved := m.rewrite(issue.Body)
+	return fmt.Sprintf("_Originally opened by **%s** on %s as [%s#%d](%s)_\n\n%s",
+		issue.Author, issue.CreatedAt.UTC().Format(timeLayout), m.Mapping.Source, issue.Number, url, body), unresolved
+}
+
+// commentBody returns the body of the migrated comment and whether it references issues not migrated yet
+func (m *Migrator) commentBody(c archive.Comment) (string, bool) {
+	body, unresolved := m.rewrite(c.Body)
+	return fmt.Sprintf("_Originally posted by **%s** on %s_\n\n%s", c.Author, c.CreatedAt.UTC().Format(timeLayout), body), unresolved
+}
+
+// rewrite points links to migrated issues to the new issues and their comments, references to their new
+// numbers keep the text written by Github. Other links to issues are replaced by their Github URL.
+// Reports whether the text references issues of the archive which weren't migrated yet.
+func (m *Migrator) rewrite(text string) (string, bool) {
+	rewritten, unresolved, _ := m.rewriteCount(text)
+	return rewritten, unresolved
+}
+
+// rewriteCount is like rewrite and also returns the number of references to migrated issues
+func (m *Migrator) rewriteCount(text string) (string, bool, int) {
+	var (
+		unresolved bool
+		migrated   int
+	)
+	rewritten := regexLink.ReplaceAllStringFunc(text, func(s string) string {
+		sub := regexLink.FindStringSubmatch(s)
+		link, ok := archive.ParseLink(sub[2])
+		if !ok {
+			if link, ok = parseIssueURL(sub[2]); !ok {
+				return s
+			}
+		}
+		if link.Owner != "" && link.Owner+"/"+link.Repo != m.Mapping.Source {
+			return "[" + sub[1] + "](" + link.URL(m.issueURL) + ")"
+		}
+		if mi := m.Mapping.Issues[link.Number]; mi != nil {
+			migrated++
+			if _, ok := mi.CommentURLs[link.Fragment]; !ok && !mi.Complete && strings.HasPrefix(link.Fragment, "issuecomment-") {
+				// the comment wasn't migrated yet
+				unresolved = true
+			}
+			return m.migratedLink(sub[1], link, mi)
+		}
+		if m.archived[link.Number] {
+			unresolved = true
+		}
+		return fmt.Sprintf("[%s#%d](%s)", m.Mapping.Source, link.Number, m.sourceURL(link.Number))
+	})
+	return rewritten, unresolved, migrated
+}
+
+// migratedLink returns the link to a migrated issue, a comment is linked if it was migrated already.
+// Texts starting with the old number, like the references written by Github, get the new number.
+func (m *Migrator) migratedLink(text string, link archive.Link, mi *Issue) string {
+	old := "#" + strconv.Itoa(link.Number)
+	if text == old || strings.HasPrefix(text, old+" ") {
+		text = "#" + strconv.Itoa(mi.Number) + text[len(old):]
+	}
+	url, ok := mi.CommentURLs[link.Fragment]
+	if !ok {
+		if text == "#"+strconv.Itoa(mi.Number) {
+			return text
+		}
+		url = mi.URL
+		if url == "" {
+			url = fmt.Sprintf("https://github.com/%s/issues/%d", m.Mapping.Target, mi.Number)
+		}
+	}
+	return "[" + text + "](" + url + ")"
+}
+
+// parseIssueURL parses the URL of a Github issue
+func parseIssueURL(url string) (archive.Link, bool) {
+	sub := regexIssueURL.FindStringSubmatch(url)
+	if sub == nil {
+		return archive.Link{}, false
+	}
+	n, err := strconv.Atoi(sub[3])
+	return archive.Link{Owner: sub[1], Repo: sub[2], Number: n, Fragment: sub[4]}, err == nil
+}
+
+// sourceURL returns the URL of an issue in the source repository, which is on Github unless the
+// URLs of the archived issues point elsewhere
+func (m *Migrator) sourceURL(number int) string {
+	if m.issueURL != "" {
+		return archive.Link{Number: number}.URL(m.issueURL)
+	}
+	return fmt.Sprintf("https://github.com/%s/issues/%d", m.Mapping.Source, number)
+}
+
+func (m *Migrator) log(format string, args ...interface{}) {
+	if m.Log != nil {
+		m.Log(format, args...)
+	}
+}
+
+// warn logs a message only once
+func (m *Migrator) warn(format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	if !m.warned[msg] {
+		m.warned[msg] = true
+		m.log("%s", msg)
+	}
+}
@@ -0,0 +1,193 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/S7evinK/issues-to-go/pkg/archive"
+	"github.com/S7evinK/issues-to-go/pkg/gh"
+)
+
+// fakeTarget records the calls and numbers the created issues starting at 100
+type fakeTarget struct {
+	issues   []gh.NewIssue
+	comments map[string]string
+	edits    map[string]string
+	calls    []string
+	// failAfter makes AddComment fail once the number of comments is reached
+	failAfter int
+}
+
+func (f *fakeTarget) RepositoryNames(ctx context.Context) ([]string, []string, error) {
+	return []string{"bug"}, []string{"v2.0"}, nil
+}
+
+func (f *fakeTarget) CreateIssue(ctx context.Context, issue gh.NewIssue) (*gh.CreatedIssue, error) {
+	f.issues = append(f.issues, issue)
+	n := 99 + len(f.issues)
+	f.calls = append(f.calls, fmt.Sprintf("create %d", n))
+	return &gh.CreatedIssue{ID: fmt.Sprintf("I%d", n), Number: n}, nil
+}
+
+func (f *fakeTarget) AddComment(ctx context.Context, issueID, body string) (*gh.PostedComment, error) {
+	if f.failAfter > 0 && len(f.comments) >= f.failAfter {
+		return nil, errors.New("rate limited")
+	}
+	if f.comments == nil {
+		f.comments = make(map[string]string)
+	}
+	n := len(f.comments) + 1
+	id := fmt.Sprintf("C%d", n)
+	f.comments[id] = body
+	f.calls = append(f.calls, "comment "+issueID)
+	url := fmt.Sprintf("https://github.com/new/repo/issues/%s#issuecomment-%d", strings.TrimPrefix(issueID, "I"), 500+n)
+	return &gh.PostedComment{ID: id, URL: url}, nil
+}
+
+func (f *fakeTarget) CloseIssue(ctx context.Context, id string) error {
+	f.calls = append(f.calls, "close "+id)
+	return nil
+}
+
+func (f *fakeTarget) EditIssue(ctx context.Context, id, body string) error {
+	f.edit(id, body)
+	return nil
+}
+
+func (f *fakeTarget) EditComment(ctx context.Context, id, body string) error {
+	f.edit(id, body)
+	return nil
+}
+
+func (f *fakeTarget) edit(id, body string) {
+	if f.edits == nil {
+		f.edits = make(map[string]string)
+	}
+	f.edits[id] = body
+	f.calls = append(f.calls, "edit "+id)
+}
+
+func testIssues() []*archive.Issue {
+	created := time.Date(2019, 11, 2, 18, 30, 0, 0, time.FixedZone("CET", 3600))
+	return []*archive.Issue{
+		{
+			Number: 1, Title: "Crash", Author: "alice", CreatedAt: created, State: "closed",
+			Labels: []string{"bug", "ui"}, Milestone: "v1.0", FrontMatter: true,
+			Body: "Same as [#2](../open/2.md), see [other#5](../../other/lib/open/5.md)",
+			Comments: []archive.Comment{
+				{ID: 11, Author: "bob", CreatedAt: created, Body: "Fixed"},
+				{ID: 12, Author: "alice", CreatedAt: created, Body: "See [#2](https://github.com/owner/repo/issues/2)"},
+			},
+		},
+		{
+			Number: 2, Title: "Crash again", Author: "bob", CreatedAt: created, State: "open",
+			Body: "Follow-up of [#1](1.md) and [#9](https://github.com/owner/repo/issues/9), " +
+				"see [#1 (comment)](../closed/1.md#issuecomment-11) and [the crash](https://github.com/owner/repo/issues/1)",
+		},
+	}
+}
+
+func TestRun(t *testing.T) {
+	dir, err := ioutil.TempDir("", "migrate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "mapping.json")
+
+	mapping, err := OpenMapping(path, "owner/repo", "new/repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	target := &fakeTarget{failAfter: 1}
+	m := &Migrator{Target: target, Mapping: mapping, Milestones: map[string]string{"v1.0": "v2.0"}}
+	if err := m.Run(context.Background(), testIssues()); err == nil {
+		t.Fatal("expected the migration to fail")
+	}
+
+	// resume with a new mapping read from the file
+	mapping, err = OpenMapping(path, "owner/repo", "new/repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := mapping.Issues[1]; got == nil || got.Number != 100 || len(got.Comments) != 1 || got.Complete {
+		t.Fatalf("got mapping %+v after the failure, want issue 100 with 1 comment", got)
+	}
+	target.failAfter = 0
+	m = &Migrator{Target: target, Mapping: mapping, Milestones: map[string]string{"v1.0": "v2.0"}}
+	if err := m.Run(context.Background(), testIssues()); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"create 100", "comment I100", "comment I100", "close I100", "create 101", "edit I100", "edit C2"}
+	if !reflect.DeepEqual(target.calls, want) {
+		t.Errorf("got calls %v, want %v", target.calls, want)
+	}
+	if got := target.issues[0]; !reflect.DeepEqual(got.Labels, []string{"bug"}) || got.Milestone != "v2.0" {
+		t.Errorf("got labels %v and milestone %q, want bug and v2.0", got.Labels, got.Milestone)
+	}
+	if !strings.HasPrefix(target.issues[0].Body, "_Originally opened by **alice** on 2019-11-02 17:30 UTC as [owner/repo#1](https://github.com/owner/repo/issues/1)_\n\n") {
+		t.Errorf("unexpected attribution: %q", target.issues[0].Body)
+	}
+	if want := "Follow-up of #100 and [owner/repo#9](https://github.com/owner/repo/issues/9), " +
+		"see [#100 (comment)](https://github.com/new/repo/issues/100#issuecomment-501) and [the crash](https://github.com/new/repo/issues/100)"; !strings.HasSuffix(target.issues[1].Body, want) {
+		t.Errorf("got body %q, want suffix %q", target.issues[1].Body, want)
+	}
+	if want := "Same as #101, see [other#5](https://github.com/other/lib/issues/5)"; !strings.HasSuffix(target.edits["I100"], want) {
+		t.Errorf("got edited body %q, want suffix %q", target.edits["I100"], want)
+	}
+	if want := "See #101"; !strings.HasSuffix(target.edits["C2"], want) {
+		t.Errorf("got edited comment %q, want suffix %q", target.edits["C2"], want)
+	}
+	if mapping.Issues[1].Unresolved || !mapping.Issues[2].Complete {
+		t.Errorf("unexpected mapping %+v %+v", mapping.Issues[1], mapping.Issues[2])
+	}
+
+	// a complete migration doesn't create anything again
+	target.calls = nil
+	if err := m.Run(context.Background(), testIssues()); err != nil {
+		t.Fatal(err)
+	}
+	if len(target.calls) != 0 {
+		t.Errorf("got calls %v, want none", target.calls)
+	}
+
+	if _, err := OpenMapping(path, "owner/repo", "other/repo"); err == nil {
+		t.Error("expected an error for a mapping of another migration")
+	}
+}
+
+func TestNewIssueWithoutFrontMatter(t *testing.T) {
+	var logged []string
+	m := &Migrator{
+		Milestones: map[string]string{"v1/rc": "v1.0"},
+		Log:        func(format string, args ...interface{}) { logged = append(logged, fmt.Sprintf(format, args...)) },
+		labels:     map[string]bool{"bug": true},
+		milestones: map[string]bool{"v1.0": true, "v2/beta": true},
+		warned:     make(map[string]bool),
+	}
+	tests := []struct {
+		milestone string
+		want      string
+	}{
+		{milestone: "v2_beta", want: "v2/beta"},
+		{milestone: "v1_rc", want: "v1.0"},
+		{milestone: "v3", want: ""},
+	}
+	for _, tt := range tests {
+		if got := m.newIssue(&archive.Issue{Title: "Crash", Milestone: tt.milestone}); got.Milestone != tt.want {
+			t.Errorf("got milestone %q for %q, want %q", got.Milestone, tt.milestone, tt.want)
+		}
+	}
+	if len(logged) != 2 || !strings.Contains(logged[0], "no front matter") {
+		t.Errorf("got log %q, want a warning about the missing front matter and milestone v3", logged)
+	}
+}
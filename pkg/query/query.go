@@ -0,0 +1,431 @@
+// Package query evaluates Github search queries (eg. is:open label:bug sort:comments-desc) against the archive.
+package query
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/S7evinK/issues-to-go/pkg/archive"
+)
+
+// unsupported contains qualifiers of Github, which can't be evaluated with the data of the archive
+var unsupported = map[string]bool{
+	"base": true, "draft": true, "head": true, "interactions": true, "language": true, "linked": true,
+	"project": true, "reactions": true, "reason": true, "review": true, "review-requested": true,
+	"reviewed-by": true, "status": true, "team": true, "team-review-requested": true, "archived": true,
+}
+
+// sortKeys maps the sort keys of Github to the keys of archive.Sort
+var sortKeys = map[string]string{
+	"created":  "created",
+	"updated":  "updated",
+	"comments": "comments",
+}
+
+type (
+	// Query is a parsed Github search query
+	Query struct {
+		// Text contains the parts of the query which aren't qualifiers
+		Text string
+		// In contains the fields the text is searched in (title, body, comments), empty means all
+		In []string
+		// SortKey is the key for archive.Sort given by sort:, empty if the query doesn't define the order
+		SortKey  string
+		SortDesc bool
+		// Ignored contains the qualifiers, which can't be evaluated offline and are ignored (eg. is:locked)
+		Ignored []string
+
+		predicates []predicate
+		words      []word
+	}
+
+	// predicate is a qualifier of the query
+	predicate struct {
+		match  func(i *archive.Issue) bool
+		negate bool
+		// frontMatter is set for predicates on labels and assignees, which are only known from the front matter.
+		// Issues without front matter never match them.
+		frontMatter bool
+	}
+
+	// word is a term or phrase of the text, which must be contained in the issue
+	word struct {
+		text   string
+		negate bool
+	}
+)
+
+// Parse parses a Github search query. Unknown qualifiers are treated as text, like Github does.
+// Values of is: which can't be evaluated offline are ignored, see Ignored.
+func Parse(s string) (*Query, error) {
+	q := &Query{}
+	var text []string
+	for _, item := range Split(s) {
+		negate := strings.HasPrefix(item, "-") && len(item) > 1
+		key, value := "", ""
+		if i := strings.Index(item, ":"); i > 0 && !strings.HasPrefix(item, `"`) {
+			key = strings.ToLower(strings.TrimPrefix(item[:i], "-"))
+			value = unquote(item[i+1:])
+		}
+
+		if key != "" && unsupported[key] {
+			return nil, fmt.Errorf("the qualifier %s: is not supported offline", key)
+		}
+		ok, err := q.qualifier(key, value, negate)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			continue
+		}
+
+		text = append(text, item)
+		w := word{text: strings.ToLower(unquote(strings.TrimPrefix(item, "-"))), negate: negate}
+		if w.text != "" {
+			q.words = append(q.words, w)
+		}
+	}
+	q.Text = strings.Join(text, " ")
+	return q, nil
+}
+
+// qualifier adds the predicate for key:value to the query, returns false if key isn't a known qualifier
+func (q *Query) qualifier(key, value string, negate bool) (bool, error) {
+	add := func(match func(i *archive.Issue) bool) {
+		q.predicates = append(q.predicates, predicate{match: match, negate: negate})
+	}
+	addFrontMatter := func(match func(i *archive.Issue) bool) {
+		q.predicates = append(q.predicates, predicate{match: match, negate: negate, frontMatter: true})
+	}
+	lower := strings.ToLower(value)
+
+	switch key {
+	case "is", "state", "type":
+		switch lower {
+		case "open", "closed":
+			add(func(i *archive.Issue) bool { return strings.EqualFold(i.State, lower) })
+		case "issue":
+			add(func(i *archive.Issue) bool { return true })
+		case "pr", "pull-request", "merged", "unmerged":
+			add(func(i *archive.Issue) bool { return false })
+		default:
+			// eg. is:locked, the archive doesn't contain this information
+			q.Ignored = append(q.Ignored, key+":"+value)
+		}
+	case "no":
+		switch lower {
+		case "label":
+			addFrontMatter(func(i *archive.Issue) bool { return len(i.Labels) == 0 })
+		case "milestone":
+			add(func(i *archive.Issue) bool { return i.Milestone == "" })
+		case "assignee":
+			addFrontMatter(func(i *archive.Issue) bool { return len(i.Assignees) == 0 })
+		default:
+			return false, fmt.Errorf("unsupported value %q for no:", value)
+		}
+	case "label":
+		labels := strings.Split(value, ",")
+		for i := range labels {
+			labels[i] = unquote(labels[i])
+		}
+		addFrontMatter(func(i *archive.Issue) bool { return containsAny(i.Labels, labels) })
+	case "milestone":
+		add(func(i *archive.Issue) bool { return archive.MatchMilestone(i.Milestone, value) })
+	case "author":
+		add(func(i *archive.Issue) bool { return strings.EqualFold(i.Author, value) })
+	case "assignee":
+		addFrontMatter(func(i *archive.Issue) bool { return containsAny(i.Assignees, []string{value}) })
+	case "commenter":
+		add(func(i *archive.Issue) bool { return commented(i, value) })
+	case "mentions":
+		add(func(i *archive.Issue) bool { return mentions(i, value) })
+	case "involves":
+		add(func(i *archive.Issue) bool {
+			return strings.EqualFold(i.Author, value) || containsAny(i.Assignees, []string{value}) || commented(i, value) || mentions(i, value)
+		})
+	case "repo":
+		add(func(i *archive.Issue) bool { r := i.Repository(); return r == "" || strings.EqualFold(r, value) })
+	case "user", "org":
+		add(func(i *archive.Issue) bool {
+			r := i.Repository()
+			return r == "" || strings.EqualFold(strings.SplitN(r, "/", 2)[0], value)
+		})
+	case "created", "updated", "closed":
+		match, err := parseDateRange(value)
+		if err != nil {
+			return false, fmt.Errorf("invalid date for %s: %v", key, err)
+		}
+		date := map[string]func(i *archive.Issue) time.Time{
+			"created": func(i *archive.Issue) time.Time { return i.CreatedAt },
+			"updated": func(i *archive.Issue) time.Time { return i.LastActivity() },
+			"closed":  func(i *archive.Issue) time.Time { return i.ClosedAt },
+		}[key]
+		add(func(i *archive.Issue) bool { t := date(i); return !t.IsZero() && match(t) })
+	case "comments":
+		match, err := parseNumberRange(value)
+		if err != nil {
+			return false, fmt.Errorf("invalid number for comments: %v", err)
+		}
+		add(func(i *archive.Issue) bool { return match(len(i.Comments)) })
+	case "in":
+		for _, f := range strings.Split(lower, ",") {
+			if f != "title" && f != "body" && f != "comments" {
+				return false, fmt.Errorf("unsupported value %q for in:", f)
+			}
+			q.In = append(q.In, f)
+		}
+	case "sort":
+		parts := strings.SplitN(lower, "-", 2)
+		k, ok := sortKeys[parts[0]]
+		if !ok {
+			return false, fmt.Errorf("unsupported value %q for sort:", value)
+		}
+		q.SortKey, q.SortDesc = k, len(parts) == 1 || parts[1] == "desc"
+		if len(parts) == 2 && parts[1] != "asc" && parts[1] != "desc" {
+			return false, fmt.Errorf("unsupported value %q for sort:", value)
+		}
+	default:
+		return false, nil
+	}
+	return true, nil
+}
+
+// Match reports whether the issue matches the qualifiers and the text of the query.
+// The text is matched case-insensitively as substrings of the fields given by in:.
+func (q *Query) Match(i *archive.Issue) bool {
+	if !q.MatchQualifiers(i) {
+		return false
+	}
+	for _, w := range q.words {
+		if q.contains(i, w.text) == w.negate {
+			return false
+		}
+	}
+	return true
+}
+
+// MatchQualifiers reports whether the issue matches the qualifiers of the query, the text is ignored
+func (q *Query) MatchQualifiers(i *archive.Issue) bool {
+	for _, p := range q.predicates {
+		if p.frontMatter && !i.FrontMatter || p.match(i) == p.negate {
+			return false
+		}
+	}
+	return true
+}
+
+// Apply returns the issues matching the query, sorted by the order given with sort:
+func (q *Query) Apply(issues []*archive.Issue) []*archive.Issue {
+	var selected []*archive.Issue
+	for _, i := range issues {
+		if q.Match(i) {
+			selected = append(selected, i)
+		}
+	}
+	if q.SortKey != "" {
+		_ = archive.Sort(selected, q.SortKey, q.SortDesc)
+	}
+	return selected
+}
+
+// contains reports whether the fields of the issue selected by in: contain text
+func (q *Query) contains(i *archive.Issue, text string) bool {
+	in := func(field string) bool {
+		if len(q.In) == 0 {
+			return true
+		}
+		for _, f := range q.In {
+			if f == field {
+				return true
+			}
+		}
+		return false
+	}
+	if in("title") && strings.Contains(strings.ToLower(i.Title), text) {
+		return true
+	}
+	if in("body") && strings.Contains(strings.ToLower(i.Body), text) {
+		return true
+	}
+	if in("comments") {
+		for _, c := range i.Comments {
+			if strings.Contains(strings.ToLower(c.Body), text) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func containsAny(list, values []string) bool {
+	for _, l := range list {
+		for _, v := range values {
+			if strings.EqualFold(l, strings.TrimSpace(v)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func commented(i *archive.Issue, user string) bool {
+	for _, c := range i.Comments {
+		if strings.EqualFold(c.Author, user) {
+			return true
+		}
+	}
+	return false
+}
+
+// mentions reports whether the body or a comment of the issue mentions @user
+func mentions(i *archive.Issue, user string) bool {
+	re, err := regexp.Compile(`(?i)(^|[^\w])@` + regexp.QuoteMeta(user) + `\b`)
+	if err != nil {
+		return false
+	}
+	if re.MatchString(i.Body) {
+		return true
+	}
+	for _, c := range i.Comments {
+		if re.MatchString(c.Body) {
+			return true
+		}
+	}
+	return false
+}
+
+// Split splits a query at whitespace outside of quotes
+func Split(s string) []string {
+	var (
+		items  []string
+		item   strings.Builder
+		quoted bool
+	)
+	for _, r := range s {
+		switch {
+		case r == '"':
+			quoted = !quoted
+			item.WriteRune(r)
+		case !quoted && (r == ' ' || r == '\t' || r == '\n'):
+			if item.Len() > 0 {
+				items = append(items, item.String())
+				item.Reset()
+			}
+		default:
+			item.WriteRune(r)
+		}
+	}
+	if item.Len() > 0 {
+		items = append(items, item.String())
+	}
+	return items
+}
+
+func unquote(s string) string {
+	if len(s) >= 2 && strings.HasPrefix(s, `"`) && strings.HasSuffix(s, `"`) {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+// parseNumberRange parses a number or range like >5, <=3, 10..20 or 5..*
+func parseNumberRange(s string) (func(int) bool, error) {
+	bounds, err := parseRange(s, func(s string) (interface{}, error) { return strconv.Atoi(s) })
+	if err != nil {
+		return nil, err
+	}
+	return func(n int) bool {
+		return bounds.match(func(v interface{}) int { return n - v.(int) })
+	}, nil
+}
+
+// parseDateRange parses a date or range like >2024-01-01, <=2024-01-01T10:00:00Z or 2024-01-01..2024-02-01.
+// Dates without time cover the whole day, all dates without time zone are in UTC like on Github.
+func parseDateRange(s string) (func(time.Time) bool, error) {
+	bounds, err := parseRange(s, func(s string) (interface{}, error) { return parseDate(s) })
+	if err != nil {
+		return nil, err
+	}
+	return func(t time.Time) bool {
+		return bounds.match(func(v interface{}) int {
+			d := v.(day)
+			switch {
+			case t.Before(d.start):
+				return -1
+			case t.Before(d.end):
+				return 0
+			}
+			return 1
+		})
+	}, nil
+}
+
+// day is a period of time given as date, a date with time has the same start and end
+type day struct {
+	start, end time.Time
+}
+
+func parseDate(s string) (day, error) {
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02T15:04"} {
+		if t, err := time.ParseInLocation(layout, s, time.UTC); err == nil {
+			return day{start: t, end: t.Add(time.Second)}, nil
+		}
+	}
+	t, err := time.ParseInLocation("2006-01-02", s, time.UTC)
+	if err != nil {
+		return day{}, err
+	}
+	return day{start: t, end: t.AddDate(0, 0, 1)}, nil
+}
+
+// bounds is a parsed range, nil values are unbounded
+type bounds struct {
+	op       string
+	from, to interface{}
+}
+
+func parseRange(s string, parse func(string) (interface{}, error)) (*bounds, error) {
+	if i := strings.Index(s, ".."); i >= 0 {
+		b := &bounds{op: ".."}
+		var err error
+		if from := s[:i]; from != "*" {
+			if b.from, err = parse(from); err != nil {
+				return nil, err
+			}
+		}
+		if to := s[i+2:]; to != "*" {
+			if b.to, err = parse(to); err != nil {
+				return nil, err
+			}
+		}
+		return b, nil
+	}
+	for _, op := range []string{">=", "<=", ">", "<"} {
+		if strings.HasPrefix(s, op) {
+			v, err := parse(s[len(op):])
+			return &bounds{op: op, from: v}, err
+		}
+	}
+	v, err := parse(s)
+	return &bounds{op: "=", from: v}, err
+}
+
+// match reports whether a value is within the bounds, cmp compares the value with a bound
+func (b *bounds) match(cmp func(bound interface{}) int) bool {
+	switch b.op {
+	case ">=":
+		return cmp(b.from) >= 0
+	case "<=":
+		return cmp(b.from) <= 0
+	case ">":
+		return cmp(b.from) > 0
+	case "<":
+		return cmp(b.from) < 0
+	case "..":
+		return (b.from == nil || cmp(b.from) >= 0) && (b.to == nil || cmp(b.to) <= 0)
+	}
+	return cmp(b.from) == 0
+}
@@ -0,0 +1,126 @@
+package query
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/S7evinK/issues-to-go/pkg/archive"
+)
+
+func TestParse(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, time.January, d, 12, 0, 0, 0, time.UTC) }
+	issues := []*archive.Issue{
+		{
+			Number: 1, Title: "Crash on start", State: "open", Author: "foo", CreatedAt: day(1), UpdatedAt: day(5),
+			Milestone: "v2", Labels: []string{"bug"}, URL: "https://github.com/S7evinK/issues-to-go/issues/1", FrontMatter: true,
+			Comments: []archive.Comment{{Author: "bar", Body: "Ping @baz"}, {Author: "foo"}},
+		},
+		{
+			Number: 2, Title: "Docs", State: "closed", Author: "bar", CreatedAt: day(2), ClosedAt: day(3),
+			Labels: []string{"help wanted"}, Assignees: []string{"foo"}, Body: "The app crashes", FrontMatter: true,
+		},
+		{Number: 3, Title: "Feature", State: "open", Author: "baz", CreatedAt: day(10), Comments: []archive.Comment{{}, {}, {}}, FrontMatter: true},
+	}
+
+	tests := []struct {
+		query string
+		want  []int
+	}{
+		{query: "is:open", want: []int{1, 3}},
+		{query: "-is:open is:issue", want: []int{2}},
+		{query: "is:pr", want: nil},
+		{query: "is:open is:locked", want: []int{1, 3}},
+		{query: `label:bug author:foo milestone:"v2"`, want: []int{1}},
+		{query: `label:"help wanted",bug`, want: []int{1, 2}},
+		{query: "-label:bug no:milestone", want: []int{2, 3}},
+		{query: "no:label", want: []int{3}},
+		{query: "assignee:foo", want: []int{2}},
+		{query: "commenter:bar", want: []int{1}},
+		{query: "mentions:baz", want: []int{1}},
+		{query: "involves:foo", want: []int{1, 2}},
+		{query: "repo:S7evinK/issues-to-go", want: []int{1, 2, 3}},
+		{query: "repo:other/repo", want: []int{2, 3}},
+		{query: "updated:>2024-01-03", want: []int{1, 3}},
+		{query: "created:2024-01-02", want: []int{2}},
+		{query: "created:2024-01-02..*", want: []int{2, 3}},
+		{query: "created:<=2024-01-02", want: []int{1, 2}},
+		{query: "closed:2024-01-01..2024-01-05", want: []int{2}},
+		{query: "comments:>=2", want: []int{1, 3}},
+		{query: "comments:0", want: []int{2}},
+		{query: "crash", want: []int{1, 2}},
+		{query: "crash in:title", want: []int{1}},
+		{query: `"crash on" -docs`, want: []int{1}},
+		{query: "sort:comments-desc", want: []int{3, 1, 2}},
+		{query: "sort:created-asc", want: []int{1, 2, 3}},
+		{query: "sort:updated", want: []int{3, 1, 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			q, err := Parse(tt.query)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			var got []int
+			for _, i := range q.Apply(issues) {
+				got = append(got, i.Number)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("Apply() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseText(t *testing.T) {
+	q, err := Parse(`is:open title:crash "out of memory" -wontfix label:bug`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `title:crash "out of memory" -wontfix`; q.Text != want {
+		t.Errorf("Text = %q, want %q", q.Text, want)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, query := range []string{"reactions:>5", "sort:interactions", "sort:created-up", "created:yesterday", "comments:many", "in:readme", "no:project"} {
+		if _, err := Parse(query); err == nil {
+			t.Errorf("Parse(%s) should fail", query)
+		}
+	}
+}
+
+func TestIgnored(t *testing.T) {
+	q, err := Parse("is:locked is:open is:public")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"is:locked", "is:public"}; fmt.Sprint(q.Ignored) != fmt.Sprint(want) {
+		t.Errorf("Ignored = %v, want %v", q.Ignored, want)
+	}
+}
+
+func TestWithoutFrontMatter(t *testing.T) {
+	// labels and assignees are unknown without front matter, the milestone is taken from the symlinks
+	issue := &archive.Issue{Number: 1, State: "open", Milestone: "v2"}
+	tests := []struct {
+		query string
+		want  bool
+	}{
+		{query: "no:label", want: false},
+		{query: "-label:bug", want: false},
+		{query: "no:assignee", want: false},
+		{query: "-no:assignee", want: false},
+		{query: "milestone:v2", want: true},
+		{query: "is:open", want: true},
+	}
+	for _, tt := range tests {
+		q, err := Parse(tt.query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := q.Match(issue); got != tt.want {
+			t.Errorf("Match() of %q = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,312 @@
+package render
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/yuin/goldmark/ast"
+	east "github.com/yuin/goldmark/extension/ast"
+	"github.com/yuin/goldmark/text"
+)
+
+// ANSI escape sequences used for styling
+const (
+	reset         = "\x1b[0m"
+	bold          = "1"
+	dim           = "2"
+	italic        = "3"
+	underline     = "4"
+	strikethrough = "9"
+	yellow        = "33"
+	blue          = "34"
+	magenta       = "35"
+	cyan          = "36"
+)
+
+// Styler applies ANSI styles to text. A Styler without colors returns the text unchanged.
+type Styler bool
+
+// Style wraps s in the given ANSI styles (eg. "1" for bold). Styles of the enclosing text are
+// restored after nested styles, so styled text can be combined.
+func (color Styler) Style(s string, styles ...string) string {
+	if !color || s == "" || len(styles) == 0 {
+		return s
+	}
+	start := "\x1b[" + strings.Join(styles, ";") + "m"
+	return start + strings.Replace(s, reset, reset+start, -1) + reset
+}
+
+// Bold styles s as bold text
+func (color Styler) Bold(s string) string { return color.Style(s, bold) }
+
+// Dim styles s as faint text
+func (color Styler) Dim(s string) string { return color.Style(s, dim) }
+
+// MarkFunc wraps the rendered text of a link to dest, eg. to make the link selectable
+type MarkFunc func(text, dest string) string
+
+// ansiRenderer converts a Markdown AST to styled text
+type ansiRenderer struct {
+	source []byte
+	mark   MarkFunc
+	Styler
+}
+
+// ANSI converts Markdown to text for terminals. Headings, emphasis, code, quotes and links are styled
+// with ANSI escape sequences, if color is set, otherwise the Markdown structure is kept readable as plain text.
+func ANSI(source []byte, color bool) string {
+	return ANSIMarked(source, color, nil)
+}
+
+// ANSIMarked is like ANSI, but passes the rendered text of every link to mark and uses the result instead
+func ANSIMarked(source []byte, color bool, mark MarkFunc) string {
+	source = []byte(stripControl(string(source)))
+	doc := markdown.Parser().Parse(text.NewReader(source))
+	r := &ansiRenderer{source: source, mark: mark, Styler: Styler(color)}
+	return r.blocks(doc, "\n\n")
+}
+
+// blocks renders the child blocks of n, separated by sep
+func (r *ansiRenderer) blocks(n ast.Node, sep string) string {
+	var parts []string
+	for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+		if s := r.block(c); s != "" {
+			parts = append(parts, s)
+		}
+	}
+	return strings.Join(parts, sep)
+}
+
+func (r *ansiRenderer) block(n ast.Node) string {
+	switch n := n.(type) {
+	case *ast.Heading:
+		s := strings.Repeat("#", n.Level) + " " + r.inlines(n)
+		if n.Level == 1 {
+			return r.Style(s, bold, underline, magenta)
+		}
+		return r.Style(s, bold, magenta)
+	case *ast.Paragraph, *ast.TextBlock:
+		return r.inlines(n)
+	case *ast.ThematicBreak:
+		return r.Dim(strings.Repeat("─", 40))
+	case *ast.CodeBlock, *ast.FencedCodeBlock:
+		return r.code(n)
+	case *ast.HTMLBlock:
+		var lines []string
+		for i := 0; i < n.Lines().Len(); i++ {
+			line := n.Lines().At(i)
+			lines = append(lines, strings.TrimRight(string(line.Value(r.source)), "\n"))
+		}
+		if n.HasClosure() {
+			lines = append(lines, strings.TrimRight(string(n.ClosureLine.Value(r.source)), "\n"))
+		}
+		return r.Dim(strings.Join(lines, "\n"))
+	case *ast.Blockquote:
+		return indent(r.blocks(n, "\n\n"), r.Style("│ ", dim, cyan), r.Style("│ ", dim, cyan))
+	case *ast.List:
+		return r.list(n)
+	case *east.Table:
+		return r.table(n)
+	default:
+		return r.blocks(n, "\n\n")
+	}
+}
+
+// code renders a code block, indented by four spaces
+func (r *ansiRenderer) code(n ast.Node) string {
+	var lines []string
+	for i := 0; i < n.Lines().Len(); i++ {
+		line := n.Lines().At(i)
+		lines = append(lines, r.Style(strings.TrimRight(string(line.Value(r.source)), "\n"), yellow))
+	}
+	return indent(strings.Join(lines, "\n"), "    ", "    ")
+}
+
+func (r *ansiRenderer) list(n *ast.List) string {
+	var (
+		items  []string
+		number = n.Start
+	)
+	for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+		marker := "• "
+		if n.IsOrdered() {
+			marker = fmt.Sprintf("%d%c ", number, n.Marker)
+			number++
+		}
+		sep := "\n"
+		if !n.IsTight {
+			sep = "\n\n"
+		}
+		items = append(items, indent(r.blocks(c, sep), r.Style(marker, cyan), strings.Repeat(" ", utf8.RuneCountInString(marker))))
+	}
+	if n.IsTight {
+		return strings.Join(items, "\n")
+	}
+	return strings.Join(items, "\n\n")
+}
+
+// table renders a table with aligned columns
+func (r *ansiRenderer) table(n *east.Table) string {
+	var (
+		rows   [][]string
+		widths []int
+	)
+	for row := n.FirstChild(); row != nil; row = row.NextSibling() {
+		var cells []string
+		for i, cell := 0, row.FirstChild(); cell != nil; i, cell = i+1, cell.NextSibling() {
+			s := r.inlines(cell)
+			if _, ok := row.(*east.TableHeader); ok {
+				s = r.Bold(s)
+			}
+			cells = append(cells, s)
+			if i >= len(widths) {
+				widths = append(widths, 0)
+			}
+			if w := visibleWidth(s); w > widths[i] {
+				widths[i] = w
+			}
+		}
+		rows = append(rows, cells)
+	}
+
+	var lines []string
+	for i, cells := range rows {
+		for j, c := range cells {
+			cells[j] = c + strings.Repeat(" ", widths[j]-visibleWidth(c))
+		}
+		lines = append(lines, strings.TrimRight(strings.Join(cells, r.Dim(" │ ")), " "))
+		if i == 0 {
+			var rule []string
+			for _, w := range widths {
+				rule = append(rule, strings.Repeat("─", w))
+			}
+			lines = append(lines, r.Dim(strings.Join(rule, "─┼─")))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// inlines renders the inline children of n
+func (r *ansiRenderer) inlines(n ast.Node) string {
+	var b strings.Builder
+	for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+		b.WriteString(r.inline(c))
+	}
+	return b.String()
+}
+
+func (r *ansiRenderer) inline(n ast.Node) string {
+	switch n := n.(type) {
+	case *ast.Text:
+		s := string(n.Segment.Value(r.source))
+		if n.SoftLineBreak() || n.HardLineBreak() {
+			s += "\n"
+		}
+		return s
+	case *ast.String:
+		return string(n.Value)
+	case *ast.Emphasis:
+		if !r.Styler {
+			marker := strings.Repeat("*", n.Level)
+			return marker + r.inlines(n) + marker
+		}
+		if n.Level >= 2 {
+			return r.Bold(r.inlines(n))
+		}
+		return r.Style(r.inlines(n), italic)
+	case *east.Strikethrough:
+		if !r.Styler {
+			return "~~" + r.inlines(n) + "~~"
+		}
+		return r.Style(r.inlines(n), strikethrough)
+	case *ast.CodeSpan:
+		var b strings.Builder
+		for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+			if t, ok := c.(*ast.Text); ok {
+				b.Write(t.Segment.Value(r.source))
+			}
+		}
+		if !r.Styler {
+			return "`" + b.String() + "`"
+		}
+		return r.Style(b.String(), yellow)
+	case *ast.Link:
+		return r.link(r.inlines(n), string(n.Destination))
+	case *ast.AutoLink:
+		url := string(n.URL(r.source))
+		return r.link(url, url)
+	case *ast.Image:
+		return r.link("[image: "+r.inlines(n)+"]", string(n.Destination))
+	case *ast.RawHTML:
+		var b strings.Builder
+		for i := 0; i < n.Segments.Len(); i++ {
+			seg := n.Segments.At(i)
+			b.Write(seg.Value(r.source))
+		}
+		return r.Dim(b.String())
+	case *east.TaskCheckBox:
+		if n.IsChecked {
+			return "[x] "
+		}
+		return "[ ] "
+	default:
+		return r.inlines(n)
+	}
+}
+
+// link renders the link text followed by the destination, unless both are the same
+func (r *ansiRenderer) link(label, dest string) string {
+	s := r.Style(label, underline, blue)
+	if label != dest && dest != "" {
+		s += " " + r.Dim("("+dest+")")
+	}
+	if r.mark != nil {
+		return r.mark(s, dest)
+	}
+	return s
+}
+
+// indent prefixes the first line of s with first and all other non-empty lines with rest
+func indent(s, first, rest string) string {
+	lines := strings.Split(s, "\n")
+	for i, l := range lines {
+		switch {
+		case i == 0:
+			lines[i] = first + l
+		case l == "" && strings.TrimSpace(rest) == "":
+			// no trailing whitespace on empty lines
+		default:
+			lines[i] = rest + l
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// stripControl removes control characters except newlines and tabs, so text of issues can't contain
+// escape sequences, which change the terminal or move the cursor
+func stripControl(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '\n' || r == '\t' || !unicode.IsControl(r) {
+			return r
+		}
+		return -1
+	}, s)
+}
+
+// visibleWidth returns the number of characters of s without ANSI escape sequences
+func visibleWidth(s string) int {
+	width, escape := 0, false
+	for _, c := range s {
+		switch {
+		case escape:
+			escape = c != 'm'
+		case c == '\x1b':
+			escape = true
+		default:
+			width++
+		}
+	}
+	return width
+}
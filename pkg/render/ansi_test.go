@@ -0,0 +1,56 @@
+package render
+
+import "testing"
+
+func TestANSI(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		color  bool
+		want   string
+	}{
+		{
+			name:   "plain text keeps markers",
+			source: "# Title\n\nSome *italic*, **bold** and `code`.",
+			want:   "# Title\n\nSome *italic*, **bold** and `code`.",
+		},
+		{
+			name:   "links show their destination",
+			source: "See [#2](../closed/2.md), <https://github.com>",
+			want:   "See #2 (../closed/2.md), https://github.com",
+		},
+		{
+			name:   "quotes and lists",
+			source: "> quoted\n>\n> - item\n\n1. one\n2. two\n   - nested",
+			want:   "│ quoted\n│ \n│ • item\n\n1. one\n2. two\n   • nested",
+		},
+		{
+			name:   "code blocks are indented",
+			source: "```go\nfunc main() {}\n```",
+			want:   "    func main() {}",
+		},
+		{
+			name:   "tables are aligned",
+			source: "| a | long |\n|---|---|\n| 1 | 2 |",
+			want:   "a │ long\n──┼─────\n1 │ 2",
+		},
+		{
+			name:   "nested styles are restored",
+			source: "**bold `code` bold**",
+			color:  true,
+			want:   "\x1b[1mbold \x1b[33mcode\x1b[0m\x1b[1m bold\x1b[0m",
+		},
+		{
+			name:   "control characters are removed",
+			source: "clear\x1b[2J screen\r\n`\x1b]0;title\x07`",
+			want:   "clear[2J screen\n`]0;title`",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ANSI([]byte(tt.source), tt.color); got != tt.want {
+				t.Errorf("ANSI() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+// Package render converts the Markdown of issues to other formats.
+package render
+
+import (
+	"bytes"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/text"
+)
+
+// LinkFunc returns the new destination of a link or image
+type LinkFunc func(dest string, image bool) string
+
+var markdown = goldmark.New(
+	goldmark.WithExtensions(extension.GFM),
+	goldmark.WithRendererOptions(html.WithXHTML(), html.WithHardWraps()),
+)
+
+// HTML converts Markdown to XHTML. Raw HTML is omitted.
+// If rewrite is set, it's used to change the destination of all links and images.
+func HTML(source []byte, rewrite LinkFunc) ([]byte, error) {
+	doc := markdown.Parser().Parse(text.NewReader(source))
+	if rewrite != nil {
+		_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+			if !entering {
+				return ast.WalkContinue, nil
+			}
+			switch n := n.(type) {
+			case *ast.Link:
+				n.Destination = []byte(rewrite(string(n.Destination), false))
+			case *ast.Image:
+				n.Destination = []byte(rewrite(string(n.Destination), true))
+			}
+			return ast.WalkContinue, nil
+		})
+	}
+
+	var buf bytes.Buffer
+	if err := markdown.Renderer().Render(&buf, source, doc); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
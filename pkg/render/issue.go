@@ -0,0 +1,64 @@
+package render
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/S7evinK/issues-to-go/pkg/archive"
+)
+
+// TimeLayout is the layout of times shown in the terminal
+const TimeLayout = "2006-01-02 15:04 MST"
+
+// Issue renders the metadata, body and comments of an issue for terminals.
+// Links in the body and comments are passed to mark, if it isn't nil. Control characters are removed from all texts.
+func Issue(issue *archive.Issue, s Styler, mark MarkFunc) string {
+	var b strings.Builder
+	color := bool(s)
+
+	b.WriteString(s.Style(fmt.Sprintf("#%d %s", issue.Number, stripControl(issue.Title)), bold) + "\n")
+	meta := []string{s.Style(issue.State, StateColor(issue.State), bold)}
+	meta = append(meta, fmt.Sprintf("created by %s on %s", s.Bold(stripControl(issue.Author)), issue.CreatedAt.Format(TimeLayout)))
+	if issue.Closed() && !issue.ClosedAt.IsZero() {
+		meta = append(meta, "closed on "+issue.ClosedAt.Format(TimeLayout))
+	}
+	b.WriteString(strings.Join(meta, " · ") + "\n")
+
+	var details []string
+	if issue.Milestone != "" {
+		details = append(details, "milestone: "+issue.Milestone)
+	}
+	if len(issue.Labels) > 0 {
+		details = append(details, "labels: "+strings.Join(issue.Labels, ", "))
+	}
+	if len(issue.Assignees) > 0 {
+		details = append(details, "assignees: "+strings.Join(issue.Assignees, ", "))
+	}
+	if len(details) > 0 {
+		b.WriteString(s.Dim(stripControl(strings.Join(details, " · "))) + "\n")
+	}
+	if issue.URL != "" {
+		b.WriteString(s.Dim(stripControl(issue.URL)) + "\n")
+	}
+
+	b.WriteString("\n" + ANSIMarked([]byte(issue.Body), color, mark) + "\n")
+
+	for _, c := range issue.Comments {
+		header := fmt.Sprintf("── %s commented on %s ", stripControl(c.Author), c.CreatedAt.Format(TimeLayout))
+		rule := 72 - len([]rune(header))
+		if rule < 3 {
+			rule = 3
+		}
+		b.WriteString("\n" + s.Style(header+strings.Repeat("─", rule), cyan) + "\n\n")
+		b.WriteString(ANSIMarked([]byte(c.Body), color, mark) + "\n")
+	}
+	return b.String()
+}
+
+// StateColor returns the ANSI color of an issue state
+func StateColor(state string) string {
+	if state == "closed" {
+		return "31"
+	}
+	return "32"
+}
@@ -0,0 +1,58 @@
+package render
+
+import (
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/text"
+)
+
+// Range is a part of a Markdown source given by the offsets of its first and after its last byte
+type Range struct {
+	Start, Stop int
+}
+
+// Contains reports whether the offset is part of the range
+func (r Range) Contains(offset int) bool {
+	return r.Start <= offset && offset < r.Stop
+}
+
+// CodeRanges returns the parts of the Markdown source containing code spans, code blocks and HTML,
+// which must be left untouched when the prose is changed
+func CodeRanges(source []byte) []Range {
+	var ranges []Range
+	add := func(segments *text.Segments) {
+		if segments.Len() > 0 {
+			ranges = append(ranges, Range{Start: segments.At(0).Start, Stop: segments.At(segments.Len() - 1).Stop})
+		}
+	}
+	doc := markdown.Parser().Parse(text.NewReader(source))
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch n := n.(type) {
+		case *ast.CodeSpan:
+			segments := text.NewSegments()
+			for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+				if t, ok := c.(*ast.Text); ok {
+					segments.Append(t.Segment)
+				}
+			}
+			add(segments)
+			return ast.WalkSkipChildren, nil
+		case *ast.RawHTML:
+			add(n.Segments)
+			return ast.WalkSkipChildren, nil
+		case *ast.HTMLBlock:
+			add(n.Lines())
+			if n.HasClosure() {
+				ranges = append(ranges, Range{Start: n.ClosureLine.Start, Stop: n.ClosureLine.Stop})
+			}
+			return ast.WalkSkipChildren, nil
+		case *ast.CodeBlock, *ast.FencedCodeBlock:
+			add(n.Lines())
+			return ast.WalkSkipChildren, nil
+		}
+		return ast.WalkContinue, nil
+	})
+	return ranges
+}
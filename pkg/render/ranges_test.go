@@ -0,0 +1,20 @@
+package render
+
+import "testing"
+
+func TestCodeRanges(t *testing.T) {
+	source := "See [#1](1.md) and `[#2](2.md)`.\n\n```\n[#3](3.md)\n```\n\n    [#4](4.md)\n\n<a href=\"5.md\">#5</a>\n\n<div>\n[#6](6.md)\n</div>\n"
+	var got []string
+	for _, r := range CodeRanges([]byte(source)) {
+		got = append(got, source[r.Start:r.Stop])
+	}
+	want := []string{"[#2](2.md)", "[#3](3.md)\n", "[#4](4.md)\n", "<a href=\"5.md\">", "</a>", "<div>\n[#6](6.md)\n</div>\n"}
+	if len(got) != len(want) {
+		t.Fatalf("CodeRanges() = %q, want %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("range %d = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
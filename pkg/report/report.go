@@ -0,0 +1,184 @@
+// Package report creates a human-readable report of the changes made to the archive by a download.
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/S7evinK/issues-to-go/pkg/archive"
+)
+
+// fileLayout is the layout of the timestamp in the name of report files
+const fileLayout = "20060102-150405"
+
+// FileName returns the name of the report file for a download at t
+func FileName(t time.Time) string {
+	return "CHANGES-" + t.Format(fileLayout) + ".md"
+}
+
+// Write writes the report of the changes to a new file in dir and returns its path
+func Write(dir, repo string, changes []archive.Change, now time.Time) (string, error) {
+	var b bytes.Buffer
+	if err := Markdown(&b, dir, repo, changes, now); err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, FileName(now))
+	return path, ioutil.WriteFile(path, b.Bytes(), os.ModePerm)
+}
+
+// issueChanges are the changes of a single issue
+type issueChanges struct {
+	number   int
+	title    string
+	path     string
+	created  *archive.Change
+	comments []archive.Change
+	other    []archive.Change
+}
+
+// Markdown writes the report of the changes as Markdown. Links to the issues are relative to dir.
+// Issues are listed once per section: new issues, new comments, state, title and milestone changes.
+func Markdown(w io.Writer, dir, repo string, changes []archive.Change, now time.Time) error {
+	byIssue := make(map[int]*issueChanges)
+	var numbers []int
+	for i := range changes {
+		c := changes[i]
+		ic, ok := byIssue[c.Number]
+		if !ok {
+			ic = &issueChanges{number: c.Number}
+			byIssue[c.Number] = ic
+			numbers = append(numbers, c.Number)
+		}
+		// the last change contains the current title and path
+		ic.title, ic.path = c.Title, c.Path
+		switch c.Kind {
+		case archive.NewIssue:
+			ic.created = &c
+		case archive.NewComment:
+			ic.comments = append(ic.comments, c)
+		default:
+			ic.other = append(ic.other, c)
+		}
+	}
+	sort.Ints(numbers)
+
+	var b strings.Builder
+	heading := "Changes"
+	if repo != "" {
+		heading += " in " + repo
+	}
+	fmt.Fprintf(&b, "# %s on %s\n", heading, now.Format("2006-01-02 15:04 MST"))
+	if len(numbers) == 0 {
+		b.WriteString("\nNo new or updated issues.\n")
+		_, err := io.WriteString(w, b.String())
+		return err
+	}
+
+	sections := []struct {
+		title string
+		line  func(ic *issueChanges) string
+	}{
+		{"New issues", func(ic *issueChanges) string {
+			if ic.created == nil {
+				return ""
+			}
+			s := " by " + ic.created.Author
+			for _, c := range ic.other {
+				if c.Kind == archive.StateChange {
+					s += ", " + c.To
+				}
+			}
+			if n := len(ic.comments); n > 0 {
+				s += fmt.Sprintf(", %d comment%s", n, plural(n))
+			}
+			return s
+		}},
+		{"New comments", func(ic *issueChanges) string {
+			if ic.created != nil || len(ic.comments) == 0 {
+				return ""
+			}
+			var authors []string
+			seen := make(map[string]bool)
+			for _, c := range ic.comments {
+				if !seen[c.Author] {
+					seen[c.Author] = true
+					authors = append(authors, c.Author)
+				}
+			}
+			n := len(ic.comments)
+			return fmt.Sprintf(": %d comment%s by %s", n, plural(n), strings.Join(authors, ", "))
+		}},
+		{"State changes", transition(archive.StateChange)},
+		{"Title changes", transition(archive.TitleChange)},
+		{"Milestone changes", transition(archive.MilestoneChange)},
+	}
+	for _, s := range sections {
+		var lines []string
+		for _, n := range numbers {
+			ic := byIssue[n]
+			if line := s.line(ic); line != "" {
+				lines = append(lines, "- "+issueLink(dir, ic)+line)
+			}
+		}
+		if len(lines) > 0 {
+			fmt.Fprintf(&b, "\n## %s\n\n%s\n", s.title, strings.Join(lines, "\n"))
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// transition returns the description of changes of the given kind from one value to another, new issues are skipped
+func transition(kind archive.ChangeKind) func(ic *issueChanges) string {
+	return func(ic *issueChanges) string {
+		if ic.created != nil {
+			return ""
+		}
+		var parts []string
+		for _, c := range ic.other {
+			if c.Kind != kind {
+				continue
+			}
+			if kind == archive.TitleChange {
+				parts = append(parts, fmt.Sprintf("%q → %q", c.From, c.To))
+			} else {
+				parts = append(parts, orNone(c.From)+" → "+orNone(c.To))
+			}
+		}
+		if len(parts) == 0 {
+			return ""
+		}
+		return ": " + strings.Join(parts, ", ")
+	}
+}
+
+// issueLink links to the issue file relative to dir
+func issueLink(dir string, ic *issueChanges) string {
+	title := strings.NewReplacer("[", `\[`, "]", `\]`).Replace(ic.title)
+	rel, err := filepath.Rel(dir, ic.path)
+	if err != nil || ic.path == "" {
+		return fmt.Sprintf("#%d %s", ic.number, title)
+	}
+	return fmt.Sprintf("[#%d %s](%s)", ic.number, title, filepath.ToSlash(rel))
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "none"
+	}
+	return s
+}
+
+func plural(n int) string {
+	if n == 1 {
+		return ""
+	}
+	return "s"
+}
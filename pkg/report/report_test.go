@@ -0,0 +1,89 @@
+package report
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/S7evinK/issues-to-go/pkg/archive"
+)
+
+func TestMarkdown(t *testing.T) {
+	now := time.Date(2019, 11, 20, 10, 0, 0, 0, time.UTC)
+	changes := []archive.Change{
+		{Kind: archive.NewIssue, Number: 7, Title: "Crash [linux]", Path: "out/closed/7.md", Author: "alice"},
+		{Kind: archive.StateChange, Number: 7, Title: "Crash [linux]", Path: "out/closed/7.md", From: "open", To: "closed"},
+		{Kind: archive.NewComment, Number: 7, Title: "Crash [linux]", Path: "out/closed/7.md", Author: "bob"},
+		{Kind: archive.NewComment, Number: 3, Title: "Docs", Path: "out/open/3.md", Author: "bob"},
+		{Kind: archive.NewComment, Number: 3, Title: "Docs", Path: "out/open/3.md", Author: "carol"},
+		{Kind: archive.NewComment, Number: 3, Title: "Docs", Path: "out/open/3.md", Author: "bob"},
+		{Kind: archive.StateChange, Number: 2, Title: "Feature", Path: "out/open/2.md", From: "closed", To: "open"},
+		{Kind: archive.TitleChange, Number: 2, Title: "Feature", Path: "out/open/2.md", From: "Feat", To: "Feature"},
+		{Kind: archive.MilestoneChange, Number: 2, Title: "Feature", Path: "out/open/2.md", From: "", To: "v2"},
+	}
+
+	var b strings.Builder
+	if err := Markdown(&b, "out", "o/r", changes, now); err != nil {
+		t.Fatal(err)
+	}
+	want := `# Changes in o/r on 2019-11-20 10:00 UTC
+
+## New issues
+
+- [#7 Crash \[linux\]](closed/7.md) by alice, closed, 1 comment
+
+## New comments
+
+- [#3 Docs](open/3.md): 3 comments by bob, carol
+
+## State changes
+
+- [#2 Feature](open/2.md): closed → open
+
+## Title changes
+
+- [#2 Feature](open/2.md): "Feat" → "Feature"
+
+## Milestone changes
+
+- [#2 Feature](open/2.md): none → v2
+`
+	if b.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", b.String(), want)
+	}
+
+	b.Reset()
+	if err := Markdown(&b, "out", "", nil, now); err != nil {
+		t.Fatal(err)
+	}
+	if want := "# Changes on 2019-11-20 10:00 UTC\n\nNo new or updated issues.\n"; b.String() != want {
+		t.Errorf("got %q, want %q", b.String(), want)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	dir, err := ioutil.TempDir("", "report")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	now := time.Date(2019, 11, 20, 10, 0, 5, 0, time.UTC)
+	path, err := Write(dir, "o/r", []archive.Change{{Kind: archive.NewIssue, Number: 1, Title: "Test", Path: filepath.Join(dir, "open", "1.md"), Author: "alice"}}, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(dir, "CHANGES-20191120-100005.md"); path != want {
+		t.Errorf("got path %s, want %s", path, want)
+	}
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), "- [#1 Test](open/1.md) by alice") {
+		t.Errorf("unexpected report:\n%s", b)
+	}
+}
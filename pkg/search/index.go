@@ -0,0 +1,246 @@
+// Package search implements a persistent full-text index of the archive with BM25 ranking.
+package search
+
+import (
+	"bufio"
+	"encoding/gob"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/S7evinK/issues-to-go/pkg/archive"
+	"github.com/pkg/errors"
+)
+
+// Fields of an issue which can be searched separately
+const (
+	Title    = "title"
+	Body     = "body"
+	Comments = "comments"
+	Author   = "author"
+)
+
+const (
+	// indexFile is the name of the index in the metadata folder of the archive
+	indexFile = "search.idx"
+	// indexVersion is increased whenever the format of the index changes, older indexes are rebuilt
+	indexVersion = 1
+	// commentGap separates the positions of comments, so phrases don't match across comments
+	commentGap = 100
+)
+
+// Fields contains all searchable fields
+var Fields = []string{Title, Body, Comments, Author}
+
+type (
+	// Index is an inverted index of the issues of an archive
+	Index struct {
+		Version int
+		Docs    map[int]*Doc
+		// Postings maps field and term to the positions of the term in each issue
+		Postings map[string]map[string]map[int][]int
+		dir      string
+	}
+
+	// Doc describes an indexed issue file
+	Doc struct {
+		Number  int
+		Title   string
+		State   string
+		Path    string
+		ModTime int64
+		Size    int64
+		// Lengths contains the number of terms of each field
+		Lengths map[string]int
+		// Terms contains the distinct terms of each field, used to remove the issue from the index
+		Terms map[string][]string
+	}
+)
+
+// Open reads the index of the archive in dir. An empty index is returned, if none exists yet.
+func Open(dir string) (*Index, error) {
+	idx := newIndex(dir)
+	f, err := os.Open(filepath.Join(dir, archive.MetaDir, indexFile))
+	if os.IsNotExist(err) {
+		return idx, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	stored := &Index{}
+	if err := gob.NewDecoder(bufio.NewReader(f)).Decode(stored); err != nil || stored.Version != indexVersion {
+		// the index is rebuilt by the next update
+		return idx, nil
+	}
+	stored.dir = dir
+	return stored, nil
+}
+
+func newIndex(dir string) *Index {
+	postings := make(map[string]map[string]map[int][]int)
+	for _, f := range Fields {
+		postings[f] = make(map[string]map[int][]int)
+	}
+	return &Index{Version: indexVersion, Docs: make(map[int]*Doc), Postings: postings, dir: dir}
+}
+
+// Update adds new and changed issue files to the index and removes deleted ones.
+// Only files whose modification time or size changed are read. The index is saved, if it changed.
+func (idx *Index) Update() (updated, removed int, err error) {
+	seen := make(map[int]bool)
+	var errs []string
+	for _, state := range archive.States {
+		files, err := ioutil.ReadDir(filepath.Join(idx.dir, state))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return updated, removed, err
+		}
+		for _, info := range files {
+			if !info.Mode().IsRegular() || filepath.Ext(info.Name()) != ".md" {
+				continue
+			}
+			number, err := strconv.Atoi(strings.TrimSuffix(info.Name(), ".md"))
+			if err != nil {
+				continue
+			}
+			seen[number] = true
+			rel := filepath.Join(state, info.Name())
+			if d, ok := idx.Docs[number]; ok && d.Path == rel && d.ModTime == info.ModTime().UnixNano() && d.Size == info.Size() {
+				continue
+			}
+			issue, err := archive.ReadFile(filepath.Join(idx.dir, rel))
+			if err != nil {
+				errs = append(errs, err.Error())
+				continue
+			}
+			issue.Number = number
+			idx.remove(number)
+			idx.add(issue, rel, info)
+			updated++
+		}
+	}
+	for number := range idx.Docs {
+		if !seen[number] {
+			idx.remove(number)
+			removed++
+		}
+	}
+
+	if updated > 0 || removed > 0 {
+		if err := idx.save(); err != nil {
+			return updated, removed, err
+		}
+	}
+	if len(errs) > 0 {
+		return updated, removed, errors.New("unable to index issues: " + strings.Join(errs, "; "))
+	}
+	return updated, removed, nil
+}
+
+// fieldTokens returns the tokens of each field of an issue, positions of comments are separated by commentGap
+func fieldTokens(issue *archive.Issue) map[string][]token {
+	fields := map[string][]token{
+		Title:  tokenize(issue.Title),
+		Body:   tokenize(issue.Body),
+		Author: tokenize(issue.Author),
+	}
+	var comments []token
+	for i, c := range issue.Comments {
+		if i > 0 {
+			for j := 0; j < commentGap; j++ {
+				comments = append(comments, token{})
+			}
+		}
+		comments = append(comments, tokenize(c.Body)...)
+	}
+	fields[Comments] = comments
+	return fields
+}
+
+func (idx *Index) add(issue *archive.Issue, rel string, info os.FileInfo) {
+	d := &Doc{
+		Number:  issue.Number,
+		Title:   issue.Title,
+		State:   issue.State,
+		Path:    rel,
+		ModTime: info.ModTime().UnixNano(),
+		Size:    info.Size(),
+		Lengths: make(map[string]int),
+		Terms:   make(map[string][]string),
+	}
+	for field, tokens := range fieldTokens(issue) {
+		for pos, t := range tokens {
+			if t.term == "" {
+				continue
+			}
+			d.Lengths[field]++
+			docs := idx.Postings[field][t.term]
+			if docs == nil {
+				docs = make(map[int][]int)
+				idx.Postings[field][t.term] = docs
+			}
+			if docs[issue.Number] == nil {
+				d.Terms[field] = append(d.Terms[field], t.term)
+			}
+			docs[issue.Number] = append(docs[issue.Number], pos)
+		}
+	}
+	idx.Docs[issue.Number] = d
+}
+
+func (idx *Index) remove(number int) {
+	d, ok := idx.Docs[number]
+	if !ok {
+		return
+	}
+	for field, terms := range d.Terms {
+		for _, term := range terms {
+			delete(idx.Postings[field][term], number)
+			if len(idx.Postings[field][term]) == 0 {
+				delete(idx.Postings[field], term)
+			}
+		}
+	}
+	delete(idx.Docs, number)
+}
+
+// save writes the index atomically to the metadata folder of the archive
+func (idx *Index) save() error {
+	dir := filepath.Join(idx.dir, archive.MetaDir)
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(dir, indexFile)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(tmp)
+	if err := gob.NewEncoder(w).Encode(idx); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return errors.Wrap(err, "unable to encode search index")
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(dir, indexFile))
+}
+
+// Len returns the number of indexed issues
+func (idx *Index) Len() int {
+	return len(idx.Docs)
+}
@@ -0,0 +1,81 @@
+package search
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/S7evinK/issues-to-go/pkg/query"
+)
+
+type (
+	// Query is a parsed search query. All clauses must match an issue, except negated ones which must not match.
+	Query struct {
+		Clauses []Clause
+	}
+
+	// Clause is a term or phrase, optionally restricted to a field
+	Clause struct {
+		// Field restricts the clause to a field, an empty field matches the fields given by In
+		Field string
+		// In contains the fields searched by clauses without field, by default title, body and comments
+		In []string
+		// Terms contains a single term or the terms of a phrase
+		Terms  []string
+		Negate bool
+	}
+)
+
+// ParseQuery parses a query consisting of terms and "quoted phrases". Terms and phrases can be restricted
+// to a field by prefixing them with the field name (eg. title:crash or comments:"out of memory")
+// and excluded with a leading minus (eg. -wontfix).
+func ParseQuery(s string) (*Query, error) {
+	q := &Query{}
+	for _, item := range query.Split(s) {
+		c := Clause{}
+		if strings.HasPrefix(item, "-") && len(item) > 1 {
+			c.Negate = true
+			item = item[1:]
+		}
+		if i := strings.Index(item, ":"); i > 0 && isField(item[:i]) {
+			c.Field = strings.ToLower(item[:i])
+			item = item[i+1:]
+		}
+		if strings.HasPrefix(item, `"`) {
+			if len(item) < 2 || !strings.HasSuffix(item, `"`) {
+				return nil, fmt.Errorf("unterminated phrase %s", item)
+			}
+			item = item[1 : len(item)-1]
+		}
+		if c.Terms = terms(item); len(c.Terms) > 0 {
+			q.Clauses = append(q.Clauses, c)
+		}
+	}
+	return q, nil
+}
+
+func isField(s string) bool {
+	for _, f := range Fields {
+		if strings.EqualFold(f, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// fields returns the fields searched by the clause
+func (c *Clause) fields() []string {
+	if c.Field != "" {
+		return []string{c.Field}
+	}
+	if len(c.In) > 0 {
+		return c.In
+	}
+	return []string{Title, Body, Comments}
+}
+
+// Restrict limits the clauses without field to the given fields
+func (q *Query) Restrict(fields []string) {
+	for i := range q.Clauses {
+		q.Clauses[i].In = fields
+	}
+}
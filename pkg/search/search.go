@@ -0,0 +1,146 @@
+package search
+
+import (
+	"math"
+	"path/filepath"
+	"sort"
+)
+
+// BM25 parameters
+const (
+	k1 = 1.2
+	b  = 0.75
+)
+
+// weights defines the importance of matches in each field
+var weights = map[string]float64{
+	Title:    2,
+	Body:     1,
+	Comments: 0.5,
+	Author:   1,
+}
+
+// Result is an issue matching a query
+type Result struct {
+	Number int
+	Title  string
+	State  string
+	// Path is the path to the issue file
+	Path  string
+	Score float64
+}
+
+// Search returns the issues matching all clauses of the query, ranked by BM25
+func (idx *Index) Search(q *Query) []Result {
+	var (
+		scores   map[int]float64
+		excluded = make(map[int]bool)
+		avgLen   = idx.averageLengths()
+	)
+	for _, c := range q.Clauses {
+		matches := idx.match(&c, avgLen)
+		if c.Negate {
+			for n := range matches {
+				excluded[n] = true
+			}
+			continue
+		}
+		if scores == nil {
+			scores = matches
+			continue
+		}
+		for n := range scores {
+			if m, ok := matches[n]; ok {
+				scores[n] += m
+			} else {
+				delete(scores, n)
+			}
+		}
+	}
+
+	var results []Result
+	for n, score := range scores {
+		if excluded[n] {
+			continue
+		}
+		d := idx.Docs[n]
+		results = append(results, Result{Number: n, Title: d.Title, State: d.State, Path: filepath.Join(idx.dir, d.Path), Score: score})
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Number > results[j].Number
+	})
+	return results
+}
+
+// match returns the BM25 score of every issue matching the clause
+func (idx *Index) match(c *Clause, avgLen map[string]float64) map[int]float64 {
+	scores := make(map[int]float64)
+	for _, field := range c.fields() {
+		freqs := idx.frequencies(field, c.Terms)
+		if len(freqs) == 0 {
+			continue
+		}
+		n, df := float64(len(idx.Docs)), float64(len(freqs))
+		idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+		for number, tf := range freqs {
+			length := float64(idx.Docs[number].Lengths[field])
+			norm := 1 - b
+			if avgLen[field] > 0 {
+				norm += b * length / avgLen[field]
+			}
+			scores[number] += weights[field] * idf * float64(tf) * (k1 + 1) / (float64(tf) + k1*norm)
+		}
+	}
+	return scores
+}
+
+// frequencies returns the number of occurrences of the phrase in the field of every issue containing it
+func (idx *Index) frequencies(field string, phrase []string) map[int]int {
+	postings := idx.Postings[field]
+	freqs := make(map[int]int)
+	for number, positions := range postings[phrase[0]] {
+		if len(phrase) == 1 {
+			freqs[number] = len(positions)
+			continue
+		}
+		count := 0
+	positions:
+		for _, start := range positions {
+			for i, term := range phrase[1:] {
+				if !containsInt(postings[term][number], start+i+1) {
+					continue positions
+				}
+			}
+			count++
+		}
+		if count > 0 {
+			freqs[number] = count
+		}
+	}
+	return freqs
+}
+
+// containsInt reports whether the sorted list contains i
+func containsInt(list []int, i int) bool {
+	j := sort.SearchInts(list, i)
+	return j < len(list) && list[j] == i
+}
+
+func (idx *Index) averageLengths() map[string]float64 {
+	avg := make(map[string]float64)
+	if len(idx.Docs) == 0 {
+		return avg
+	}
+	for _, d := range idx.Docs {
+		for field, l := range d.Lengths {
+			avg[field] += float64(l)
+		}
+	}
+	for field := range avg {
+		avg[field] /= float64(len(idx.Docs))
+	}
+	return avg
+}
@@ -0,0 +1,129 @@
+package search
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/S7evinK/issues-to-go/pkg/archive"
+)
+
+func writeIssue(t *testing.T, dir, state string, number int, title, author, body string, comments ...string) {
+	t.Helper()
+	content := fmt.Sprintf("%s\n---\n\nCreated by %s on 2019-11-15 13:05:33 +0100 CET:\n\n%s\n\n---\n", title, author, body)
+	for _, c := range comments {
+		content += fmt.Sprintf("\nghost commented on 2019-11-15 13:07:38 +0100 CET:\n\n%s\n\n---\n", c)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, state), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, state, fmt.Sprintf("%d.md", number)), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSearch(t *testing.T) {
+	dir, err := ioutil.TempDir("", "issues-to-go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	writeIssue(t, dir, "open", 1, "Crash on start", "alice", "The app crashes with out of memory.", "Still crashing")
+	writeIssue(t, dir, "open", 2, "Memory usage", "bob", "Uses a lot of memory, but no crash.")
+	writeIssue(t, dir, "closed", 3, "Docs", "alice", "Typo", "memory of out", "out of memory here too")
+
+	idx, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated, removed, err := idx.Update(); err != nil || updated != 3 || removed != 0 {
+		t.Fatalf("Update() = %d, %d, %v, want 3 updated", updated, removed, err)
+	}
+
+	tests := []struct {
+		query string
+		want  []int
+	}{
+		{query: "memory", want: []int{2, 3, 1}},
+		{query: `"out of memory"`, want: []int{1, 3}},
+		{query: `body:"out of memory"`, want: []int{1}},
+		{query: "title:crash", want: []int{1}},
+		{query: "author:alice memory", want: []int{3, 1}},
+		{query: "memory -crash", want: []int{3}},
+		{query: "nothing", want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			q, err := ParseQuery(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []int
+			for _, r := range idx.Search(q) {
+				got = append(got, r.Number)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("Search(%s) = %v, want %v", tt.query, got, tt.want)
+			}
+		})
+	}
+
+	// only changed files are indexed again
+	writeIssue(t, dir, "closed", 2, "Memory usage", "bob", "Fixed")
+	if err := os.Remove(filepath.Join(dir, "open", "2.md")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(dir, "closed", "3.md")); err != nil {
+		t.Fatal(err)
+	}
+	idx, err = Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated, removed, err := idx.Update(); err != nil || updated != 1 || removed != 1 {
+		t.Fatalf("Update() = %d, %d, %v, want 1 updated and 1 removed", updated, removed, err)
+	}
+	q, _ := ParseQuery("memory")
+	if got := idx.Search(q); len(got) != 2 || got[0].Number != 2 || got[0].State != "closed" {
+		t.Errorf("Search(memory) after update = %+v", got)
+	}
+}
+
+func TestMakeSnippet(t *testing.T) {
+	issue := &archive.Issue{
+		Title: "Crash",
+		Body:  strings.Repeat("lorem ipsum ", 30) + "it ran out\nof memory " + strings.Repeat("dolor sit ", 30),
+		Comments: []archive.Comment{
+			{Author: "alice", Body: "Same here"},
+		},
+	}
+	highlight := func(s string) string { return "[" + s + "]" }
+
+	tests := []struct {
+		query  string
+		want   Snippet
+		wantOK bool
+	}{
+		{
+			query:  `"out of memory"`,
+			want:   Snippet{Field: Body, Text: "…ipsum lorem ipsum lorem ipsum lorem ipsum it ran [out] [of] [memory] dolor sit dolor sit dolor sit dolor sit dolor sit dolor sit dolor sit dolor sit dolor sit dolor…"},
+			wantOK: true,
+		},
+		{query: "same", want: Snippet{Field: Comments, Author: "alice", Text: "[Same] here"}, wantOK: true},
+		{query: "title:crash", want: Snippet{Field: Title, Text: "[Crash]"}, wantOK: true},
+		{query: "missing"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			q, _ := ParseQuery(tt.query)
+			got, ok := MakeSnippet(issue, q, 160, highlight)
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("MakeSnippet() = %+v, %v, want %+v", got, ok, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package search
+
+import (
+	"strings"
+
+	"github.com/S7evinK/issues-to-go/pkg/archive"
+)
+
+// Snippet is an excerpt of an issue containing matches of a query
+type Snippet struct {
+	// Field is the field the excerpt is taken from
+	Field string
+	// Author is the author of the comment, if the excerpt is taken from a comment
+	Author string
+	Text   string
+}
+
+// MakeSnippet returns an excerpt of about width characters around the first match of the query in the
+// body, comments or title of the issue. Matched terms are passed through highlight.
+func MakeSnippet(issue *archive.Issue, q *Query, width int, highlight func(string) string) (Snippet, bool) {
+	type candidate struct {
+		field, author, text string
+	}
+	candidates := []candidate{{field: Body, text: issue.Body}}
+	for _, c := range issue.Comments {
+		candidates = append(candidates, candidate{field: Comments, author: c.Author, text: c.Body})
+	}
+	candidates = append(candidates, candidate{field: Title, text: issue.Title})
+
+	for _, c := range candidates {
+		tokens := tokenize(c.text)
+		start, highlighted := q.matches(c.field, tokens)
+		if start < 0 {
+			continue
+		}
+		return Snippet{Field: c.field, Author: c.author, Text: excerpt(c.text, tokens, start, highlighted, width, highlight)}, true
+	}
+	return Snippet{}, false
+}
+
+// matches returns the index of the first token matching a clause of the query in field
+// and the indexes of all tokens belonging to matches
+func (q *Query) matches(field string, tokens []token) (int, map[int]bool) {
+	first, highlighted := -1, make(map[int]bool)
+	for _, c := range q.Clauses {
+		if c.Negate || !containsString(c.fields(), field) {
+			continue
+		}
+	tokens:
+		for i := range tokens {
+			if i+len(c.Terms) > len(tokens) {
+				break
+			}
+			for j, term := range c.Terms {
+				if tokens[i+j].term != term {
+					continue tokens
+				}
+			}
+			for j := range c.Terms {
+				highlighted[i+j] = true
+			}
+			if first < 0 || i < first {
+				first = i
+			}
+		}
+	}
+	return first, highlighted
+}
+
+// excerpt cuts about width characters around the token at index start out of text and highlights tokens
+func excerpt(text string, tokens []token, start int, highlighted map[int]bool, width int, highlight func(string) string) string {
+	from := tokens[start].start - width/3
+	if from <= 0 {
+		from = 0
+	} else if i := strings.IndexAny(text[from:], " \n\t"); i >= 0 && from+i < tokens[start].start {
+		from += i + 1
+	}
+	to := from + width
+	if to >= len(text) {
+		to = len(text)
+	} else if i := strings.LastIndexAny(text[from:to], " \n\t"); i > 0 && from+i > tokens[start].end {
+		to = from + i
+	} else {
+		// don't cut the text in the middle of a multibyte character
+		for to < len(text) && text[to]&0xC0 == 0x80 {
+			to++
+		}
+	}
+
+	var b strings.Builder
+	if from > 0 {
+		b.WriteString("…")
+	}
+	pos := from
+	for i, t := range tokens {
+		if t.start < from || t.end > to || !highlighted[i] {
+			continue
+		}
+		b.WriteString(text[pos:t.start])
+		b.WriteString(highlight(text[t.start:t.end]))
+		pos = t.end
+	}
+	b.WriteString(text[pos:to])
+	if to < len(text) {
+		b.WriteString("…")
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+func containsString(list []string, s string) bool {
+	for _, l := range list {
+		if l == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,43 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+)
+
+// token is a term of a text together with its byte offsets
+type token struct {
+	term       string
+	start, end int
+}
+
+// tokenize splits s into lower case terms consisting of letters and digits
+func tokenize(s string) []token {
+	var (
+		tokens []token
+		start  = -1
+	)
+	for i, r := range s {
+		isTerm := unicode.IsLetter(r) || unicode.IsDigit(r)
+		switch {
+		case isTerm && start < 0:
+			start = i
+		case !isTerm && start >= 0:
+			tokens = append(tokens, token{term: strings.ToLower(s[start:i]), start: start, end: i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		tokens = append(tokens, token{term: strings.ToLower(s[start:]), start: start, end: len(s)})
+	}
+	return tokens
+}
+
+// terms returns the terms of s without offsets
+func terms(s string) []string {
+	var t []string
+	for _, tok := range tokenize(s) {
+		t = append(t, tok.term)
+	}
+	return t
+}
@@ -0,0 +1,383 @@
+// Package server serves the archive as HTML pages over HTTP.
+//
+// The URLs mirror the paths of the archive (eg. /open/12.md), so relative links between issues keep working.
+package server
+
+import (
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/S7evinK/issues-to-go/pkg/archive"
+	"github.com/S7evinK/issues-to-go/pkg/query"
+	"github.com/S7evinK/issues-to-go/pkg/render"
+	"github.com/S7evinK/issues-to-go/pkg/search"
+)
+
+// regexDest matches the destinations of Markdown links and images and of HTML src attributes
+var regexDest = regexp.MustCompile(`\]\(\s*<?([^)\s>]+)|\ssrc="([^"]+)"`)
+
+// Server renders the issues of an archive on the fly
+type Server struct {
+	dir   string
+	title string
+
+	mu     sync.Mutex
+	index  *search.Index
+	issues []*archive.Issue
+	// assets are the URL paths of the files linked from the issues
+	assets map[string]bool
+}
+
+// New creates a server for the archive in dir
+func New(dir, title string) (*Server, error) {
+	idx, err := search.Open(dir)
+	if err != nil {
+		return nil, err
+	}
+	s := &Server{dir: dir, title: title, index: idx}
+	if err := s.reload(true); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// reload updates the search index and reads the issues again, if files changed since the last reload
+func (s *Server) reload(force bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	updated, removed, err := s.index.Update()
+	if err != nil {
+		log.Println("Unable to update search index:", err)
+	}
+	if !force && updated == 0 && removed == 0 {
+		return nil
+	}
+	issues, err := archive.Load(s.dir)
+	if err != nil {
+		log.Println("Unable to read all issues:", err)
+	}
+	s.issues = issues
+	s.assets = assets(s.dir, issues)
+	return nil
+}
+
+// assets returns the URL paths of the local files linked from the issues. Only these files are
+// served besides the issues, other files in the output folder may be private.
+func assets(dir string, issues []*archive.Issue) map[string]bool {
+	found := make(map[string]bool)
+	for _, i := range issues {
+		texts := []string{i.Body}
+		for _, c := range i.Comments {
+			texts = append(texts, c.Body)
+		}
+		for _, text := range texts {
+			for _, m := range regexDest.FindAllStringSubmatch(text, -1) {
+				if p, ok := assetPath(dir, i.Path, m[1]+m[2]); ok {
+					found[p] = true
+				}
+			}
+		}
+	}
+	return found
+}
+
+// assetPath returns the URL path of the file a link destination in the issue file at from points to.
+// Returns false for links to other sites, to issues and to files outside of the archive in dir.
+func assetPath(dir, from, dest string) (string, bool) {
+	u, err := url.Parse(dest)
+	if err != nil || u.Scheme != "" || u.Host != "" || u.Path == "" {
+		return "", false
+	}
+	p := path.Clean(u.Path)
+	if !strings.HasPrefix(p, "/") {
+		rel, err := filepath.Rel(dir, filepath.Join(filepath.Dir(from), filepath.FromSlash(p)))
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return "", false
+		}
+		p = "/" + filepath.ToSlash(rel)
+	}
+	return p, p != "/" && !strings.HasSuffix(p, ".md")
+}
+
+// isAsset reports whether the URL path is a file linked from the issues
+func (s *Server) isAsset(p string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.assets[p]
+}
+
+// snapshot returns the current issues and search index
+func (s *Server) snapshot() ([]*archive.Issue, *search.Index) {
+	if err := s.reload(false); err != nil {
+		log.Println("Unable to reload issues:", err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.issues, s.index
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	p := path.Clean("/" + r.URL.Path)
+	parts := strings.Split(strings.Trim(p, "/"), "/")
+
+	switch {
+	case p == "/":
+		s.list(w, r, "All issues", r.URL.Query().Get("q"))
+	case p == "/search":
+		s.search(w, r)
+	case p == "/milestones":
+		s.milestones(w, r)
+	case len(parts) == 1 && isState(parts[0]):
+		s.list(w, r, strings.ToUpper(parts[0][:1])+parts[0][1:]+" issues", "is:"+parts[0]+" "+r.URL.Query().Get("q"))
+	case len(parts) == 2 && isState(parts[0]) && strings.HasSuffix(parts[1], ".md"):
+		s.issue(w, r, parts[0], strings.TrimSuffix(parts[1], ".md"))
+	case len(parts) == 4 && parts[0] == "milestones" && isState(parts[2]):
+		// milestone folders contain symlinks, links are relative to the linked files
+		http.Redirect(w, r, "/"+parts[2]+"/"+parts[3], http.StatusMovedPermanently)
+	case parts[0] == archive.MetaDir || strings.HasSuffix(p, ".md"):
+		http.NotFound(w, r)
+	default:
+		// images and other files linked from issues, folders aren't listed
+		file := filepath.Join(s.dir, filepath.FromSlash(p))
+		if info, err := os.Stat(file); err != nil || info.IsDir() || !s.isAsset(p) {
+			http.NotFound(w, r)
+			return
+		}
+		http.ServeFile(w, r, file)
+	}
+}
+
+func isState(s string) bool {
+	for _, state := range archive.States {
+		if s == state {
+			return true
+		}
+	}
+	return false
+}
+
+// list renders the issues matching a Github search query
+func (s *Server) list(w http.ResponseWriter, r *http.Request, heading, q string) {
+	parsed, err := query.Parse(q)
+	if err != nil {
+		s.error(w, http.StatusBadRequest, err)
+		return
+	}
+	issues, _ := s.snapshot()
+	selected := parsed.Apply(issues)
+	if parsed.SortKey == "" {
+		_ = archive.Sort(selected, "number", true)
+	}
+	s.render(w, listTemplate, map[string]interface{}{
+		"Heading": heading,
+		"Query":   r.URL.Query().Get("q"),
+		"Issues":  entries(selected),
+	})
+}
+
+// search renders the results of a full-text search, queries without text are shown as list
+func (s *Server) search(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query().Get("q")
+	gq, err := query.Parse(q)
+	if err != nil {
+		s.error(w, http.StatusBadRequest, err)
+		return
+	}
+	sq, err := search.ParseQuery(gq.Text)
+	if err != nil {
+		s.error(w, http.StatusBadRequest, err)
+		return
+	}
+	if len(sq.Clauses) == 0 {
+		http.Redirect(w, r, "/?q="+url.QueryEscape(q), http.StatusFound)
+		return
+	}
+	if len(gq.In) > 0 {
+		sq.Restrict(gq.In)
+	}
+
+	_, idx := s.snapshot()
+	var found []*archive.Issue
+	for _, res := range idx.Search(sq) {
+		if issue, err := archive.ReadFile(res.Path); err == nil {
+			found = append(found, issue)
+		}
+	}
+	if err := archive.SetMilestones(s.dir, found); err != nil {
+		s.error(w, http.StatusInternalServerError, err)
+		return
+	}
+	var issues []*archive.Issue
+	for _, issue := range found {
+		if gq.MatchQualifiers(issue) {
+			issues = append(issues, issue)
+		}
+	}
+	if gq.SortKey != "" {
+		_ = archive.Sort(issues, gq.SortKey, gq.SortDesc)
+	}
+
+	results := entries(issues)
+	for i := range results {
+		snippet, ok := search.MakeSnippet(results[i].Issue, sq, 200, func(t string) string { return "\x02" + t + "\x03" })
+		if !ok {
+			continue
+		}
+		// the snippet is escaped first, the markers are replaced by the highlighting afterwards
+		html := template.HTMLEscapeString(snippet.Text)
+		results[i].Snippet = template.HTML(strings.NewReplacer("\x02", "<mark>", "\x03", "</mark>").Replace(html))
+	}
+	s.render(w, listTemplate, map[string]interface{}{
+		"Heading": fmt.Sprintf("Search results for %q", q),
+		"Query":   q,
+		"Issues":  results,
+	})
+}
+
+// milestones renders the milestones with the number of open and closed issues
+func (s *Server) milestones(w http.ResponseWriter, r *http.Request) {
+	issues, _ := s.snapshot()
+	type milestone struct {
+		Name         string
+		Query        string
+		Open, Closed int
+	}
+	counts := make(map[string]*milestone)
+	for _, i := range issues {
+		if i.Milestone == "" {
+			continue
+		}
+		m, ok := counts[i.Milestone]
+		if !ok {
+			m = &milestone{Name: i.Milestone, Query: fmt.Sprintf("milestone:%q", i.Milestone)}
+			counts[i.Milestone] = m
+		}
+		if i.Closed() {
+			m.Closed++
+		} else {
+			m.Open++
+		}
+	}
+	var list []*milestone
+	for _, m := range counts {
+		list = append(list, m)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	s.render(w, milestonesTemplate, map[string]interface{}{"Milestones": list})
+}
+
+// issue renders a single issue with its comments
+func (s *Server) issue(w http.ResponseWriter, r *http.Request, state, number string) {
+	n, err := strconv.Atoi(number)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	file := filepath.Join(s.dir, state, strconv.Itoa(n)+".md")
+	issue, err := archive.ReadIssue(s.dir, file)
+	if os.IsNotExist(err) {
+		// the issue may have been closed or reopened since the link was created
+		if moved, err := archive.Find(s.dir, n); err == nil {
+			http.Redirect(w, r, href(moved), http.StatusFound)
+			return
+		}
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		s.error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	body, err := render.HTML([]byte(issue.Body), rewriteLink(issue.URL))
+	if err != nil {
+		s.error(w, http.StatusInternalServerError, err)
+		return
+	}
+	type comment struct {
+		Anchor string
+		archive.Comment
+		HTML template.HTML
+	}
+	var comments []comment
+	for i, c := range issue.Comments {
+		html, err := render.HTML([]byte(c.Body), rewriteLink(issue.URL))
+		if err != nil {
+			s.error(w, http.StatusInternalServerError, err)
+			return
+		}
+		anchor := fmt.Sprintf("comment-%d", i+1)
+		if c.ID > 0 {
+			anchor = fmt.Sprintf("issuecomment-%d", c.ID)
+		}
+		comments = append(comments, comment{Anchor: anchor, Comment: c, HTML: template.HTML(html)})
+	}
+	s.render(w, issueTemplate, map[string]interface{}{
+		"Issue":    issue,
+		"Body":     template.HTML(body),
+		"Comments": comments,
+	})
+}
+
+// rewriteLink returns a function linking to issues of other archives on the forge of the issue at issueURL,
+// since only one archive is served
+func rewriteLink(issueURL string) func(dest string, image bool) string {
+	return func(dest string, image bool) string {
+		if link, ok := archive.ParseLink(dest); ok && link.Owner != "" {
+			return link.URL(issueURL)
+		}
+		return dest
+	}
+}
+
+func (s *Server) render(w http.ResponseWriter, t *template.Template, data map[string]interface{}) {
+	data["Title"] = s.title
+	if _, ok := data["Query"]; !ok {
+		data["Query"] = ""
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := t.Execute(w, data); err != nil {
+		log.Println("Unable to render page:", err)
+	}
+}
+
+func (s *Server) error(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	_ = errorTemplate.Execute(w, map[string]interface{}{"Title": s.title, "Error": err.Error()})
+}
+
+// entry is an issue shown in a list
+type entry struct {
+	*archive.Issue
+	Href    string
+	Snippet template.HTML
+}
+
+func newEntry(i *archive.Issue) entry {
+	return entry{Issue: i, Href: href(i)}
+}
+
+// href returns the URL of an issue, which is the path of its file in the archive
+func href(i *archive.Issue) string {
+	return "/" + filepath.Base(filepath.Dir(i.Path)) + "/" + strconv.Itoa(i.Number) + ".md"
+}
+
+func entries(issues []*archive.Issue) []entry {
+	list := make([]entry, 0, len(issues))
+	for _, i := range issues {
+		list = append(list, newEntry(i))
+	}
+	return list
+}
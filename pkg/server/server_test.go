@@ -0,0 +1,88 @@
+package server
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestServer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "issues-to-go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"open/1.md": "---\nnumber: 1\ntitle: Crash on start\nstate: open\nauthor: alice\ncreated: 2019-11-15T13:05:33+01:00\n" +
+			"updated: 2019-11-15T13:05:33+01:00\nmilestone: v1\nlabels:\n- bug\n---\n\n" +
+			"Crash on start\n---\n\nCreated by alice on 2019-11-15 13:05:33 +0100 CET:\n\nSee [#2](../closed/2.md) <script>x</script> ![shot](shot.png)\n\n---\n",
+		"closed/2.md": "Docs\n---\n\nCreated by bob on 2019-11-15 13:05:33 +0100 CET:\n\nThe app crashes\n\n---\n" +
+			"\n<a id=\"issuecomment-5\"></a>alice commented on 2019-11-15 13:07:38 +0100 CET:\n\nFixed\n\n---\nClosed on 2019-11-16 09:12:01 +0100 CET",
+		"open/shot.png": "png",
+		"notes.txt":     "private",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	srv, err := New(dir, "Test archive")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path     string
+		status   int
+		contains []string
+		excludes []string
+	}{
+		{path: "/", status: http.StatusOK, contains: []string{`href="/open/1.md"`, `href="/closed/2.md"`, "2 issue(s)"}},
+		{path: "/closed", status: http.StatusOK, contains: []string{"Docs"}, excludes: []string{"Crash on start"}},
+		{path: "/?q=label:bug+is:open", status: http.StatusOK, contains: []string{"Crash on start", "1 issue(s)"}},
+		{path: "/milestones", status: http.StatusOK, contains: []string{"v1"}},
+		{path: "/open/1.md", status: http.StatusOK, contains: []string{`<a href="../closed/2.md">#2</a>`}, excludes: []string{"<script>"}},
+		{path: "/closed/2.md", status: http.StatusOK, contains: []string{`id="issuecomment-5"`, "Closed on 2019-11-16"}},
+		{path: "/closed/1.md", status: http.StatusFound},
+		{path: "/milestones/v1/open/1.md", status: http.StatusMovedPermanently},
+		{path: "/search?q=crash", status: http.StatusOK, contains: []string{"<mark>Crash</mark> on start", "1 issue(s)"}},
+		{path: "/search?q=app+-is:open", status: http.StatusOK, contains: []string{"The <mark>app</mark> crashes", "1 issue(s)"}},
+		{path: "/search?q=is:open", status: http.StatusFound},
+		{path: "/?q=reactions:>1", status: http.StatusBadRequest},
+		{path: "/open/shot.png", status: http.StatusOK},
+		{path: "/notes.txt", status: http.StatusNotFound},
+		{path: "/.meta/search.idx", status: http.StatusNotFound},
+		{path: "/open/3.md", status: http.StatusNotFound},
+		{path: "/../etc/passwd", status: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			srv.ServeHTTP(w, httptest.NewRequest("GET", tt.path, nil))
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d", w.Code, tt.status)
+			}
+			body := w.Body.String()
+			for _, s := range tt.contains {
+				if !strings.Contains(body, s) {
+					t.Errorf("body doesn't contain %q:\n%s", s, body)
+				}
+			}
+			for _, s := range tt.excludes {
+				if strings.Contains(body, s) {
+					t.Errorf("body contains %q", s)
+				}
+			}
+		})
+	}
+}
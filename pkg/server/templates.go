@@ -0,0 +1,114 @@
+package server
+
+import "html/template"
+
+const layout = `{{define "header"}}<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{block "title" .}}{{.Title}}{{end}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; line-height: 1.5; margin: 0; color: #24292e; }
+header { background: #24292e; padding: 0.6em 1em; display: flex; flex-wrap: wrap; gap: 1em; align-items: center; }
+header a { color: #fff; text-decoration: none; font-weight: 600; }
+header form { margin-left: auto; }
+header input { width: 22em; max-width: 60vw; padding: 0.3em; }
+main { max-width: 60em; margin: 1em auto; padding: 0 1em; }
+table { border-collapse: collapse; width: 100%; }
+td, th { text-align: left; padding: 0.4em; border-bottom: 1px solid #e1e4e8; vertical-align: top; }
+.state { border-radius: 1em; padding: 0.1em 0.6em; color: #fff; font-size: 0.85em; }
+.open { background: #28a745; } .closed { background: #cb2431; }
+.meta, .snippet { color: #586069; font-size: 0.9em; }
+.label { background: #e1e4e8; border-radius: 0.3em; padding: 0 0.4em; font-size: 0.85em; }
+.comment { border: 1px solid #e1e4e8; border-radius: 0.3em; margin: 1em 0; }
+.comment > .meta { background: #f6f8fa; border-bottom: 1px solid #e1e4e8; padding: 0.4em 0.8em; }
+.comment > .body { padding: 0 0.8em; }
+pre { background: #f6f8fa; padding: 0.8em; overflow: auto; }
+blockquote { color: #6a737d; border-left: 0.25em solid #dfe2e5; margin-left: 0; padding-left: 1em; }
+img { max-width: 100%; }
+mark { background: #fff5b1; }
+</style>
+</head>
+<body>
+<header>
+  <a href="/">{{.Title}}</a>
+  <a href="/open">Open</a>
+  <a href="/closed">Closed</a>
+  <a href="/milestones">Milestones</a>
+  <form action="/search"><input type="search" name="q" value="{{.Query}}" placeholder="Search, eg. crash is:open label:bug"></form>
+</header>
+<main>
+{{end}}
+{{define "footer"}}</main>
+</body>
+</html>
+{{end}}`
+
+func page(content string) *template.Template {
+	return template.Must(template.Must(template.New("layout").Parse(layout)).New("page").Parse(content))
+}
+
+var (
+	listTemplate = page(`{{template "header" .}}
+<h1>{{.Heading}}</h1>
+<p class="meta">{{len .Issues}} issue(s)</p>
+<table>
+{{- range .Issues}}
+<tr>
+  <td>#{{.Number}}</td>
+  <td><span class="state {{.State}}">{{.State}}</span></td>
+  <td><a href="{{.Href}}">{{.Title}}</a>
+    {{- range .Labels}} <span class="label">{{.}}</span>{{end}}
+    <div class="meta">by {{.Author}} on {{.CreatedAt.Format "2006-01-02"}}{{if .Milestone}} · {{.Milestone}}{{end}} · {{len .Comments}} comment(s)</div>
+    {{- if .Snippet}}<div class="snippet">{{.Snippet}}</div>{{end}}
+  </td>
+</tr>
+{{- end}}
+</table>
+{{template "footer" .}}`)
+
+	milestonesTemplate = page(`{{template "header" .}}
+<h1>Milestones</h1>
+<table>
+<tr><th>Milestone</th><th>Open</th><th>Closed</th></tr>
+{{- range .Milestones}}
+<tr>
+  <td><a href="/?q={{.Query}}">{{.Name}}</a></td>
+  <td><a href="/?q=is:open+{{.Query}}">{{.Open}}</a></td>
+  <td><a href="/?q=is:closed+{{.Query}}">{{.Closed}}</a></td>
+</tr>
+{{- else}}
+<tr><td colspan="3">No milestones</td></tr>
+{{- end}}
+</table>
+{{template "footer" .}}`)
+
+	issueTemplate = page(`{{template "header" .}}
+{{with .Issue}}
+<h1>{{.Title}} #{{.Number}}</h1>
+<p class="meta"><span class="state {{.State}}">{{.State}}</span>
+  created by {{.Author}} on {{.CreatedAt.Format "2006-01-02 15:04 MST"}}
+  {{- if .Milestone}} · milestone {{.Milestone}}{{end}}
+  {{- range .Labels}} <span class="label">{{.}}</span>{{end}}
+  {{- if .Assignees}} · assigned to {{range $i, $a := .Assignees}}{{if $i}}, {{end}}{{$a}}{{end}}{{end}}
+  {{- if .URL}} · <a href="{{.URL}}">Github</a>{{end}}</p>
+{{end}}
+<div class="comment">
+  <div class="meta">{{.Issue.Author}} opened on {{.Issue.CreatedAt.Format "2006-01-02 15:04 MST"}}</div>
+  <div class="body">{{.Body}}</div>
+</div>
+{{- range .Comments}}
+<div class="comment" id="{{.Anchor}}">
+  <div class="meta"><a href="#{{.Anchor}}">{{.Author}} commented on {{.CreatedAt.Format "2006-01-02 15:04 MST"}}</a></div>
+  <div class="body">{{.HTML}}</div>
+</div>
+{{- end}}
+{{if and .Issue.Closed (not .Issue.ClosedAt.IsZero)}}<p class="meta">Closed on {{.Issue.ClosedAt.Format "2006-01-02 15:04 MST"}}</p>{{end}}
+{{template "footer" .}}`)
+
+	errorTemplate = page(`{{template "header" .}}
+<h1>Error</h1>
+<p>{{.Error}}</p>
+{{template "footer" .}}`)
+)
@@ -0,0 +1,106 @@
+// Package spreadsheet writes the metadata of issues as CSV file.
+package spreadsheet
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/S7evinK/issues-to-go/pkg/archive"
+)
+
+// column returns the value of a column for an issue
+type column func(issue *archive.Issue) string
+
+var columns = map[string]column{
+	"number":        func(i *archive.Issue) string { return strconv.Itoa(i.Number) },
+	"title":         func(i *archive.Issue) string { return i.Title },
+	"state":         func(i *archive.Issue) string { return i.State },
+	"author":        func(i *archive.Issue) string { return i.Author },
+	"created":       func(i *archive.Issue) string { return formatTime(i.CreatedAt) },
+	"closed":        func(i *archive.Issue) string { return formatTime(i.ClosedAt) },
+	"updated":       func(i *archive.Issue) string { return formatTime(i.UpdatedAt) },
+	"milestone":     func(i *archive.Issue) string { return i.Milestone },
+	"labels":        func(i *archive.Issue) string { return strings.Join(i.Labels, ", ") },
+	"assignees":     func(i *archive.Issue) string { return strings.Join(i.Assignees, ", ") },
+	"comments":      func(i *archive.Issue) string { return strconv.Itoa(len(i.Comments)) },
+	"last_activity": func(i *archive.Issue) string { return formatTime(i.LastActivity()) },
+	"url":           func(i *archive.Issue) string { return i.URL },
+}
+
+// DefaultColumns are the columns written if none are configured
+var DefaultColumns = []string{"number", "title", "state", "author", "created", "closed", "milestone", "labels", "comments", "last_activity"}
+
+// Columns returns the names of all available columns
+func Columns() []string {
+	return []string{"number", "title", "state", "author", "created", "closed", "updated", "milestone", "labels", "assignees", "comments", "last_activity", "url"}
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+// Write writes a header and one row per issue with the given columns to w.
+// Column names are case insensitive, the header contains them in lower case.
+func Write(w io.Writer, issues []*archive.Issue, names []string) error {
+	if len(names) == 0 {
+		names = DefaultColumns
+	}
+	header := make([]string, len(names))
+	cols := make([]column, len(names))
+	for i, name := range names {
+		header[i] = strings.ToLower(strings.TrimSpace(name))
+		col, ok := columns[header[i]]
+		if !ok {
+			return fmt.Errorf("unknown column %q, available columns: %s", name, strings.Join(Columns(), ", "))
+		}
+		cols[i] = col
+	}
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	record := make([]string, len(cols))
+	for _, issue := range issues {
+		for i, col := range cols {
+			record[i] = col(issue)
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteFile replaces the file at path with a CSV file of the issues.
+// The file is written to a temporary file first, so readers never see a partial file.
+func WriteFile(path string, issues []*archive.Issue, names []string) error {
+	f, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if err := Write(f, issues, names); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(f.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
@@ -0,0 +1,62 @@
+package spreadsheet
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/S7evinK/issues-to-go/pkg/archive"
+)
+
+func TestWrite(t *testing.T) {
+	created := time.Date(2019, time.November, 15, 13, 5, 33, 0, time.UTC)
+	issues := []*archive.Issue{
+		{Number: 1, Title: "Test, \"quoted\"", State: "open", Author: "S7evinK", CreatedAt: created, Labels: []string{"bug", "help wanted"}},
+		{
+			Number: 2, Title: "Closed", State: "closed", Author: "alice", CreatedAt: created, ClosedAt: created.Add(time.Hour),
+			Comments: []archive.Comment{{Author: "bob", CreatedAt: created.Add(2 * time.Hour)}},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		columns []string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "default columns",
+			want: "number,title,state,author,created,closed,milestone,labels,comments,last_activity\n" +
+				"1,\"Test, \"\"quoted\"\"\",open,S7evinK,2019-11-15T13:05:33Z,,,\"bug, help wanted\",0,2019-11-15T13:05:33Z\n" +
+				"2,Closed,closed,alice,2019-11-15T13:05:33Z,2019-11-15T14:05:33Z,,,1,2019-11-15T15:05:33Z\n",
+		},
+		{
+			name:    "selected columns",
+			columns: []string{"number", "comments"},
+			want:    "number,comments\n1,0\n2,1\n",
+		},
+		{
+			name:    "normalized columns",
+			columns: []string{" Number", "COMMENTS "},
+			want:    "number,comments\n1,0\n2,1\n",
+		},
+		{
+			name:    "unknown column",
+			columns: []string{"number", "reactions"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := Write(&buf, issues, tt.columns)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Write() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && buf.String() != tt.want {
+				t.Errorf("Write() = %q, want %q", buf.String(), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,274 @@
+// Package stats computes metrics about the health of a project from the issues of an archive.
+package stats
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/S7evinK/issues-to-go/pkg/archive"
+)
+
+// Intervals of the timeline
+const (
+	Week  = "week"
+	Month = "month"
+)
+
+type (
+	// Stats are the metrics of a set of issues
+	Stats struct {
+		Open   int `json:"open"`
+		Closed int `json:"closed"`
+		// Timeline contains the number of open and closed issues at the end of each interval
+		Timeline            []Point     `json:"timeline"`
+		TimeToClose         Durations   `json:"time_to_close"`
+		TimeToFirstResponse Durations   `json:"time_to_first_response"`
+		Reporters           []Count     `json:"top_reporters"`
+		Commenters          []Count     `json:"top_commenters"`
+		Milestones          []Milestone `json:"milestones"`
+	}
+
+	// Point is the number of open and closed issues at a point in time
+	Point struct {
+		Time   time.Time `json:"time"`
+		Open   int       `json:"open"`
+		Closed int       `json:"closed"`
+	}
+
+	// Durations summarizes the distribution of durations, eg. the time it took to close issues
+	Durations struct {
+		Count  int
+		Median time.Duration
+		P90    time.Duration
+	}
+
+	// Count is the number of issues or comments of a user
+	Count struct {
+		User  string `json:"user"`
+		Count int    `json:"count"`
+	}
+
+	// Milestone is the number of open and closed issues of a milestone
+	Milestone struct {
+		Name   string `json:"name"`
+		Open   int    `json:"open"`
+		Closed int    `json:"closed"`
+	}
+)
+
+// Compute calculates the metrics of the issues. The timeline has one point at the end of each interval
+// (Week or Month) between the first issue and now, the lists of users are limited to top entries.
+func Compute(issues []*archive.Issue, interval string, top int, now time.Time) (*Stats, error) {
+	if interval != Week && interval != Month {
+		return nil, fmt.Errorf("unknown interval %q, available: %s, %s", interval, Week, Month)
+	}
+	s := &Stats{}
+	var (
+		toClose, toResponse []time.Duration
+		reporters           = make(map[string]int)
+		commenters          = make(map[string]int)
+		milestones          = make(map[string]*Milestone)
+	)
+	for _, i := range issues {
+		if i.Closed() {
+			s.Closed++
+			if !i.ClosedAt.IsZero() && !i.CreatedAt.IsZero() {
+				toClose = append(toClose, i.ClosedAt.Sub(i.CreatedAt))
+			}
+		} else {
+			s.Open++
+		}
+		if d, ok := firstResponse(i); ok {
+			toResponse = append(toResponse, d)
+		}
+		if i.Author != "" {
+			reporters[i.Author]++
+		}
+		for _, c := range i.Comments {
+			if c.Author != "" {
+				commenters[c.Author]++
+			}
+		}
+		if i.Milestone != "" {
+			m, ok := milestones[i.Milestone]
+			if !ok {
+				m = &Milestone{Name: i.Milestone}
+				milestones[i.Milestone] = m
+			}
+			if i.Closed() {
+				m.Closed++
+			} else {
+				m.Open++
+			}
+		}
+	}
+
+	s.Timeline = timeline(issues, interval, now)
+	s.TimeToClose = summarize(toClose)
+	s.TimeToFirstResponse = summarize(toResponse)
+	s.Reporters = ranking(reporters, top)
+	s.Commenters = ranking(commenters, top)
+	for _, m := range milestones {
+		s.Milestones = append(s.Milestones, *m)
+	}
+	sort.Slice(s.Milestones, func(i, j int) bool { return s.Milestones[i].Name < s.Milestones[j].Name })
+	return s, nil
+}
+
+// closedAt returns when the issue was closed, taken from the front matter or the "Closed on" footer.
+// Closed issues without a closing date, like hand-edited or corrupted files and footers which couldn't be parsed,
+// count as closed since their last activity.
+func closedAt(i *archive.Issue) time.Time {
+	if !i.ClosedAt.IsZero() {
+		return i.ClosedAt
+	}
+	return i.LastActivity()
+}
+
+// firstResponse returns the time until the first comment of someone else than the author
+func firstResponse(i *archive.Issue) (time.Duration, bool) {
+	for _, c := range i.Comments {
+		if c.Author != i.Author && !c.CreatedAt.IsZero() && !i.CreatedAt.IsZero() {
+			return c.CreatedAt.Sub(i.CreatedAt), true
+		}
+	}
+	return 0, false
+}
+
+// timeline counts the open and closed issues at the end of each interval
+func timeline(issues []*archive.Issue, interval string, now time.Time) []Point {
+	var first time.Time
+	for _, i := range issues {
+		if !i.CreatedAt.IsZero() && (first.IsZero() || i.CreatedAt.Before(first)) {
+			first = i.CreatedAt
+		}
+	}
+	if first.IsZero() {
+		return nil
+	}
+
+	var points []Point
+	for end := periodEnd(first, interval); ; end = periodEnd(end, interval) {
+		t := end
+		if t.After(now) {
+			t = now
+		}
+		p := Point{Time: t}
+		for _, i := range issues {
+			switch {
+			case i.CreatedAt.After(t):
+			case i.Closed() && !closedAt(i).After(t):
+				p.Closed++
+			default:
+				p.Open++
+			}
+		}
+		points = append(points, p)
+		if !end.Before(now) {
+			return points
+		}
+	}
+}
+
+// periodEnd returns the start of the week (Monday) or month following t
+func periodEnd(t time.Time, interval string) time.Time {
+	y, m, d := t.Date()
+	if interval == Week {
+		days := (8 - int(t.Weekday())) % 7
+		if days == 0 {
+			days = 7
+		}
+		return time.Date(y, m, d+days, 0, 0, 0, 0, t.Location())
+	}
+	return time.Date(y, m+1, 1, 0, 0, 0, 0, t.Location())
+}
+
+// summarize calculates the median and 90th percentile of durations
+func summarize(durations []time.Duration) Durations {
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+	return Durations{
+		Count:  len(durations),
+		Median: percentile(durations, 0.5),
+		P90:    percentile(durations, 0.9),
+	}
+}
+
+// percentile interpolates linearly between the closest ranks of the sorted durations
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := p * float64(len(sorted)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+	frac := rank - float64(lower)
+	return sorted[lower] + time.Duration(frac*float64(sorted[upper]-sorted[lower]))
+}
+
+// ranking returns the users with the highest counts, ties are sorted by name
+func ranking(counts map[string]int, top int) []Count {
+	list := make([]Count, 0, len(counts))
+	for user, n := range counts {
+		list = append(list, Count{User: user, Count: n})
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Count != list[j].Count {
+			return list[i].Count > list[j].Count
+		}
+		return list[i].User < list[j].User
+	})
+	if top > 0 && len(list) > top {
+		list = list[:top]
+	}
+	return list
+}
+
+// Percent returns the share of closed issues of the milestone
+func (m Milestone) Percent() float64 {
+	if m.Open+m.Closed == 0 {
+		return 0
+	}
+	return 100 * float64(m.Closed) / float64(m.Open+m.Closed)
+}
+
+// MarshalJSON adds the completion percentage to the milestone
+func (m Milestone) MarshalJSON() ([]byte, error) {
+	type milestone Milestone
+	return json.Marshal(struct {
+		milestone
+		Percent float64 `json:"percent"`
+	}{milestone(m), math.Round(m.Percent()*10) / 10})
+}
+
+// MarshalJSON writes the durations in hours, which is easier to read and process than nanoseconds
+func (d Durations) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Count  int     `json:"count"`
+		Median float64 `json:"median_hours"`
+		P90    float64 `json:"p90_hours"`
+	}{d.Count, hours(d.Median), hours(d.P90)})
+}
+
+func hours(d time.Duration) float64 {
+	return math.Round(d.Hours()*100) / 100
+}
+
+// FormatDuration formats d in days and hours, eg. "3d 4h"
+func FormatDuration(d time.Duration) string {
+	if d < time.Hour {
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	}
+	days, h := int(d.Hours())/24, int(d.Hours())%24
+	var parts []string
+	if days > 0 {
+		parts = append(parts, fmt.Sprintf("%dd", days))
+	}
+	if h > 0 || days == 0 {
+		parts = append(parts, fmt.Sprintf("%dh", h))
+	}
+	return strings.Join(parts, " ")
+}
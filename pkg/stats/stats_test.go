@@ -0,0 +1,120 @@
+package stats
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/S7evinK/issues-to-go/pkg/archive"
+)
+
+func TestCompute(t *testing.T) {
+	day := func(m time.Month, d int) time.Time { return time.Date(2019, m, d, 12, 0, 0, 0, time.UTC) }
+	issues := []*archive.Issue{
+		{Number: 1, State: "closed", Author: "alice", CreatedAt: day(10, 1), ClosedAt: day(10, 3), Milestone: "v1",
+			Comments: []archive.Comment{{Author: "alice", CreatedAt: day(10, 1).Add(time.Hour)}, {Author: "bob", CreatedAt: day(10, 2)}}},
+		{Number: 2, State: "closed", Author: "bob", CreatedAt: day(10, 5), ClosedAt: day(11, 4), Milestone: "v1",
+			Comments: []archive.Comment{{Author: "carol", CreatedAt: day(10, 5).Add(3 * time.Hour)}}},
+		{Number: 3, State: "open", Author: "alice", CreatedAt: day(11, 10), Milestone: "v2",
+			Comments: []archive.Comment{{Author: "bob", CreatedAt: day(11, 11)}}},
+		{Number: 4, State: "open", Author: "dave", CreatedAt: day(12, 1), Milestone: "v1"},
+	}
+	s, err := Compute(issues, Month, 2, day(12, 15))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if s.Open != 2 || s.Closed != 2 {
+		t.Errorf("got %d open and %d closed issues, want 2 and 2", s.Open, s.Closed)
+	}
+	wantTimeline := []Point{
+		{Time: time.Date(2019, 11, 1, 0, 0, 0, 0, time.UTC), Open: 1, Closed: 1},
+		{Time: time.Date(2019, 12, 1, 0, 0, 0, 0, time.UTC), Open: 1, Closed: 2},
+		{Time: day(12, 15), Open: 2, Closed: 2},
+	}
+	if !reflect.DeepEqual(s.Timeline, wantTimeline) {
+		t.Errorf("timeline = %+v, want %+v", s.Timeline, wantTimeline)
+	}
+	if want := (Durations{Count: 2, Median: 16 * 24 * time.Hour, P90: 2*24*time.Hour + 28*24*time.Hour*9/10}); s.TimeToClose != want {
+		t.Errorf("time to close = %+v, want %+v", s.TimeToClose, want)
+	}
+	if want := (Durations{Count: 3, Median: 24 * time.Hour, P90: 24 * time.Hour}); s.TimeToFirstResponse != want {
+		t.Errorf("time to first response = %+v, want %+v", s.TimeToFirstResponse, want)
+	}
+	if want := []Count{{"alice", 2}, {"bob", 1}}; !reflect.DeepEqual(s.Reporters, want) {
+		t.Errorf("reporters = %v, want %v", s.Reporters, want)
+	}
+	if want := []Count{{"bob", 2}, {"alice", 1}}; !reflect.DeepEqual(s.Commenters, want) {
+		t.Errorf("commenters = %v, want %v", s.Commenters, want)
+	}
+	if want := []Milestone{{"v1", 1, 2}, {"v2", 1, 0}}; !reflect.DeepEqual(s.Milestones, want) {
+		t.Errorf("milestones = %v, want %v", s.Milestones, want)
+	}
+
+	b, err := json.Marshal(s.Milestones[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"name":"v1","open":1,"closed":2,"percent":66.7}`; string(b) != want {
+		t.Errorf("milestone JSON = %s, want %s", b, want)
+	}
+
+	if _, err := Compute(issues, "year", 2, day(12, 15)); err == nil {
+		t.Error("expected an error for an unknown interval")
+	}
+}
+
+func TestPeriodEnd(t *testing.T) {
+	tests := []struct {
+		t        time.Time
+		interval string
+		want     time.Time
+	}{
+		{time.Date(2019, 12, 15, 8, 0, 0, 0, time.UTC), Month, time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)},
+		// Wednesday and Sunday end on the next Monday, a Monday on the Monday after
+		{time.Date(2019, 11, 13, 8, 0, 0, 0, time.UTC), Week, time.Date(2019, 11, 18, 0, 0, 0, 0, time.UTC)},
+		{time.Date(2019, 11, 17, 8, 0, 0, 0, time.UTC), Week, time.Date(2019, 11, 18, 0, 0, 0, 0, time.UTC)},
+		{time.Date(2019, 11, 18, 0, 0, 0, 0, time.UTC), Week, time.Date(2019, 11, 25, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		if got := periodEnd(tt.t, tt.interval); !got.Equal(tt.want) {
+			t.Errorf("periodEnd(%v, %s) = %v, want %v", tt.t, tt.interval, got, tt.want)
+		}
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	tests := map[time.Duration]string{
+		30 * time.Minute:             "30m",
+		5 * time.Hour:                "5h",
+		48 * time.Hour:               "2d",
+		3*24*time.Hour + 4*time.Hour: "3d 4h",
+	}
+	for d, want := range tests {
+		if got := FormatDuration(d); got != want {
+			t.Errorf("FormatDuration(%v) = %q, want %q", d, got, want)
+		}
+	}
+}
+
+func TestSVG(t *testing.T) {
+	var b bytes.Buffer
+	points := []Point{{Time: time.Date(2019, 11, 1, 0, 0, 0, 0, time.UTC), Open: 2}, {Time: time.Date(2019, 12, 1, 0, 0, 0, 0, time.UTC), Open: 1, Closed: 1}}
+	if err := TimelineSVG(&b, points); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(b.String(), "<svg") || strings.Count(b.String(), "<polyline") != 2 {
+		t.Errorf("unexpected timeline chart:\n%s", b.String())
+	}
+
+	b.Reset()
+	if err := MilestonesSVG(&b, []Milestone{{Name: "<v1>", Open: 1, Closed: 3}}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(b.String(), "&lt;v1&gt;") || !strings.Contains(b.String(), "75% (3/4)") {
+		t.Errorf("unexpected milestone chart:\n%s", b.String())
+	}
+}
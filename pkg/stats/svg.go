@@ -0,0 +1,108 @@
+package stats
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"io"
+)
+
+// size and margins of the charts
+const (
+	chartWidth  = 720
+	chartHeight = 320
+	marginLeft  = 50
+	marginRight = 20
+	marginTop   = 30
+	marginBot   = 40
+	barHeight   = 24
+	labelWidth  = 160
+)
+
+// colors of open and closed issues, like on Github
+const (
+	openColor   = "#2da44e"
+	closedColor = "#8250df"
+)
+
+// TimelineSVG draws the number of open and closed issues over time as line chart
+func TimelineSVG(w io.Writer, points []Point) error {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" font-family="sans-serif" font-size="12">`+"\n", chartWidth, chartHeight)
+	fmt.Fprintf(&b, `<rect width="100%%" height="100%%" fill="white"/>`+"\n")
+
+	max := 1
+	for _, p := range points {
+		if p.Open > max {
+			max = p.Open
+		}
+		if p.Closed > max {
+			max = p.Closed
+		}
+	}
+	plotWidth := float64(chartWidth - marginLeft - marginRight)
+	plotHeight := float64(chartHeight - marginTop - marginBot)
+	x := func(i int) float64 {
+		if len(points) < 2 {
+			return marginLeft + plotWidth/2
+		}
+		return marginLeft + plotWidth*float64(i)/float64(len(points)-1)
+	}
+	y := func(n int) float64 { return marginTop + plotHeight*(1-float64(n)/float64(max)) }
+
+	// axes with the maximum and the first and last date
+	fmt.Fprintf(&b, `<g stroke="#999"><line x1="%d" y1="%d" x2="%d" y2="%.1f"/><line x1="%d" y1="%.1f" x2="%d" y2="%.1f"/></g>`+"\n",
+		marginLeft, marginTop, marginLeft, y(0), marginLeft, y(0), chartWidth-marginRight, y(0))
+	fmt.Fprintf(&b, `<text x="%d" y="%.1f" text-anchor="end">%d</text>`+"\n", marginLeft-6, y(max)+4, max)
+	fmt.Fprintf(&b, `<text x="%d" y="%.1f" text-anchor="end">0</text>`+"\n", marginLeft-6, y(0)+4)
+	if len(points) > 0 {
+		fmt.Fprintf(&b, `<text x="%.1f" y="%.1f" text-anchor="start">%s</text>`+"\n", x(0), y(0)+18, points[0].Time.Format("2006-01-02"))
+		fmt.Fprintf(&b, `<text x="%.1f" y="%.1f" text-anchor="end">%s</text>`+"\n", x(len(points)-1), y(0)+18, points[len(points)-1].Time.Format("2006-01-02"))
+	}
+
+	for _, series := range []struct {
+		name  string
+		color string
+		value func(Point) int
+	}{
+		{"open", openColor, func(p Point) int { return p.Open }},
+		{"closed", closedColor, func(p Point) int { return p.Closed }},
+	} {
+		b.WriteString(`<polyline fill="none" stroke-width="2" stroke="` + series.color + `" points="`)
+		for i, p := range points {
+			fmt.Fprintf(&b, "%.1f,%.1f ", x(i), y(series.value(p)))
+		}
+		b.WriteString(`"/>` + "\n")
+	}
+
+	// legend
+	fmt.Fprintf(&b, `<rect x="%d" y="8" width="12" height="12" fill="%s"/><text x="%d" y="18">open</text>`+"\n", marginLeft, openColor, marginLeft+16)
+	fmt.Fprintf(&b, `<rect x="%d" y="8" width="12" height="12" fill="%s"/><text x="%d" y="18">closed</text>`+"\n", marginLeft+70, closedColor, marginLeft+86)
+	b.WriteString("</svg>\n")
+
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+// MilestonesSVG draws the completion of each milestone as horizontal bar chart
+func MilestonesSVG(w io.Writer, milestones []Milestone) error {
+	var b bytes.Buffer
+	height := marginTop + len(milestones)*(barHeight+8) + 10
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" font-family="sans-serif" font-size="12">`+"\n", chartWidth, height)
+	fmt.Fprintf(&b, `<rect width="100%%" height="100%%" fill="white"/>`+"\n")
+	fmt.Fprintf(&b, `<text x="10" y="18" font-weight="bold">Milestone completion</text>`+"\n")
+
+	barWidth := float64(chartWidth - labelWidth - 100)
+	for i, m := range milestones {
+		top := marginTop + i*(barHeight+8)
+		done := barWidth * m.Percent() / 100
+		fmt.Fprintf(&b, `<text x="%d" y="%d" text-anchor="end">%s</text>`+"\n", labelWidth-8, top+barHeight/2+4, html.EscapeString(m.Name))
+		fmt.Fprintf(&b, `<rect x="%d" y="%d" width="%.1f" height="%d" fill="%s" fill-opacity="0.25"/>`+"\n", labelWidth, top, barWidth, barHeight, openColor)
+		fmt.Fprintf(&b, `<rect x="%d" y="%d" width="%.1f" height="%d" fill="%s"/>`+"\n", labelWidth, top, done, barHeight, closedColor)
+		fmt.Fprintf(&b, `<text x="%.1f" y="%d">%.0f%% (%d/%d)</text>`+"\n", float64(labelWidth)+barWidth+8, top+barHeight/2+4, m.Percent(), m.Closed, m.Open+m.Closed)
+	}
+	b.WriteString("</svg>\n")
+
+	_, err := w.Write(b.Bytes())
+	return err
+}
@@ -0,0 +1,282 @@
+// Package triage records triage actions taken offline in a journal and replays them when online.
+package triage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/S7evinK/issues-to-go/pkg/archive"
+)
+
+// File is the name of the journal in the metadata folder of the archive
+const File = "triage.json"
+
+// Kind is the type of an action
+type Kind string
+
+// Kinds of actions
+const (
+	Close     Kind = "close"
+	Reopen    Kind = "reopen"
+	Label     Kind = "label"
+	Milestone Kind = "milestone"
+)
+
+// Action is a change of an issue recorded offline
+type Action struct {
+	ID       int       `json:"id"`
+	Kind     Kind      `json:"kind"`
+	Number   int       `json:"number"`
+	Recorded time.Time `json:"recorded"`
+	// Add and Remove are the labels added and removed by a label action
+	Add    []string `json:"add,omitempty"`
+	Remove []string `json:"remove,omitempty"`
+	// Milestone is the milestone set by a milestone action, empty removes the milestone
+	Milestone string `json:"milestone,omitempty"`
+	// Before is the milestone of the issue in the archive when a milestone action was recorded.
+	// BeforeUnknown is set if the archive doesn't tell, eg. without front matter and milestone symlinks.
+	Before        string `json:"before,omitempty"`
+	BeforeUnknown bool   `json:"before_unknown,omitempty"`
+	// Flag is the reason why the action wasn't replayed, empty if it wasn't replayed yet
+	Flag string `json:"flag,omitempty"`
+}
+
+func (a Action) String() string {
+	switch a.Kind {
+	case Label:
+		var changes []string
+		for _, l := range a.Add {
+			changes = append(changes, "+"+l)
+		}
+		for _, l := range a.Remove {
+			changes = append(changes, "-"+l)
+		}
+		return fmt.Sprintf("label #%d %s", a.Number, strings.Join(changes, " "))
+	case Milestone:
+		if a.Milestone == "" {
+			return fmt.Sprintf("remove milestone of #%d", a.Number)
+		}
+		return fmt.Sprintf("milestone #%d %s", a.Number, a.Milestone)
+	default:
+		return fmt.Sprintf("%s #%d", a.Kind, a.Number)
+	}
+}
+
+// Journal contains the actions which weren't replayed yet
+type Journal struct {
+	NextID  int      `json:"next_id"`
+	Actions []Action `json:"actions"`
+	path    string
+}
+
+// Open reads the journal of the archive in dir, a missing journal is empty
+func Open(dir string) (*Journal, error) {
+	j := &Journal{NextID: 1, path: filepath.Join(dir, archive.MetaDir, File)}
+	b, err := ioutil.ReadFile(j.path)
+	if os.IsNotExist(err) {
+		return j, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, j); err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+// Save writes the journal
+func (j *Journal) Save() error {
+	b, err := json.MarshalIndent(j, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(j.path), os.ModePerm); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(j.path, append(b, '\n'), 0644)
+}
+
+// Record adds an action to the journal and returns it with its ID
+func (j *Journal) Record(a Action) Action {
+	a.ID = j.NextID
+	j.NextID++
+	j.Actions = append(j.Actions, a)
+	return a
+}
+
+// Drop removes the action with the ID and reports whether it was found
+func (j *Journal) Drop(id int) bool {
+	for i, a := range j.Actions {
+		if a.ID == id {
+			j.Actions = append(j.Actions[:i], j.Actions[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// State is the current state of an issue on Github
+type State struct {
+	ID        string
+	State     string
+	Labels    []string
+	Milestone string
+}
+
+// Outcome is the result of checking an action against the current state of the issue
+type Outcome string
+
+// Outcomes of the check
+const (
+	// Apply means the action can be replayed
+	Apply Outcome = "apply"
+	// Done means the action was already taken by someone else, it's skipped
+	Done Outcome = "done"
+	// Conflict means the issue was changed since the action was recorded, it's flagged and kept in the journal
+	Conflict Outcome = "conflict"
+)
+
+// Check compares the action with the current state of the issue. Returns the outcome and its reason.
+// For label actions, the returned action contains only the labels which still have to be added or removed.
+func (a Action) Check(s *State) (Action, Outcome, string) {
+	switch a.Kind {
+	case Close, Reopen:
+		want := "closed"
+		if a.Kind == Reopen {
+			want = "open"
+		}
+		if s.State == want {
+			return a, Done, "the issue is already " + want
+		}
+	case Label:
+		has := make(map[string]bool)
+		for _, l := range s.Labels {
+			has[strings.ToLower(l)] = true
+		}
+		pending := a
+		pending.Add, pending.Remove = nil, nil
+		for _, l := range a.Add {
+			if !has[strings.ToLower(l)] {
+				pending.Add = append(pending.Add, l)
+			}
+		}
+		for _, l := range a.Remove {
+			if has[strings.ToLower(l)] {
+				pending.Remove = append(pending.Remove, l)
+			}
+		}
+		if len(pending.Add) == 0 && len(pending.Remove) == 0 {
+			return a, Done, "the labels are already set"
+		}
+		return pending, Apply, ""
+	case Milestone:
+		if s.Milestone == a.Milestone {
+			return a, Done, "the milestone is already set"
+		}
+		if !a.BeforeUnknown && !archive.MatchMilestone(s.Milestone, a.Before) {
+			current := s.Milestone
+			if current == "" {
+				current = "none"
+			}
+			return a, Conflict, fmt.Sprintf("the milestone was changed to %s", current)
+		}
+	}
+	return a, Apply, ""
+}
+
+// Tracker changes issues on Github
+type Tracker interface {
+	IssueState(ctx context.Context, number int) (*State, error)
+	CloseIssue(ctx context.Context, id string) error
+	ReopenIssue(ctx context.Context, id string) error
+	AddLabels(ctx context.Context, id string, labels []string) error
+	RemoveLabels(ctx context.Context, id string, labels []string) error
+	SetMilestone(ctx context.Context, id string, milestone string) error
+}
+
+// Result is the outcome of replaying an action
+type Result struct {
+	Action  Action
+	Outcome Outcome
+	Reason  string
+	Err     error
+}
+
+// Replay checks the actions of the journal against the current state of the issues and applies them.
+// Applied and skipped actions are removed from the journal, conflicts are flagged and kept unless force is set.
+// With dryRun the actions are only checked.
+func (j *Journal) Replay(ctx context.Context, t Tracker, force, dryRun bool) []Result {
+	var (
+		results []Result
+		kept    []Action
+	)
+	for _, a := range j.Actions {
+		if ctx.Err() != nil {
+			kept = append(kept, a)
+			continue
+		}
+		r := Result{Action: a}
+		s, err := t.IssueState(ctx, a.Number)
+		if err != nil {
+			r.Err = err
+			results = append(results, r)
+			if !dryRun {
+				a.Flag = err.Error()
+			}
+			kept = append(kept, a)
+			continue
+		}
+		pending, outcome, reason := a.Check(s)
+		r.Outcome, r.Reason = outcome, reason
+		if outcome == Conflict && force {
+			r.Outcome = Apply
+		}
+		if r.Outcome == Apply && !dryRun {
+			r.Err = apply(ctx, t, s.ID, pending)
+		}
+		results = append(results, r)
+
+		switch {
+		case dryRun:
+			kept = append(kept, a)
+		case r.Err != nil:
+			a.Flag = r.Err.Error()
+			kept = append(kept, a)
+		case r.Outcome == Conflict:
+			a.Flag = r.Reason
+			kept = append(kept, a)
+		}
+	}
+	j.Actions = kept
+	return results
+}
+
+// apply takes the action on the issue with the node ID
+func apply(ctx context.Context, t Tracker, id string, a Action) error {
+	switch a.Kind {
+	case Close:
+		return t.CloseIssue(ctx, id)
+	case Reopen:
+		return t.ReopenIssue(ctx, id)
+	case Label:
+		if len(a.Add) > 0 {
+			if err := t.AddLabels(ctx, id, a.Add); err != nil {
+				return err
+			}
+		}
+		if len(a.Remove) > 0 {
+			return t.RemoveLabels(ctx, id, a.Remove)
+		}
+		return nil
+	case Milestone:
+		return t.SetMilestone(ctx, id, a.Milestone)
+	}
+	return fmt.Errorf("unknown action %q", a.Kind)
+}
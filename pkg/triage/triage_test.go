@@ -0,0 +1,164 @@
+package triage
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestJournal(t *testing.T) {
+	dir, err := ioutil.TempDir("", "triage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	j, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	j.Record(Action{Kind: Close, Number: 1})
+	j.Record(Action{Kind: Label, Number: 2, Add: []string{"bug"}})
+	j.Record(Action{Kind: Milestone, Number: 3, Milestone: "v1.0"})
+	if !j.Drop(2) || j.Drop(7) {
+		t.Error("Drop() removed the wrong actions")
+	}
+	if err := j.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	j, err = Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(j.Actions) != 2 || j.Actions[0].ID != 1 || j.Actions[1].ID != 3 {
+		t.Fatalf("got actions %+v, want 1 and 3", j.Actions)
+	}
+	if a := j.Record(Action{Kind: Reopen, Number: 4}); a.ID != 4 {
+		t.Errorf("got id %d, want 4", a.ID)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name    string
+		action  Action
+		state   State
+		want    Outcome
+		pending Action
+	}{
+		{name: "close open issue", action: Action{Kind: Close}, state: State{State: "open"}, want: Apply},
+		{name: "close closed issue", action: Action{Kind: Close}, state: State{State: "closed"}, want: Done},
+		{name: "reopen closed issue", action: Action{Kind: Reopen}, state: State{State: "closed"}, want: Apply},
+		{name: "reopen open issue", action: Action{Kind: Reopen}, state: State{State: "open"}, want: Done},
+		{
+			name:    "labels partly set",
+			action:  Action{Kind: Label, Add: []string{"bug", "ui"}, Remove: []string{"question", "duplicate"}},
+			state:   State{Labels: []string{"Bug", "question"}},
+			want:    Apply,
+			pending: Action{Kind: Label, Add: []string{"ui"}, Remove: []string{"question"}},
+		},
+		{name: "labels set", action: Action{Kind: Label, Add: []string{"bug"}}, state: State{Labels: []string{"bug"}}, want: Done},
+		{name: "set milestone", action: Action{Kind: Milestone, Milestone: "v2", Before: "v1"}, state: State{Milestone: "v1"}, want: Apply},
+		{name: "milestone already set", action: Action{Kind: Milestone, Milestone: "v2", Before: "v1"}, state: State{Milestone: "v2"}, want: Done},
+		{name: "milestone changed", action: Action{Kind: Milestone, Milestone: "v2", Before: "v1"}, state: State{Milestone: "v3"}, want: Conflict},
+		{name: "milestone removed", action: Action{Kind: Milestone, Before: "v1"}, state: State{Milestone: "v1"}, want: Apply},
+		{name: "milestone from symlink", action: Action{Kind: Milestone, Milestone: "v2", Before: "v1_beta"}, state: State{Milestone: "v1/beta"}, want: Apply},
+		{name: "unknown milestone", action: Action{Kind: Milestone, Milestone: "v2", BeforeUnknown: true}, state: State{Milestone: "v3"}, want: Apply},
+		{name: "unknown milestone removed", action: Action{Kind: Milestone, BeforeUnknown: true}, state: State{Milestone: "v3"}, want: Apply},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pending, got, _ := tt.action.Check(&tt.state)
+			if got != tt.want {
+				t.Errorf("Check() = %s, want %s", got, tt.want)
+			}
+			if tt.pending.Kind != "" && !reflect.DeepEqual(pending, tt.pending) {
+				t.Errorf("got pending action %+v, want %+v", pending, tt.pending)
+			}
+		})
+	}
+}
+
+// fakeTracker records the calls and returns the states of the issues
+type fakeTracker struct {
+	states map[int]*State
+	calls  []string
+}
+
+func (f *fakeTracker) IssueState(ctx context.Context, number int) (*State, error) {
+	s, ok := f.states[number]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return s, nil
+}
+
+func (f *fakeTracker) CloseIssue(ctx context.Context, id string) error {
+	f.calls = append(f.calls, "close "+id)
+	return nil
+}
+
+func (f *fakeTracker) ReopenIssue(ctx context.Context, id string) error {
+	f.calls = append(f.calls, "reopen "+id)
+	return nil
+}
+
+func (f *fakeTracker) AddLabels(ctx context.Context, id string, labels []string) error {
+	f.calls = append(f.calls, "add "+id+" "+strings.Join(labels, ","))
+	return nil
+}
+
+func (f *fakeTracker) RemoveLabels(ctx context.Context, id string, labels []string) error {
+	f.calls = append(f.calls, "remove "+id+" "+strings.Join(labels, ","))
+	return nil
+}
+
+func (f *fakeTracker) SetMilestone(ctx context.Context, id string, milestone string) error {
+	f.calls = append(f.calls, "milestone "+id+" "+milestone)
+	return nil
+}
+
+func TestReplay(t *testing.T) {
+	tracker := &fakeTracker{states: map[int]*State{
+		1: {ID: "I1", State: "open", Labels: []string{"bug"}},
+		2: {ID: "I2", State: "closed"},
+		3: {ID: "I3", State: "open", Milestone: "v3"},
+	}}
+	newJournal := func() *Journal {
+		j := &Journal{NextID: 1}
+		j.Record(Action{Kind: Close, Number: 1})
+		j.Record(Action{Kind: Label, Number: 1, Add: []string{"bug", "ui"}})
+		j.Record(Action{Kind: Close, Number: 2})
+		j.Record(Action{Kind: Milestone, Number: 3, Milestone: "v2", Before: "v1"})
+		j.Record(Action{Kind: Reopen, Number: 4})
+		return j
+	}
+
+	j := newJournal()
+	results := j.Replay(context.Background(), tracker, false, true)
+	if len(results) != 5 || len(tracker.calls) != 0 || len(j.Actions) != 5 {
+		t.Fatalf("dry run changed something: %d results, calls %v, %d actions", len(results), tracker.calls, len(j.Actions))
+	}
+
+	j.Replay(context.Background(), tracker, false, false)
+	if want := []string{"close I1", "add I1 ui"}; !reflect.DeepEqual(tracker.calls, want) {
+		t.Errorf("got calls %v, want %v", tracker.calls, want)
+	}
+	if len(j.Actions) != 2 || j.Actions[0].Number != 3 || j.Actions[1].Number != 4 {
+		t.Fatalf("got kept actions %+v, want the conflict and the failure", j.Actions)
+	}
+	if j.Actions[0].Flag != "the milestone was changed to v3" || j.Actions[1].Flag != "not found" {
+		t.Errorf("unexpected flags %q and %q", j.Actions[0].Flag, j.Actions[1].Flag)
+	}
+
+	tracker.calls = nil
+	j.Replay(context.Background(), tracker, true, false)
+	if want := []string{"milestone I3 v2"}; !reflect.DeepEqual(tracker.calls, want) {
+		t.Errorf("got calls %v with force, want %v", tracker.calls, want)
+	}
+}
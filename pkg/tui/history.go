@@ -0,0 +1,45 @@
+package tui
+
+// History contains the issue files visited in the reader, like the history of a web browser
+type History struct {
+	entries []string
+	pos     int
+}
+
+// Visit adds path after the current entry. Entries visited after the current one are dropped.
+func (h *History) Visit(path string) {
+	if cur, ok := h.Current(); ok && cur == path {
+		return
+	}
+	if len(h.entries) > 0 {
+		h.entries = h.entries[:h.pos+1]
+	}
+	h.entries = append(h.entries, path)
+	h.pos = len(h.entries) - 1
+}
+
+// Current returns the current entry
+func (h *History) Current() (string, bool) {
+	if len(h.entries) == 0 {
+		return "", false
+	}
+	return h.entries[h.pos], true
+}
+
+// Back moves to the previous entry and returns it
+func (h *History) Back() (string, bool) {
+	if h.pos == 0 {
+		return "", false
+	}
+	h.pos--
+	return h.entries[h.pos], true
+}
+
+// Forward moves to the next entry and returns it
+func (h *History) Forward() (string, bool) {
+	if h.pos+1 >= len(h.entries) {
+		return "", false
+	}
+	h.pos++
+	return h.entries[h.pos], true
+}
@@ -0,0 +1,37 @@
+package tui
+
+import "testing"
+
+func TestHistory(t *testing.T) {
+	var h History
+	if _, ok := h.Back(); ok {
+		t.Fatal("empty history has no previous entry")
+	}
+	for _, p := range []string{"open/1.md", "open/2.md", "open/2.md", "closed/3.md"} {
+		h.Visit(p)
+	}
+
+	steps := []struct {
+		name string
+		move func() (string, bool)
+		want string
+		ok   bool
+	}{
+		{name: "back", move: h.Back, want: "open/2.md", ok: true},
+		{name: "duplicate visits are ignored", move: h.Back, want: "open/1.md", ok: true},
+		{name: "back at the start", move: h.Back},
+		{name: "forward", move: h.Forward, want: "open/2.md", ok: true},
+		{name: "visit drops forward entries", move: func() (string, bool) {
+			h.Visit("open/4.md")
+			return h.Forward()
+		}},
+		{name: "back after visit", move: h.Back, want: "open/2.md", ok: true},
+		{name: "current", move: h.Current, want: "open/2.md", ok: true},
+	}
+	for _, s := range steps {
+		got, ok := s.move()
+		if got != s.want || ok != s.ok {
+			t.Errorf("%s: got %q, %v, want %q, %v", s.name, got, ok, s.want, s.ok)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/S7evinK/issues-to-go/pkg/archive"
+	"github.com/S7evinK/issues-to-go/pkg/render"
+	"github.com/rivo/tview"
+)
+
+// markers of links in the rendered text, replaced by regions after the text is translated for tview
+var regionStart = regexp.MustCompile("\x02(\\d+)\x03")
+
+const regionEnd = "\x04"
+
+// link is a link to another issue file
+type link struct {
+	archive.Link
+	// Dest is the destination of the link, relative to the issue file containing it
+	Dest string
+}
+
+// thread renders an issue with its comments for a tview.TextView. Links to other issues become
+// regions, named after their index in the returned links.
+func thread(issue *archive.Issue) (string, []link) {
+	var links []link
+	text := render.Issue(issue, render.Styler(true), func(s, dest string) string {
+		l, ok := archive.ParseLink(dest)
+		if !ok {
+			return s
+		}
+		links = append(links, link{Link: l, Dest: dest})
+		return fmt.Sprintf("\x02%d\x03%s%s", len(links)-1, s, regionEnd)
+	})
+	// brackets of the text are escaped before the ANSI escape sequences become tview tags
+	text = tview.TranslateANSI(tview.Escape(text))
+	text = regionStart.ReplaceAllString(text, `["$1"]`)
+	return strings.Replace(text, regionEnd, `[""]`, -1), links
+}
+
+// resolve returns the file the link points to. Links to issues of the archive in dir are looked up by
+// number, since the issue may have been closed or reopened. Links to other archives are relative to from.
+func (l link) resolve(dir string, from *archive.Issue) (string, error) {
+	if l.Owner == "" {
+		issue, err := archive.Find(dir, l.Number)
+		if err != nil {
+			return "", err
+		}
+		return issue.Path, nil
+	}
+	dest := l.Dest
+	if i := strings.Index(dest, "#"); i >= 0 {
+		dest = dest[:i]
+	}
+	file := filepath.Join(filepath.Dir(from.Path), filepath.FromSlash(dest))
+	if _, err := os.Stat(file); err != nil {
+		return "", err
+	}
+	return file, nil
+}
@@ -0,0 +1,94 @@
+package tui
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/S7evinK/issues-to-go/pkg/archive"
+)
+
+func TestThread(t *testing.T) {
+	issue := &archive.Issue{
+		Number: 1,
+		Title:  "Crash [on start]",
+		State:  "open",
+		Author: "alice",
+		Body:   "Same as [#2](../closed/2.md) and [o/r#3](../../../o/r/open/3.md), see [docs](https://example.com)",
+		Comments: []archive.Comment{
+			{Author: "bob", Body: "Fixed by [#4](../open/4.md#issuecomment-12)"},
+		},
+	}
+	text, links := thread(issue)
+
+	want := []link{
+		{Link: archive.Link{Number: 2}, Dest: "../closed/2.md"},
+		{Link: archive.Link{Owner: "o", Repo: "r", Number: 3}, Dest: "../../../o/r/open/3.md"},
+		{Link: archive.Link{Number: 4, Fragment: "issuecomment-12"}, Dest: "../open/4.md#issuecomment-12"},
+	}
+	if len(links) != len(want) {
+		t.Fatalf("got %d links, want %d: %v", len(links), len(want), links)
+	}
+	for i := range want {
+		if links[i] != want[i] {
+			t.Errorf("link %d: got %+v, want %+v", i, links[i], want[i])
+		}
+	}
+	for _, region := range []string{`["0"]`, `["1"]`, `["2"]`, `[""]`} {
+		if !strings.Contains(text, region) {
+			t.Errorf("region %s missing in %q", region, text)
+		}
+	}
+	if !strings.Contains(text, "Crash [on start[]") {
+		t.Errorf("brackets of the title aren't escaped: %q", text)
+	}
+	if strings.ContainsAny(text, "\x1b\x02\x03\x04") {
+		t.Errorf("escape sequences or markers left in %q", text)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tui")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	archiveDir := filepath.Join(dir, "a", "b")
+	for _, f := range []string{filepath.Join(archiveDir, "closed", "2.md"), filepath.Join(dir, "o", "r", "open", "3.md")} {
+		if err := os.MkdirAll(filepath.Dir(f), os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(f, []byte("Title\n---\n\nCreated by alice on 2019-11-15 13:05:33 +0100 CET:\n\nBody\n\n---\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	from := &archive.Issue{Number: 1, Path: filepath.Join(archiveDir, "open", "1.md")}
+
+	tests := []struct {
+		name    string
+		link    link
+		want    string
+		missing bool
+	}{
+		{name: "moved issue", link: link{Link: archive.Link{Number: 2}, Dest: "../open/2.md"}, want: filepath.Join(archiveDir, "closed", "2.md")},
+		{name: "other archive", link: link{Link: archive.Link{Owner: "o", Repo: "r", Number: 3}, Dest: "../../../o/r/open/3.md#issuecomment-1"}, want: filepath.Join(dir, "o", "r", "open", "3.md")},
+		{name: "not downloaded", link: link{Link: archive.Link{Number: 5}, Dest: "../open/5.md"}, missing: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.link.resolve(archiveDir, from)
+			if tt.missing {
+				if !os.IsNotExist(err) {
+					t.Fatalf("expected missing file, got %q, %v", got, err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("got %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+}
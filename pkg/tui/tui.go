@@ -0,0 +1,328 @@
+// Package tui implements a full-screen terminal browser for the archive.
+//
+// The list of issues can be filtered by state, milestone and a Github search query, the selected
+// issue is shown with its comments in a reading pane. Links between issues can be followed with the
+// keyboard and visited issues are kept in a history.
+package tui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/S7evinK/issues-to-go/pkg/archive"
+	"github.com/S7evinK/issues-to-go/pkg/query"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// help lists the keys, shown in the status line
+const help = "/ filter · s state · m milestone · enter open · tab links · b/f back/forward · q quit"
+
+// states are cycled through by the state key, the empty state shows all issues
+var states = []string{"", "open", "closed"}
+
+// UI is the terminal interface of an archive
+type UI struct {
+	dir string
+
+	app    *tview.Application
+	table  *tview.Table
+	reader *tview.TextView
+	input  *tview.InputField
+	status *tview.TextView
+
+	issues     []*archive.Issue
+	milestones []string
+	shown      []*archive.Issue
+	state      string
+	milestone  string
+	query      string
+
+	history History
+	issue   *archive.Issue
+	links   []link
+	// selected is the index of the highlighted link in the reader, -1 if none is highlighted
+	selected int
+}
+
+// New creates the interface for the archive in dir
+func New(dir string) (*UI, error) {
+	issues, err := archive.Load(dir)
+	if err != nil && issues == nil {
+		return nil, err
+	}
+	u := &UI{dir: dir, issues: issues, selected: -1}
+	seen := make(map[string]bool)
+	for _, i := range issues {
+		if i.Milestone != "" && !seen[i.Milestone] {
+			seen[i.Milestone] = true
+			u.milestones = append(u.milestones, i.Milestone)
+		}
+	}
+	sort.Strings(u.milestones)
+
+	u.table = tview.NewTable().SetSelectable(true, false).SetFixed(1, 0)
+	u.table.SetBorder(true).SetTitle(" Issues ")
+	u.table.SetSelectedFunc(func(row, column int) {
+		if row > 0 && row <= len(u.shown) {
+			u.open(u.shown[row-1].Path, true)
+			u.app.SetFocus(u.reader)
+		}
+	})
+
+	u.reader = tview.NewTextView().SetDynamicColors(true).SetRegions(true).SetWordWrap(true)
+	u.reader.SetBorder(true)
+
+	u.input = tview.NewInputField().SetLabel("Filter: ").SetFieldBackgroundColor(tcell.ColorDefault)
+	u.input.SetDoneFunc(func(key tcell.Key) {
+		if key == tcell.KeyEnter {
+			u.query = u.input.GetText()
+			u.filter()
+		} else {
+			u.input.SetText(u.query)
+		}
+		u.app.SetFocus(u.table)
+	})
+
+	u.status = tview.NewTextView().SetDynamicColors(true)
+
+	panes := tview.NewFlex().
+		AddItem(u.table, 0, 2, true).
+		AddItem(u.reader, 0, 3, false)
+	root := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(panes, 0, 1, true).
+		AddItem(u.input, 1, 0, false).
+		AddItem(u.status, 1, 0, false)
+	u.app = tview.NewApplication().SetRoot(root, true).SetInputCapture(u.keys)
+
+	u.filter()
+	if err != nil {
+		// issues which can't be read are skipped
+		u.message("[red]" + tview.Escape(err.Error()))
+	}
+	return u, nil
+}
+
+// Run shows the interface until it's closed
+func (u *UI) Run() error {
+	return u.app.Run()
+}
+
+// keys handles the keys of the interface, other keys are passed to the focused pane
+func (u *UI) keys(event *tcell.EventKey) *tcell.EventKey {
+	if u.input.HasFocus() {
+		return event
+	}
+	reading := u.reader.HasFocus()
+
+	switch event.Key() {
+	case tcell.KeyTab:
+		if reading {
+			u.selectLink(1)
+		} else {
+			u.app.SetFocus(u.reader)
+		}
+		return nil
+	case tcell.KeyBacktab:
+		if reading {
+			u.selectLink(-1)
+		} else {
+			u.app.SetFocus(u.reader)
+		}
+		return nil
+	case tcell.KeyEnter:
+		if reading {
+			u.follow()
+			return nil
+		}
+	case tcell.KeyEscape:
+		u.app.SetFocus(u.table)
+		return nil
+	case tcell.KeyBackspace, tcell.KeyBackspace2:
+		u.back()
+		return nil
+	case tcell.KeyLeft:
+		if event.Modifiers()&tcell.ModAlt != 0 {
+			u.back()
+			return nil
+		}
+	case tcell.KeyRight:
+		if event.Modifiers()&tcell.ModAlt != 0 {
+			u.forward()
+			return nil
+		}
+	case tcell.KeyRune:
+		switch event.Rune() {
+		case 'q':
+			if reading {
+				u.app.SetFocus(u.table)
+			} else {
+				u.app.Stop()
+			}
+		case '/':
+			u.app.SetFocus(u.input)
+		case 's':
+			u.state = next(states, u.state)
+			u.filter()
+		case 'm':
+			u.milestone = next(append([]string{""}, u.milestones...), u.milestone)
+			u.filter()
+		case 'b':
+			u.back()
+		case 'f':
+			u.forward()
+		default:
+			return event
+		}
+		return nil
+	}
+	return event
+}
+
+// next returns the value following cur in values, wrapping around at the end
+func next(values []string, cur string) string {
+	for i, v := range values {
+		if v == cur {
+			return values[(i+1)%len(values)]
+		}
+	}
+	return values[0]
+}
+
+// filter shows the issues matching the state, milestone and query in the list
+func (u *UI) filter() {
+	q, err := query.Parse(u.query)
+	if err != nil {
+		u.message("[red]" + tview.Escape(err.Error()))
+		return
+	}
+	f := archive.Filter{State: u.state, Milestone: u.milestone}
+	u.shown = q.Apply(f.Apply(u.issues))
+	if q.SortKey == "" {
+		_ = archive.Sort(u.shown, "number", true)
+	}
+
+	u.table.Clear()
+	for col, title := range []string{"#", "Title", "Milestone"} {
+		u.table.SetCell(0, col, tview.NewTableCell(title).SetAttributes(tcell.AttrBold).SetSelectable(false))
+	}
+	for row, i := range u.shown {
+		color := tcell.ColorGreen
+		if i.Closed() {
+			color = tcell.ColorRed
+		}
+		u.table.SetCell(row+1, 0, tview.NewTableCell(strconv.Itoa(i.Number)).SetTextColor(color).SetAlign(tview.AlignRight))
+		u.table.SetCell(row+1, 1, tview.NewTableCell(tview.Escape(i.Title)).SetExpansion(1))
+		u.table.SetCell(row+1, 2, tview.NewTableCell(tview.Escape(i.Milestone)).SetTextColor(tcell.ColorGray))
+	}
+	u.table.ScrollToBeginning()
+	u.table.Select(1, 0)
+	u.markCurrent()
+	u.message("")
+}
+
+// markCurrent selects the issue shown in the reader in the list, if it's listed
+func (u *UI) markCurrent() {
+	if u.issue == nil {
+		return
+	}
+	for row, i := range u.shown {
+		if i.Path == u.issue.Path {
+			u.table.Select(row+1, 0)
+			return
+		}
+	}
+}
+
+// message shows msg in the status line, followed by the active filters and the keys
+func (u *UI) message(msg string) {
+	parts := []string{fmt.Sprintf("%d of %d issues", len(u.shown), len(u.issues))}
+	if u.state != "" {
+		parts = append(parts, "state: "+u.state)
+	}
+	if u.milestone != "" {
+		parts = append(parts, "milestone: "+tview.Escape(u.milestone))
+	}
+	status := strings.Join(parts, " · ") + " [gray]" + help
+	if msg != "" {
+		status = msg + "[-] · " + status
+	}
+	u.status.SetText(status)
+}
+
+// open shows the issue file in the reader, the file is added to the history if visit is set
+func (u *UI) open(path string, visit bool) {
+	issue, err := archive.ReadIssue(u.dir, path)
+	if err != nil {
+		u.message("[red]" + tview.Escape(err.Error()))
+		return
+	}
+	if visit {
+		u.history.Visit(path)
+	}
+	u.issue = issue
+	text, links := thread(issue)
+	u.links = links
+	u.selected = -1
+	u.reader.SetTitle(fmt.Sprintf(" #%d ", issue.Number))
+	u.reader.SetText(text).Highlight().ScrollToBeginning()
+	u.markCurrent()
+	u.message("")
+}
+
+// selectLink highlights the next (delta 1) or previous (delta -1) link in the reader
+func (u *UI) selectLink(delta int) {
+	if len(u.links) == 0 {
+		return
+	}
+	if u.selected < 0 && delta < 0 {
+		// backwards from the top starts at the last link
+		u.selected = 0
+	}
+	u.selected = (u.selected + delta + len(u.links)) % len(u.links)
+	u.reader.Highlight(strconv.Itoa(u.selected)).ScrollToHighlight()
+	l := u.links[u.selected]
+	if l.Owner != "" {
+		u.message(fmt.Sprintf("%s/%s#%d", l.Owner, l.Repo, l.Number))
+	} else {
+		u.message(fmt.Sprintf("#%d", l.Number))
+	}
+}
+
+// follow opens the issue of the highlighted link
+func (u *UI) follow() {
+	if u.selected < 0 || u.selected >= len(u.links) {
+		return
+	}
+	l := u.links[u.selected]
+	path, err := l.resolve(u.dir, u.issue)
+	if os.IsNotExist(err) {
+		if l.Owner != "" {
+			u.message("[yellow]Not downloaded: " + l.URL(u.issue.URL))
+		} else {
+			u.message(fmt.Sprintf("[yellow]Issue #%d not found in %s", l.Number, tview.Escape(filepath.Clean(u.dir))))
+		}
+		return
+	}
+	if err != nil {
+		u.message("[red]" + tview.Escape(err.Error()))
+		return
+	}
+	u.open(path, true)
+}
+
+func (u *UI) back() {
+	if path, ok := u.history.Back(); ok {
+		u.open(path, false)
+	}
+}
+
+func (u *UI) forward() {
+	if path, ok := u.history.Forward(); ok {
+		u.open(path, false)
+	}
+}
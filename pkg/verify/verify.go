@@ -0,0 +1,511 @@
+// Package verify checks the output folder of downloaded issues against the expected layout and repairs it.
+//
+// Issue files are expected in the open and closed folders as NUMBER.md, the milestones folder contains
+// symlinks to them as milestones/MILESTONE/STATE/NUMBER.md.
+package verify
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/S7evinK/issues-to-go/pkg/archive"
+	"github.com/S7evinK/issues-to-go/pkg/render"
+)
+
+// Kind is the type of a problem
+type Kind string
+
+// Kinds of problems, in the order they are repaired
+const (
+	// Unreadable is an issue file which can't be read or parsed
+	Unreadable Kind = "unreadable"
+	// StrayFile is a file or folder which doesn't belong to the layout of the archive
+	StrayFile Kind = "stray-file"
+	// Duplicate is an issue stored in more than one state folder
+	Duplicate Kind = "duplicate"
+	// WrongState is an issue file in the folder of another state than given in its front matter
+	WrongState Kind = "wrong-state"
+	// WrongNumber is an issue file whose name doesn't match the number in its front matter
+	WrongNumber Kind = "wrong-number"
+	// DanglingSymlink is a milestone symlink whose target doesn't exist
+	DanglingSymlink Kind = "dangling-symlink"
+	// WrongSymlink is a milestone entry, which isn't a symlink to the issue in the right state and milestone
+	WrongSymlink Kind = "wrong-symlink"
+	// MissingSymlink is an issue with a milestone, which is missing in the milestones folder
+	MissingSymlink Kind = "missing-symlink"
+	// BrokenLink is a link to an issue file which doesn't exist
+	BrokenLink Kind = "broken-link"
+)
+
+// phases groups the kinds of problems, which are repaired together. Later phases depend on the issue files
+// being in place, so the archive is checked again after each phase.
+var phases = [][]Kind{
+	{Duplicate, WrongState, WrongNumber},
+	{DanglingSymlink, WrongSymlink, MissingSymlink},
+	{BrokenLink},
+}
+
+// maxRounds limits the number of checks per phase, in case a fix doesn't resolve its problem
+const maxRounds = 10
+
+// Problem is an inconsistency of the archive
+type Problem struct {
+	Kind    Kind
+	Path    string
+	Message string
+	fix     func() error
+}
+
+// Fixable reports whether the problem can be repaired automatically
+func (p Problem) Fixable() bool {
+	return p.fix != nil
+}
+
+// Fix repairs the problem
+func (p Problem) Fix() error {
+	if p.fix == nil {
+		return fmt.Errorf("%s can't be repaired automatically", p.Path)
+	}
+	return p.fix()
+}
+
+func (p Problem) String() string {
+	return fmt.Sprintf("%s: %s: %s", p.Kind, p.Path, p.Message)
+}
+
+var (
+	// regexIssueFile matches the names of issue files
+	regexIssueFile = regexp.MustCompile(`^\d+\.md$`)
+	// regexLink matches the destination of Markdown links
+	regexLink = regexp.MustCompile(`\]\(([^)\s]+)\)`)
+)
+
+// checker collects the problems of an archive
+type checker struct {
+	dir      string
+	problems []Problem
+	// issues contains the readable issue files by number, more than one if the issue is duplicated
+	issues map[int][]*archive.Issue
+	// issueURL is the URL of an issue of the archive, empty if the archive has no front matter
+	issueURL string
+}
+
+// Check returns the problems of the archive in dir, sorted by path
+func Check(dir string) ([]Problem, error) {
+	c := &checker{dir: dir, issues: make(map[int][]*archive.Issue)}
+	if err := c.checkStates(); err != nil {
+		return nil, err
+	}
+	c.checkDuplicates()
+	if err := c.checkMilestones(); err != nil {
+		return nil, err
+	}
+	c.checkLinks()
+	sort.SliceStable(c.problems, func(i, j int) bool { return c.problems[i].Path < c.problems[j].Path })
+	return c.problems, nil
+}
+
+// Repair checks the archive and fixes all problems which can be repaired, phase by phase.
+// Only one problem per file is fixed at a time, the archive is checked again until no more
+// problems of the phase can be fixed. Returns the repaired problems and the remaining ones.
+func Repair(dir string) (fixed, remaining []Problem, err error) {
+	for _, phase := range phases {
+		for round := 0; round < maxRounds; round++ {
+			problems, err := Check(dir)
+			if err != nil {
+				return fixed, nil, err
+			}
+			touched := make(map[string]bool)
+			for _, p := range problems {
+				if !p.Fixable() || !contains(phase, p.Kind) || touched[p.Path] {
+					continue
+				}
+				if err := p.Fix(); err != nil {
+					return fixed, nil, err
+				}
+				touched[p.Path] = true
+				fixed = append(fixed, p)
+			}
+			if len(touched) == 0 {
+				break
+			}
+		}
+	}
+	remaining, err = Check(dir)
+	return fixed, remaining, err
+}
+
+func contains(kinds []Kind, k Kind) bool {
+	for _, kind := range kinds {
+		if kind == k {
+			return true
+		}
+	}
+	return false
+}
+
+// isState reports whether s is one of the state folders
+func isState(s string) bool {
+	for _, state := range archive.States {
+		if s == state {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *checker) add(kind Kind, path, msg string, fix func() error) {
+	c.problems = append(c.problems, Problem{Kind: kind, Path: path, Message: msg, fix: fix})
+}
+
+// checkStates reads the issue files in the state folders
+func (c *checker) checkStates() error {
+	for _, state := range archive.States {
+		files, err := ioutil.ReadDir(filepath.Join(c.dir, state))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		for _, info := range files {
+			path := filepath.Join(c.dir, state, info.Name())
+			if !info.Mode().IsRegular() || !regexIssueFile.MatchString(info.Name()) {
+				c.add(StrayFile, path, "not an issue file", nil)
+				continue
+			}
+			issue, err := archive.ReadFile(path)
+			if err != nil {
+				c.add(Unreadable, path, err.Error(), nil)
+				continue
+			}
+			if c.issueURL == "" {
+				c.issueURL = issue.URL
+			}
+			c.issues[issue.Number] = append(c.issues[issue.Number], issue)
+
+			number, _ := strconv.Atoi(strings.TrimSuffix(info.Name(), ".md"))
+			if issue.Number != number {
+				target := filepath.Join(c.dir, state, strconv.Itoa(issue.Number)+".md")
+				c.add(WrongNumber, path, fmt.Sprintf("contains issue %d", issue.Number), c.move(path, target))
+			}
+			if issue.State != state && isState(issue.State) {
+				target := filepath.Join(c.dir, issue.State, strconv.Itoa(issue.Number)+".md")
+				c.add(WrongState, path, fmt.Sprintf("issue is %s", issue.State), c.move(path, target))
+			}
+		}
+	}
+	return nil
+}
+
+// move returns a fix moving the file from path to target, nil if the target already exists
+func (c *checker) move(path, target string) func() error {
+	if _, err := os.Lstat(target); err == nil {
+		return nil
+	}
+	return func() error {
+		if err := os.MkdirAll(filepath.Dir(target), os.ModePerm); err != nil {
+			return err
+		}
+		return os.Rename(path, target)
+	}
+}
+
+// checkDuplicates reports issues stored more than once, the most recently updated file is kept
+func (c *checker) checkDuplicates() {
+	for number, issues := range c.issues {
+		if len(issues) < 2 {
+			continue
+		}
+		latest := issues[0]
+		for _, i := range issues[1:] {
+			if i.LastActivity().After(latest.LastActivity()) || i.LastActivity().Equal(latest.LastActivity()) && modTime(i.Path) > modTime(latest.Path) {
+				latest = i
+			}
+		}
+		for _, i := range issues {
+			if i == latest {
+				continue
+			}
+			path := i.Path
+			c.add(Duplicate, path, fmt.Sprintf("issue %d is also stored in %s", number, latest.Path), func() error {
+				return os.Remove(path)
+			})
+		}
+		c.issues[number] = []*archive.Issue{latest}
+	}
+}
+
+func modTime(path string) int64 {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0
+	}
+	return info.ModTime().UnixNano()
+}
+
+// current returns the issue with the number, if it's stored exactly once
+func (c *checker) current(number int) (*archive.Issue, bool) {
+	issues := c.issues[number]
+	if len(issues) != 1 {
+		return nil, false
+	}
+	return issues[0], true
+}
+
+// milestoneDir returns the folder of a milestone, slashes can't be part of folder names
+func milestoneDir(milestone string) string {
+	return strings.Replace(milestone, "/", "_", -1)
+}
+
+// checkMilestones checks the symlinks in the milestones folder. Missing symlinks are only reported,
+// if the archive has a milestones folder.
+func (c *checker) checkMilestones() error {
+	root := filepath.Join(c.dir, "milestones")
+	milestones, err := ioutil.ReadDir(root)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	linked := make(map[string]bool)
+	for _, ms := range milestones {
+		if !ms.IsDir() {
+			c.add(StrayFile, filepath.Join(root, ms.Name()), "not a milestone folder", nil)
+			continue
+		}
+		states, err := ioutil.ReadDir(filepath.Join(root, ms.Name()))
+		if err != nil {
+			return err
+		}
+		for _, state := range states {
+			dir := filepath.Join(root, ms.Name(), state.Name())
+			if !state.IsDir() || !isState(state.Name()) {
+				c.add(StrayFile, dir, "not a state folder", nil)
+				continue
+			}
+			entries, err := ioutil.ReadDir(dir)
+			if err != nil {
+				return err
+			}
+			for _, e := range entries {
+				path := filepath.Join(dir, e.Name())
+				if !regexIssueFile.MatchString(e.Name()) {
+					c.add(StrayFile, path, "not an issue link", nil)
+					continue
+				}
+				number, _ := strconv.Atoi(strings.TrimSuffix(e.Name(), ".md"))
+				linked[path] = c.checkSymlink(path, ms.Name(), state.Name(), number, e)
+			}
+		}
+	}
+
+	// only the front matter tells which symlinks are missing
+	for _, issues := range c.issues {
+		if len(issues) != 1 || issues[0].Milestone == "" {
+			continue
+		}
+		i := issues[0]
+		path := c.symlinkPath(i, milestoneDir(i.Milestone))
+		if !linked[path] {
+			c.add(MissingSymlink, path, fmt.Sprintf("issue %d is part of milestone %s", i.Number, i.Milestone), c.relink(path, i))
+		}
+	}
+	return nil
+}
+
+// symlinkPath returns the path of the symlink of an issue in the folder of a milestone
+func (c *checker) symlinkPath(i *archive.Issue, milestone string) string {
+	return filepath.Join(c.dir, "milestones", milestone, filepath.Base(filepath.Dir(i.Path)), strconv.Itoa(i.Number)+".md")
+}
+
+// checkSymlink checks a milestone entry. Reports whether the entry is the expected symlink of the issue,
+// in which case a problem with it is fixed by recreating the symlink. Without front matter the milestone
+// of an issue is only known from its symlinks, so the milestone folder of the entry is taken as correct.
+func (c *checker) checkSymlink(path, milestone, state string, number int, info os.FileInfo) bool {
+	issue, ok := c.current(number)
+	ms := milestone
+	if ok && issue.FrontMatter {
+		ms = milestoneDir(issue.Milestone)
+	}
+	expected := ok && ms != "" && c.symlinkPath(issue, ms) == path
+	fix := func() error { return os.Remove(path) }
+	switch {
+	case expected:
+		fix = c.relink(path, issue)
+	case ok && !issue.FrontMatter:
+		// the issue was closed or reopened, the symlink is moved to the folder of its state
+		relink := c.relink(c.symlinkPath(issue, ms), issue)
+		fix = func() error {
+			if err := relink(); err != nil {
+				return err
+			}
+			return os.Remove(path)
+		}
+	}
+
+	if info.Mode()&os.ModeSymlink == 0 {
+		if !expected {
+			fix = nil
+		}
+		c.add(WrongSymlink, path, "not a symlink", fix)
+		return expected
+	}
+
+	target, err := os.Stat(path)
+	switch {
+	case err != nil:
+		c.add(DanglingSymlink, path, "target doesn't exist", fix)
+	case !ok:
+		c.add(WrongSymlink, path, fmt.Sprintf("issue %d isn't part of the archive", number), fix)
+	case ms == "":
+		c.add(WrongSymlink, path, fmt.Sprintf("issue %d has no milestone", number), fix)
+	case ms != milestone:
+		c.add(WrongSymlink, path, fmt.Sprintf("issue %d is part of milestone %s", number, issue.Milestone), fix)
+	case filepath.Base(filepath.Dir(issue.Path)) != state:
+		c.add(WrongSymlink, path, fmt.Sprintf("issue %d is %s", number, filepath.Base(filepath.Dir(issue.Path))), fix)
+	default:
+		if actual, err := os.Stat(issue.Path); err != nil || !os.SameFile(target, actual) {
+			c.add(WrongSymlink, path, fmt.Sprintf("doesn't point to %s", issue.Path), fix)
+		}
+	}
+	return expected
+}
+
+// relink returns a fix replacing path with a symlink to the issue file, relative to the milestone folder
+func (c *checker) relink(path string, issue *archive.Issue) func() error {
+	return func() error {
+		if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+			return err
+		}
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		target := filepath.Join("..", "..", "..", filepath.Base(filepath.Dir(issue.Path)), filepath.Base(issue.Path))
+		return os.Symlink(target, path)
+	}
+}
+
+// checkLinks reports links to issue files which don't exist. Links to issues of the archive are
+// pointed to the current file of the issue or to its URL, links to other archives are pointed to their URL.
+// Links in code and HTML are left untouched.
+func (c *checker) checkLinks() {
+	for _, issues := range c.issues {
+		for _, issue := range issues {
+			content, err := ioutil.ReadFile(issue.Path)
+			if err != nil {
+				continue
+			}
+			replacements := make(map[string]string)
+			fixable := -1
+			for _, m := range proseLinks(content) {
+				dest := string(content[m[2]:m[3]])
+				link, ok := archive.ParseLink(dest)
+				if _, seen := replacements[dest]; !ok || seen || c.exists(issue.Path, dest) {
+					continue
+				}
+				replacement := c.replacement(issue.Path, dest, link)
+				replacements[dest] = replacement
+				if replacement == "" {
+					c.add(BrokenLink, issue.Path, fmt.Sprintf("link to missing issue %d: %s", link.Number, dest), nil)
+					continue
+				}
+				if fixable < 0 {
+					fixable = len(c.problems)
+				}
+				c.add(BrokenLink, issue.Path, fmt.Sprintf("link to missing file %s, replace by %s", dest, replacement), nil)
+			}
+			// all links of a file are fixed at once, so the fix is attached to the first fixable problem only
+			if fixable >= 0 {
+				c.problems[fixable].fix = fixLinks(issue.Path, replacements)
+			}
+		}
+	}
+}
+
+// exists reports whether the file of a link destination exists relative to the linking file,
+// or relative to the archive if the destination starts with a slash
+func (c *checker) exists(from, dest string) bool {
+	if i := strings.Index(dest, "#"); i >= 0 {
+		dest = dest[:i]
+	}
+	base := filepath.Dir(from)
+	if strings.HasPrefix(dest, "/") {
+		base = c.dir
+	}
+	_, err := os.Stat(filepath.Join(base, filepath.FromSlash(dest)))
+	return err == nil
+}
+
+// replacement returns the new destination of a broken link to dest in the file from, empty if there is none.
+// Links relative to the archive are replaced by links relative to the archive.
+func (c *checker) replacement(from, dest string, link archive.Link) string {
+	if link.Owner != "" {
+		return link.URL(c.issueURL)
+	}
+	if target, ok := c.current(link.Number); ok {
+		state := filepath.Base(filepath.Dir(target.Path))
+		root := strings.HasPrefix(dest, "/")
+		dest = filepath.Base(target.Path)
+		switch {
+		case root:
+			dest = "/" + state + "/" + dest
+		case state != filepath.Base(filepath.Dir(from)):
+			dest = "../" + state + "/" + dest
+		}
+		if link.Fragment != "" {
+			dest += "#" + link.Fragment
+		}
+		return dest
+	}
+	return link.URL(c.issueURL)
+}
+
+// fixLinks returns a fix replacing the destinations of links in the file
+func fixLinks(path string, replacements map[string]string) func() error {
+	return func() error {
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		var (
+			fixed []byte
+			last  int
+		)
+		for _, m := range proseLinks(content) {
+			if r := replacements[string(content[m[2]:m[3]])]; r != "" {
+				fixed = append(append(fixed, content[last:m[2]]...), r...)
+				last = m[3]
+			}
+		}
+		fixed = append(fixed, content[last:]...)
+		return ioutil.WriteFile(path, fixed, os.ModePerm)
+	}
+}
+
+// proseLinks returns the submatch indices of regexLink in content, which aren't part of code or HTML
+func proseLinks(content []byte) [][]int {
+	var links [][]int
+	code := render.CodeRanges(content)
+	for _, m := range regexLink.FindAllSubmatchIndex(content, -1) {
+		inCode := false
+		for _, r := range code {
+			if r.Contains(m[0]) {
+				inCode = true
+				break
+			}
+		}
+		if !inCode {
+			links = append(links, m)
+		}
+	}
+	return links
+}
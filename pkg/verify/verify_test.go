@@ -0,0 +1,210 @@
+package verify
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// issueFile returns the content of an issue file with front matter
+func issueFile(number, state, updated, milestone, body string) string {
+	content := "---\nnumber: " + number + "\ntitle: Issue " + number + "\nstate: " + state + "\nauthor: S7evinK\n" +
+		"created: 2019-11-15T13:05:33+01:00\nupdated: " + updated + "\n"
+	if milestone != "" {
+		content += "milestone: " + milestone + "\n"
+	}
+	return content + "url: https://github.com/S7evinK/issues-to-go/issues/" + number + "\n---\n\n" +
+		"Issue " + number + "\n---\n\nCreated by S7evinK on 2019-11-15 13:05:33 +0100 CET:\n\n" + body + "\n\n---\n"
+}
+
+// setup creates an archive with one problem of each kind
+func setup(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "verify")
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"open/1.md":    issueFile("1", "open", "2019-11-16T10:00:00+01:00", "v1.0", "See [#2](2.md), [#9](9.md) and [#4](../closed/4.md#issuecomment-1)"),
+		"closed/2.md":  issueFile("2", "closed", "2019-11-16T10:00:00+01:00", "", "Closed"),
+		"open/3.md":    issueFile("3", "closed", "2019-11-16T10:00:00+01:00", "", "Closed, but stored as open"),
+		"open/4.md":    issueFile("4", "open", "2019-11-16T10:00:00+01:00", "", "Reopened earlier"),
+		"closed/4.md":  issueFile("4", "closed", "2019-11-17T10:00:00+01:00", "", "Closed later"),
+		"open/50.md":   issueFile("5", "open", "2019-11-16T10:00:00+01:00", "", "Wrong name"),
+		"open/6.md":    "---\nnumber: [\n---\n",
+		"open/notes":   "not an issue",
+		".meta/x.json": "{}",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, state := range []string{"open", "closed"} {
+		if err := os.MkdirAll(filepath.Join(dir, "milestones", "v1.0", state), os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+	}
+	symlinks := map[string]string{
+		"milestones/v1.0/closed/7.md": "../../../closed/7.md",
+		"milestones/v1.0/closed/2.md": "../../../closed/2.md",
+	}
+	for name, target := range symlinks {
+		if err := os.Symlink(target, filepath.Join(dir, filepath.FromSlash(name))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// kinds returns the kinds of the problems by path relative to dir
+func kinds(dir string, problems []Problem) map[string][]Kind {
+	got := make(map[string][]Kind)
+	for _, p := range problems {
+		rel, _ := filepath.Rel(dir, p.Path)
+		got[filepath.ToSlash(rel)] = append(got[filepath.ToSlash(rel)], p.Kind)
+	}
+	for _, k := range got {
+		sort.Slice(k, func(i, j int) bool { return k[i] < k[j] })
+	}
+	return got
+}
+
+func TestCheck(t *testing.T) {
+	dir := setup(t)
+	defer os.RemoveAll(dir)
+
+	problems, err := Check(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string][]Kind{
+		"open/1.md":                   {BrokenLink, BrokenLink},
+		"open/3.md":                   {WrongState},
+		"open/4.md":                   {Duplicate},
+		"open/50.md":                  {WrongNumber},
+		"open/6.md":                   {Unreadable},
+		"open/notes":                  {StrayFile},
+		"milestones/v1.0/closed/2.md": {WrongSymlink},
+		"milestones/v1.0/closed/7.md": {DanglingSymlink},
+		"milestones/v1.0/open/1.md":   {MissingSymlink},
+	}
+	if got := kinds(dir, problems); !reflect.DeepEqual(got, want) {
+		t.Errorf("got problems %v, want %v", got, want)
+	}
+}
+
+func TestRepair(t *testing.T) {
+	dir := setup(t)
+	defer os.RemoveAll(dir)
+
+	fixed, remaining, err := Repair(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fixed) == 0 {
+		t.Error("no problems were fixed")
+	}
+	want := map[string][]Kind{
+		"open/6.md":  {Unreadable},
+		"open/notes": {StrayFile},
+	}
+	if got := kinds(dir, remaining); !reflect.DeepEqual(got, want) {
+		t.Errorf("got remaining problems %v, want %v", got, want)
+	}
+
+	for _, name := range []string{"closed/3.md", "closed/4.md", "open/5.md"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("%s wasn't repaired: %v", name, err)
+		}
+	}
+	for _, name := range []string{"open/3.md", "open/4.md", "open/50.md", "milestones/v1.0/closed/2.md", "milestones/v1.0/closed/7.md"} {
+		if _, err := os.Lstat(filepath.Join(dir, name)); !os.IsNotExist(err) {
+			t.Errorf("%s wasn't removed", name)
+		}
+	}
+	if target, err := os.Readlink(filepath.Join(dir, "milestones", "v1.0", "open", "1.md")); err != nil || target != filepath.Join("..", "..", "..", "open", "1.md") {
+		t.Errorf("got symlink to %q (%v), want ../../../open/1.md", target, err)
+	}
+
+	b, err := ioutil.ReadFile(filepath.Join(dir, "open", "1.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want1 := issueFile("1", "open", "2019-11-16T10:00:00+01:00", "v1.0",
+		"See [#2](../closed/2.md), [#9](https://github.com/S7evinK/issues-to-go/issues/9) and [#4](../closed/4.md#issuecomment-1)")
+	if string(b) != want1 {
+		t.Errorf("got links\n%s\nwant\n%s", b, want1)
+	}
+}
+
+func TestRepairWithoutFrontMatter(t *testing.T) {
+	dir, err := ioutil.TempDir("", "verify")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"open/1.md":   "Issue 1\n---\n\nCreated by S7evinK on 2019-11-15 13:05:33 +0100 CET:\n\nSee [#1](/open/1.md) and [#2](/open/2.md)\n\n    [#2](/open/2.md)\n\n---\n",
+		"closed/2.md": "Issue 2\n---\n\nCreated by S7evinK on 2019-11-15 13:05:33 +0100 CET:\n\nClosed\n\n---\nClosed on 2019-11-16 10:00:00 +0100 CET",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// issue 2 was closed after its symlink was created
+	symlinks := map[string]string{
+		"milestones/v1.0/open/1.md": "../../../open/1.md",
+		"milestones/v2.0/open/2.md": "../../../open/2.md",
+	}
+	for name, target := range symlinks {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Symlink(target, path); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	problems, err := Check(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string][]Kind{"open/1.md": {BrokenLink}, "milestones/v2.0/open/2.md": {DanglingSymlink}}
+	if got := kinds(dir, problems); !reflect.DeepEqual(got, want) {
+		t.Errorf("got problems %v, want %v", got, want)
+	}
+
+	if _, remaining, err := Repair(dir); err != nil || len(remaining) > 0 {
+		t.Fatalf("got remaining problems %v (%v), want none", remaining, err)
+	}
+	if target, err := os.Readlink(filepath.Join(dir, "milestones", "v1.0", "open", "1.md")); err != nil || target != filepath.Join("..", "..", "..", "open", "1.md") {
+		t.Errorf("got symlink to %q (%v), want ../../../open/1.md", target, err)
+	}
+	if target, err := os.Readlink(filepath.Join(dir, "milestones", "v2.0", "closed", "2.md")); err != nil || target != filepath.Join("..", "..", "..", "closed", "2.md") {
+		t.Errorf("got symlink to %q (%v), want ../../../closed/2.md", target, err)
+	}
+	if _, err := os.Lstat(filepath.Join(dir, "milestones", "v2.0", "open", "2.md")); !os.IsNotExist(err) {
+		t.Error("milestones/v2.0/open/2.md wasn't removed")
+	}
+	// links relative to the archive stay relative to the archive
+	if b, err := ioutil.ReadFile(filepath.Join(dir, "open", "1.md")); err != nil || !strings.Contains(string(b), "See [#1](/open/1.md) and [#2](/closed/2.md)") {
+		t.Errorf("got open/1.md %q (%v), want the link to /closed/2.md", b, err)
+	} else if !strings.Contains(string(b), "    [#2](/open/2.md)\n") {
+		t.Errorf("got open/1.md %q, want the code block unchanged", b)
+	}
+}
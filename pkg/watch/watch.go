@@ -0,0 +1,149 @@
+// Package watch runs a task periodically and reports its health to supervisors like systemd or Kubernetes.
+package watch
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Run calls task immediately and then every interval, delayed by a random duration up to jitter, until ctx is done.
+// The context passed to task is ctx, so the task can finish its work when ctx is cancelled.
+func Run(ctx context.Context, interval, jitter time.Duration, task func(ctx context.Context)) {
+	for {
+		task(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		t := time.NewTimer(Delay(interval, jitter, rand.Int63n))
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// Delay returns interval plus a random duration in [0, jitter) obtained from random
+func Delay(interval, jitter time.Duration, random func(n int64) int64) time.Duration {
+	if jitter <= 0 {
+		return interval
+	}
+	return interval + time.Duration(random(int64(jitter)))
+}
+
+// Health is the state of the periodic task, it can be written to a file and served over HTTP
+type Health struct {
+	mu sync.Mutex
+
+	Status string
+	Cycles int
+	// Failures is the number of consecutive failed runs
+	Failures    int
+	LastRun     time.Time
+	LastSuccess time.Time
+	Error       string
+	Changes     int
+	// MaxAge is the time after which the task is considered unhealthy without a successful run
+	MaxAge time.Duration
+	start  time.Time
+}
+
+// Statuses of the task
+const (
+	StatusStarting = "starting"
+	StatusOK       = "ok"
+	StatusFailing  = "failing"
+)
+
+// NewHealth creates the health of a task, which is unhealthy if it didn't succeed for maxAge
+func NewHealth(maxAge time.Duration, now time.Time) *Health {
+	return &Health{Status: StatusStarting, MaxAge: maxAge, start: now}
+}
+
+// Record updates the health with the result of a run
+func (h *Health) Record(err error, changes int, now time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.Cycles++
+	h.LastRun = now
+	if err != nil {
+		h.Status = StatusFailing
+		h.Failures++
+		h.Error = err.Error()
+		return
+	}
+	h.Status = StatusOK
+	h.Failures = 0
+	h.Error = ""
+	h.LastSuccess = now
+	h.Changes = changes
+}
+
+// Healthy reports whether the task succeeded within MaxAge. Before the first success the start counts as success.
+func (h *Health) Healthy(now time.Time) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	last := h.LastSuccess
+	if last.IsZero() {
+		last = h.start
+	}
+	return now.Sub(last) <= h.MaxAge
+}
+
+func (h *Health) marshal() ([]byte, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	optional := func(t time.Time) *time.Time {
+		if t.IsZero() {
+			return nil
+		}
+		return &t
+	}
+	return json.MarshalIndent(struct {
+		Status      string     `json:"status"`
+		Cycles      int        `json:"cycles"`
+		Failures    int        `json:"consecutive_failures"`
+		LastRun     *time.Time `json:"last_run,omitempty"`
+		LastSuccess *time.Time `json:"last_success,omitempty"`
+		Error       string     `json:"error,omitempty"`
+		Changes     int        `json:"changes"`
+	}{h.Status, h.Cycles, h.Failures, optional(h.LastRun), optional(h.LastSuccess), h.Error, h.Changes}, "", "  ")
+}
+
+// WriteFile writes the health as JSON to path, replacing the file atomically
+func (h *Health) WriteFile(path string) error {
+	b, err := h.marshal()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, append(b, '\n'), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// ServeHTTP responds with the health as JSON, the status code is 503 if the task is unhealthy
+func (h *Health) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	b, err := h.marshal()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if !h.Healthy(time.Now()) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	_, _ = w.Write(append(b, '\n'))
+}
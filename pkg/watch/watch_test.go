@@ -0,0 +1,106 @@
+package watch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRun(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	runs := 0
+	done := make(chan struct{})
+	go func() {
+		Run(ctx, time.Millisecond, time.Millisecond, func(ctx context.Context) {
+			runs++
+			if runs == 3 {
+				cancel()
+			}
+		})
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run didn't return after the context was cancelled")
+	}
+	if runs != 3 {
+		t.Errorf("got %d runs, want 3", runs)
+	}
+}
+
+func TestDelay(t *testing.T) {
+	half := func(n int64) int64 { return n / 2 }
+	if got := Delay(time.Minute, 10*time.Second, half); got != time.Minute+5*time.Second {
+		t.Errorf("got %v, want 1m5s", got)
+	}
+	if got := Delay(time.Minute, 0, half); got != time.Minute {
+		t.Errorf("got %v without jitter, want 1m", got)
+	}
+}
+
+func TestHealth(t *testing.T) {
+	start := time.Date(2019, 11, 20, 10, 0, 0, 0, time.UTC)
+	h := NewHealth(time.Hour, start)
+
+	steps := []struct {
+		name    string
+		err     error
+		at      time.Time
+		status  string
+		healthy bool
+	}{
+		{name: "first failure after start", err: errors.New("timeout"), at: start.Add(30 * time.Minute), status: StatusFailing, healthy: true},
+		{name: "failing too long", err: errors.New("timeout"), at: start.Add(90 * time.Minute), status: StatusFailing, healthy: false},
+		{name: "recovered", at: start.Add(2 * time.Hour), status: StatusOK, healthy: true},
+	}
+	for _, s := range steps {
+		h.Record(s.err, 1, s.at)
+		if h.Status != s.status || h.Healthy(s.at) != s.healthy {
+			t.Errorf("%s: got status %s and healthy %v, want %s and %v", s.name, h.Status, h.Healthy(s.at), s.status, s.healthy)
+		}
+	}
+	if h.Cycles != 3 || h.Failures != 0 || h.Error != "" {
+		t.Errorf("unexpected health after recovery: %+v", h)
+	}
+}
+
+func TestHealthOutput(t *testing.T) {
+	dir, err := ioutil.TempDir("", "watch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	h := NewHealth(time.Hour, time.Now().Add(-2*time.Hour))
+	h.Record(errors.New("bad credentials"), 0, time.Now())
+
+	path := filepath.Join(dir, ".meta", "health.json")
+	if err := h.WriteFile(path); err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]interface{}
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got["status"] != StatusFailing || got["error"] != "bad credentials" {
+		t.Errorf("unexpected health file: %s", b)
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/health", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("got status code %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+}